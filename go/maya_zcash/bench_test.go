@@ -0,0 +1,113 @@
+//go:build bench
+
+package maya_zcash
+
+// The library reads ZCASHD_URL the first time it is called, so
+// the benchmarks run alone against a fake node:
+//   go test -tags bench -run '^$' -bench . ./maya_zcash
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// The regtest deposit of 0.1 ZEC from tmP9jLgTnhDdKdWJCm4BT2t6acGnxqP14yU.
+// The fake node returns it for every txid, so all the txs of
+// a block spend the same parent, like the inbound txs of
+// an exchange that pays many users from one hot wallet
+const benchDeposit = "050000800a27a7265510e7c800000000f00000000186b1a9c7f46c7550e48fa0781495ef891ed81b48506ef53a28c3e83a223f6482000000006a473044022014e4bc7f7ab7034fe1992ee128484e72864e7d08380b2e663b43c2d490aa26190220643a69a289195a62f9c85c208a27d417f847fd1ea94af086cce435ce6eb9f89f012103243597856d5bd7c8f91f77446a53db425ce10d237c1d6928f2268acdc538797effffffff030000000000000000066a044d454d4f80969800000000001976a914e6d4b9d2c408bf6bd44523b3b6607de4853b806088ace83c8e06000000001976a914936667ff8d2d41361a4df4a370b309fb15380eac88ac0000002024"
+
+const (
+	benchBlocks      = 100
+	benchTxsPerBlock = 20
+)
+
+func benchBlockHash(height int) string {
+	return fmt.Sprintf("%064x", height)
+}
+
+func fakeNode(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     string            `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var result any
+	switch req.Method {
+	case "getblockheader":
+		var hash string
+		var height int
+		json.Unmarshal(req.Params[0], &hash)
+		fmt.Sscanf(hash, "%x", &height)
+		header := map[string]any{"hash": hash, "height": height, "previousblockhash": benchBlockHash(height - 1)}
+		if height < benchBlocks {
+			header["nextblockhash"] = benchBlockHash(height + 1)
+		}
+		result = header
+	case "getblockcount":
+		result = benchBlocks
+	case "getaddressdeltas":
+		var p struct {
+			Start int `json:"start"`
+			End   int `json:"end"`
+		}
+		json.Unmarshal(req.Params[0], &p)
+		deltas := []map[string]any{}
+		for h := p.Start; h <= p.End; h++ {
+			for i := 0; i < benchTxsPerBlock; i++ {
+				deltas = append(deltas, map[string]any{"txid": fmt.Sprintf("%056x%08x", h, i), "height": h})
+			}
+		}
+		result = map[string]any{
+			"deltas": deltas,
+			"start":  map[string]any{"hash": benchBlockHash(p.Start), "height": p.Start},
+			"end":    map[string]any{"hash": benchBlockHash(p.End), "height": p.End},
+		}
+	case "getrawtransaction":
+		result = benchDeposit
+	default:
+		json.NewEncoder(w).Encode(map[string]any{"id": req.ID, "error": map[string]any{"message": "unsupported " + req.Method}})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"id": req.ID, "result": result, "error": nil})
+}
+
+func init() {
+	node := httptest.NewServer(http.HandlerFunc(fakeNode))
+	os.Setenv("ZCASHD_URL", node.URL)
+}
+
+func BenchmarkScanBlockRange(b *testing.B) {
+	user, err := SkToPub("L1sjrupHTXwtX847jZhXpkACVYE6d4edPeJK9762j7AeCYL4c32z")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, size := range []uint32{0, 10000} {
+		b.Run(fmt.Sprintf("cache=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				// start every scan with an empty cache
+				b.StopTimer()
+				SetTxCacheSize(0)
+				SetTxCacheSize(size)
+				b.StartTimer()
+				btxs, err := ScanBlockRange(user.Pk, benchBlockHash(0), benchBlocks, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if btxs == nil || len(btxs.Txs) != benchBlocks*benchTxsPerBlock {
+					b.Fatalf("Unexpected scan %v", btxs)
+				}
+			}
+			stats := GetTxCacheStats()
+			b.ReportMetric(float64(stats.Hits)/float64(stats.Hits+stats.Misses), "hits/lookup")
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package maya_zcash
+
+// BlockWalker scans vaults by walking the blocks and keeps
+// their UTXO sets. It works on nodes that run without the
+// address and spent indexes.
+type BlockWalker struct {
+	handle uint64
+}
+
+// NewBlockWalker starts walking after the block fromHash,
+// or after the genesis block if fromHash is empty. The vaults
+// must not have any UTXO before fromHash. Transfers to or from
+// the knownVaults are reported as migrations.
+func NewBlockWalker(pubkeys [][]byte, fromHash string, knownVaults [][]byte) (*BlockWalker, error) {
+	handle, err := BlockWalkerNew(pubkeys, fromHash, knownVaults)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockWalker{handle: handle}, nil
+}
+
+// Scan walks at most maxBlocks blocks. It returns nil at the tip.
+func (w *BlockWalker) Scan(maxBlocks uint32) (*BlockTxs, error) {
+	return BlockWalkerScan(w.handle, maxBlocks)
+}
+
+// Rewind undoes the blocks walked after height, for example
+// after an ErrZcashErrorReorg. Only the last 100 blocks can be undone
+func (w *BlockWalker) Rewind(height uint32) error {
+	return BlockWalkerRewind(w.handle, height)
+}
+
+// Utxos returns the UTXOs of the vault as of the last block walked
+func (w *BlockWalker) Utxos(pubkey []byte) ([]Utxo, error) {
+	return BlockWalkerUtxos(w.handle, pubkey)
+}
+
+// Balance returns the balance of the vault as of the last block walked
+func (w *BlockWalker) Balance(pubkey []byte) (uint64, error) {
+	return BlockWalkerBalance(w.handle, pubkey)
+}
+
+// Close releases the walker
+func (w *BlockWalker) Close() {
+	BlockWalkerClose(w.handle)
+}
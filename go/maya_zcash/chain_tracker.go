@@ -0,0 +1,31 @@
+package maya_zcash
+
+// ChainTracker follows the tip of the chain for a set of vaults
+// and reports the blocks that are connected and disconnected,
+// so that observations of orphaned vault transactions can be reverted.
+type ChainTracker struct {
+	handle uint64
+}
+
+// NewChainTracker starts tracking after the block fromHash,
+// or at the tip if fromHash is empty. Reorgs up to depth blocks
+// are reported as BlockDisconnected events. Transfers to or from
+// the knownVaults are reported as migrations.
+func NewChainTracker(pubkeys [][]byte, fromHash string, depth uint32, knownVaults [][]byte) (*ChainTracker, error) {
+	handle, err := ChainTrackerNew(pubkeys, fromHash, depth, knownVaults)
+	if err != nil {
+		return nil, err
+	}
+	return &ChainTracker{handle: handle}, nil
+}
+
+// Poll catches up with the tip. Disconnected blocks come first,
+// from the tip down, then the connected blocks in chain order.
+func (t *ChainTracker) Poll() ([]ChainEvent, error) {
+	return ChainTrackerPoll(t.handle)
+}
+
+// Close releases the tracker
+func (t *ChainTracker) Close() {
+	ChainTrackerClose(t.handle)
+}
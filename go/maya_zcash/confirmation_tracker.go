@@ -0,0 +1,46 @@
+package maya_zcash
+
+// ConfirmationTracker follows the confirmations of transactions
+// and tells when they reach the number their amount requires.
+type ConfirmationTracker struct {
+	handle uint64
+}
+
+// NewConfirmationTracker creates a tracker where a tx needs the
+// confirmations of the tier with the highest MinAmount it reaches.
+// Amounts below every tier need a single confirmation.
+func NewConfirmationTracker(tiers []ConfirmationTier) (*ConfirmationTracker, error) {
+	handle, err := ConfirmationTrackerNew(tiers)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfirmationTracker{handle: handle}, nil
+}
+
+// Watch adds a tx, its amount decides how many confirmations it needs
+func (t *ConfirmationTracker) Watch(txid string, amount uint64) error {
+	return ConfirmationTrackerWatch(t.handle, txid, amount)
+}
+
+// WatchVaultTxs adds the txs returned by the scanners,
+// by the amount of their counterparty
+func (t *ConfirmationTracker) WatchVaultTxs(txs []VaultTx) error {
+	return ConfirmationTrackerWatchVaultTxs(t.handle, txs)
+}
+
+// Unwatch removes a tx, for example once it is final
+func (t *ConfirmationTracker) Unwatch(txid string) error {
+	return ConfirmationTrackerUnwatch(t.handle, txid)
+}
+
+// Poll reports every watched tx. ThresholdCrossed is set on the
+// poll where a tx reaches its required confirmations, Reorged
+// when its block was orphaned.
+func (t *ConfirmationTracker) Poll() ([]TxConfirmation, error) {
+	return ConfirmationTrackerPoll(t.handle)
+}
+
+// Close releases the tracker
+func (t *ConfirmationTracker) Close() {
+	ConfirmationTrackerClose(t.handle)
+}
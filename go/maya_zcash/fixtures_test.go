@@ -0,0 +1,38 @@
+package maya_zcash
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"mayazcash_go/maya_zcash/rpcfixture"
+)
+
+// runWithFixtures runs the tests against the node of ZCASHD_URL or,
+// with MAYA_RPC_MODE=record|replay, records or replays its JSON-RPC
+// traffic to testdata/<suite>.json next to this file:
+//
+//	MAYA_RPC_MODE=record ./maya_zcash.test   # with the node of setup-regtest.sh
+//	MAYA_RPC_MODE=replay ./maya_zcash.test   # no node needed
+//
+// The replies of a request are replayed in the order they were
+// recorded, so replay the suite in the order it was recorded. The
+// rng_seed of config.yaml keeps the shielded transactions the same
+func runWithFixtures(m *testing.M, suite string) int {
+	_, file, _, _ := runtime.Caller(0)
+	path := filepath.Join(filepath.Dir(file), "testdata", suite+".json")
+	rpc, err := rpcfixture.Start(path)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	code := m.Run()
+	if rpc != nil {
+		if err := rpc.Close(); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+	}
+	return code
+}
@@ -5,12 +5,13 @@ package maya_zcash
 import (
 	"encoding/hex"
 	"fmt"
+	"os"
 	"testing"
 )
 
 func TestMain(t *testing.M) {
     InitLogger();
-    t.Run();
+    os.Exit(runWithFixtures(t, "mainnet"));
 }
 
 func TestLatestHeight(t *testing.T) {
@@ -83,3 +84,43 @@ func TestListUTXO(t *testing.T) {
         t.Errorf("UTXO value does not match")
     }
 }
+
+func TestMainnetDeposits(t *testing.T) {
+    // the deposits of the chain, not built by the tests
+    vault, _ := hex.DecodeString("02c72d6f1a74d169ddbdf5b7da258ece5fa09cc6b13385a8b0bcd7b1aef3bf4483")
+    address, _ := GetVaultAddress(vault)
+    tip, err := GetLatestHeight()
+    if err != nil {
+        t.Fatalf(`TestMainnetDeposits = %v`, err)
+    }
+    txs, err := BackfillVault(vault, 1, tip.Number, [][]byte {})
+    if err != nil {
+        t.Fatalf(`TestMainnetDeposits = %v`, err)
+    }
+    deposits := 0
+    for _, tx := range txs {
+        memo := tx.Counterparty.Memo
+        if tx.Direction != DirectionIncoming || memo.String() == "" {
+            continue
+        }
+        // the OP_RETURN memo is kept as text and bytes
+        if string(memo.Data) != memo.Text {
+            t.Errorf("%v: memo %q != %x", tx.Txid, memo.Text, memo.Data)
+        }
+        detail, err := GetVaultTxDetail(vault, tx.Txid, [][]byte {})
+        if err != nil {
+            t.Fatalf(`TestMainnetDeposits = %v`, err)
+        }
+        paid := false
+        for _, o := range detail.Outputs {
+            paid = paid || o.Address == address
+        }
+        if !paid {
+            t.Errorf("%v does not pay the vault %v", tx.Txid, address)
+        }
+        deposits += 1
+    }
+    if deposits == 0 {
+        t.Errorf("No deposit with a memo to %v", address)
+    }
+}
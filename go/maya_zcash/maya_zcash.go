@@ -342,15 +342,42 @@ func uniffiCheckChecksums() {
 		// If this happens try cleaning and rebuilding your project
 		panic("maya_zcash: UniFFI contract version mismatch")
 	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_analyze_vault_tx(uniffiStatus)
+		})
+		if checksum != 27166 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_analyze_vault_tx: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_apply_deposit_signatures(uniffiStatus)
+		})
+		if checksum != 23714 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_apply_deposit_signatures: UniFFI API checksum mismatch")
+		}
+	}
 	{
 		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
 			return C.uniffi_maya_zcash_checksum_func_apply_signatures(uniffiStatus)
 		})
-		if checksum != 24461 {
+		if checksum != 60307 {
 			// If this happens try cleaning and rebuilding your project
 			panic("maya_zcash: uniffi_maya_zcash_checksum_func_apply_signatures: UniFFI API checksum mismatch")
 		}
 	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_backfill_vault(uniffiStatus)
+		})
+		if checksum != 903 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_backfill_vault: UniFFI API checksum mismatch")
+		}
+	}
 	{
 		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
 			return C.uniffi_maya_zcash_checksum_func_best_recipient_of_ua(uniffiStatus)
@@ -360,6 +387,60 @@ func uniffiCheckChecksums() {
 			panic("maya_zcash: uniffi_maya_zcash_checksum_func_best_recipient_of_ua: UniFFI API checksum mismatch")
 		}
 	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_block_walker_balance(uniffiStatus)
+		})
+		if checksum != 46934 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_block_walker_balance: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_block_walker_close(uniffiStatus)
+		})
+		if checksum != 11777 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_block_walker_close: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_block_walker_new(uniffiStatus)
+		})
+		if checksum != 35084 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_block_walker_new: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_block_walker_rewind(uniffiStatus)
+		})
+		if checksum != 9405 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_block_walker_rewind: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_block_walker_scan(uniffiStatus)
+		})
+		if checksum != 50870 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_block_walker_scan: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_block_walker_utxos(uniffiStatus)
+		})
+		if checksum != 50322 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_block_walker_utxos: UniFFI API checksum mismatch")
+		}
+	}
 	{
 		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
 			return C.uniffi_maya_zcash_checksum_func_broadcast_raw_tx(uniffiStatus)
@@ -369,6 +450,60 @@ func uniffiCheckChecksums() {
 			panic("maya_zcash: uniffi_maya_zcash_checksum_func_broadcast_raw_tx: UniFFI API checksum mismatch")
 		}
 	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_build_deposit_to_vault(uniffiStatus)
+		})
+		if checksum != 31854 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_build_deposit_to_vault: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_build_deposit_to_vault_from(uniffiStatus)
+		})
+		if checksum != 40094 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_build_deposit_to_vault_from: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_chain_tracker_close(uniffiStatus)
+		})
+		if checksum != 9461 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_chain_tracker_close: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_chain_tracker_new(uniffiStatus)
+		})
+		if checksum != 37766 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_chain_tracker_new: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_chain_tracker_poll(uniffiStatus)
+		})
+		if checksum != 9628 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_chain_tracker_poll: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_close_store(uniffiStatus)
+		})
+		if checksum != 47571 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_close_store: UniFFI API checksum mismatch")
+		}
+	}
 	{
 		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
 			return C.uniffi_maya_zcash_checksum_func_combine_vault(uniffiStatus)
@@ -387,6 +522,60 @@ func uniffiCheckChecksums() {
 			panic("maya_zcash: uniffi_maya_zcash_checksum_func_combine_vault_utxos: UniFFI API checksum mismatch")
 		}
 	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_confirmation_tracker_close(uniffiStatus)
+		})
+		if checksum != 26306 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_confirmation_tracker_close: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_confirmation_tracker_new(uniffiStatus)
+		})
+		if checksum != 26328 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_confirmation_tracker_new: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_confirmation_tracker_poll(uniffiStatus)
+		})
+		if checksum != 61420 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_confirmation_tracker_poll: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_confirmation_tracker_unwatch(uniffiStatus)
+		})
+		if checksum != 35775 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_confirmation_tracker_unwatch: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_confirmation_tracker_watch(uniffiStatus)
+		})
+		if checksum != 32536 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_confirmation_tracker_watch: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_confirmation_tracker_watch_vault_txs(uniffiStatus)
+		})
+		if checksum != 1657 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_confirmation_tracker_watch_vault_txs: UniFFI API checksum mismatch")
+		}
+	}
 	{
 		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
 			return C.uniffi_maya_zcash_checksum_func_get_balance(uniffiStatus)
@@ -414,6 +603,15 @@ func uniffiCheckChecksums() {
 			panic("maya_zcash: uniffi_maya_zcash_checksum_func_get_ovk: UniFFI API checksum mismatch")
 		}
 	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_get_tx_cache_stats(uniffiStatus)
+		})
+		if checksum != 41037 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_get_tx_cache_stats: UniFFI API checksum mismatch")
+		}
+	}
 	{
 		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
 			return C.uniffi_maya_zcash_checksum_func_get_vault_address(uniffiStatus)
@@ -423,6 +621,24 @@ func uniffiCheckChecksums() {
 			panic("maya_zcash: uniffi_maya_zcash_checksum_func_get_vault_address: UniFFI API checksum mismatch")
 		}
 	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_get_vault_deposit_address(uniffiStatus)
+		})
+		if checksum != 16806 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_get_vault_deposit_address: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_get_vault_tx_detail(uniffiStatus)
+		})
+		if checksum != 39373 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_get_vault_tx_detail: UniFFI API checksum mismatch")
+		}
+	}
 	{
 		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
 			return C.uniffi_maya_zcash_checksum_func_init_logger(uniffiStatus)
@@ -459,42 +675,132 @@ func uniffiCheckChecksums() {
 			panic("maya_zcash: uniffi_maya_zcash_checksum_func_match_with_blockchain_receiver: UniFFI API checksum mismatch")
 		}
 	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_open_store(uniffiStatus)
+		})
+		if checksum != 11585 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_open_store: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_parse_script_pubkey(uniffiStatus)
+		})
+		if checksum != 57359 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_parse_script_pubkey: UniFFI API checksum mismatch")
+		}
+	}
 	{
 		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
 			return C.uniffi_maya_zcash_checksum_func_pay_from_vault(uniffiStatus)
 		})
-		if checksum != 56589 {
+		if checksum != 48452 {
 			// If this happens try cleaning and rebuilding your project
 			panic("maya_zcash: uniffi_maya_zcash_checksum_func_pay_from_vault: UniFFI API checksum mismatch")
 		}
 	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_scan_block_range(uniffiStatus)
+		})
+		if checksum != 37466 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_scan_block_range: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_scan_block_range_detail(uniffiStatus)
+		})
+		if checksum != 45557 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_scan_block_range_detail: UniFFI API checksum mismatch")
+		}
+	}
 	{
 		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
 			return C.uniffi_maya_zcash_checksum_func_scan_blocks(uniffiStatus)
 		})
-		if checksum != 29804 {
+		if checksum != 15211 {
 			// If this happens try cleaning and rebuilding your project
 			panic("maya_zcash: uniffi_maya_zcash_checksum_func_scan_blocks: UniFFI API checksum mismatch")
 		}
 	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_scan_blocks_multi(uniffiStatus)
+		})
+		if checksum != 18654 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_scan_blocks_multi: UniFFI API checksum mismatch")
+		}
+	}
 	{
 		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
 			return C.uniffi_maya_zcash_checksum_func_scan_mempool(uniffiStatus)
 		})
-		if checksum != 2161 {
+		if checksum != 52613 {
 			// If this happens try cleaning and rebuilding your project
 			panic("maya_zcash: uniffi_maya_zcash_checksum_func_scan_mempool: UniFFI API checksum mismatch")
 		}
 	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_scan_mempool_multi(uniffiStatus)
+		})
+		if checksum != 54129 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_scan_mempool_multi: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_send_shielded_to_vault(uniffiStatus)
+		})
+		if checksum != 31740 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_send_shielded_to_vault: UniFFI API checksum mismatch")
+		}
+	}
 	{
 		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
 			return C.uniffi_maya_zcash_checksum_func_send_to_vault(uniffiStatus)
 		})
-		if checksum != 12684 {
+		if checksum != 54011 {
 			// If this happens try cleaning and rebuilding your project
 			panic("maya_zcash: uniffi_maya_zcash_checksum_func_send_to_vault: UniFFI API checksum mismatch")
 		}
 	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_send_to_vault_from(uniffiStatus)
+		})
+		if checksum != 52107 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_send_to_vault_from: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_set_tx_cache_size(uniffiStatus)
+		})
+		if checksum != 634 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_set_tx_cache_size: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_set_vault_viewing_key(uniffiStatus)
+		})
+		if checksum != 18977 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_set_vault_viewing_key: UniFFI API checksum mismatch")
+		}
+	}
 	{
 		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
 			return C.uniffi_maya_zcash_checksum_func_sign_sighash(uniffiStatus)
@@ -515,62 +821,167 @@ func uniffiCheckChecksums() {
 	}
 	{
 		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
-			return C.uniffi_maya_zcash_checksum_func_validate_address(uniffiStatus)
+			return C.uniffi_maya_zcash_checksum_func_store_get_balance(uniffiStatus)
 		})
-		if checksum != 64411 {
+		if checksum != 16532 {
 			// If this happens try cleaning and rebuilding your project
-			panic("maya_zcash: uniffi_maya_zcash_checksum_func_validate_address: UniFFI API checksum mismatch")
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_store_get_balance: UniFFI API checksum mismatch")
 		}
 	}
-}
-
-type FfiConverterUint32 struct{}
-
-var FfiConverterUint32INSTANCE = FfiConverterUint32{}
-
-func (FfiConverterUint32) Lower(value uint32) C.uint32_t {
-	return C.uint32_t(value)
-}
-
-func (FfiConverterUint32) Write(writer io.Writer, value uint32) {
-	writeUint32(writer, value)
-}
-
-func (FfiConverterUint32) Lift(value C.uint32_t) uint32 {
-	return uint32(value)
-}
-
-func (FfiConverterUint32) Read(reader io.Reader) uint32 {
-	return readUint32(reader)
-}
-
-type FfiDestroyerUint32 struct{}
-
-func (FfiDestroyerUint32) Destroy(_ uint32) {}
-
-type FfiConverterUint64 struct{}
-
-var FfiConverterUint64INSTANCE = FfiConverterUint64{}
-
-func (FfiConverterUint64) Lower(value uint64) C.uint64_t {
-	return C.uint64_t(value)
-}
-
-func (FfiConverterUint64) Write(writer io.Writer, value uint64) {
-	writeUint64(writer, value)
-}
-
-func (FfiConverterUint64) Lift(value C.uint64_t) uint64 {
-	return uint64(value)
-}
-
-func (FfiConverterUint64) Read(reader io.Reader) uint64 {
-	return readUint64(reader)
-}
-
-type FfiDestroyerUint64 struct{}
-
-func (FfiDestroyerUint64) Destroy(_ uint64) {}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_store_get_cursor(uniffiStatus)
+		})
+		if checksum != 27792 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_store_get_cursor: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_store_list_txs(uniffiStatus)
+		})
+		if checksum != 57562 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_store_list_txs: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_store_list_utxos(uniffiStatus)
+		})
+		if checksum != 63582 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_store_list_utxos: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_store_rewind(uniffiStatus)
+		})
+		if checksum != 55442 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_store_rewind: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_store_scan(uniffiStatus)
+		})
+		if checksum != 31501 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_store_scan: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_subscription_close(uniffiStatus)
+		})
+		if checksum != 44604 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_subscription_close: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_subscription_new(uniffiStatus)
+		})
+		if checksum != 45577 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_subscription_new: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_subscription_next(uniffiStatus)
+		})
+		if checksum != 1298 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_subscription_next: UniFFI API checksum mismatch")
+		}
+	}
+	{
+		checksum := rustCall(func(uniffiStatus *C.RustCallStatus) C.uint16_t {
+			return C.uniffi_maya_zcash_checksum_func_validate_address(uniffiStatus)
+		})
+		if checksum != 64411 {
+			// If this happens try cleaning and rebuilding your project
+			panic("maya_zcash: uniffi_maya_zcash_checksum_func_validate_address: UniFFI API checksum mismatch")
+		}
+	}
+}
+
+type FfiConverterUint32 struct{}
+
+var FfiConverterUint32INSTANCE = FfiConverterUint32{}
+
+func (FfiConverterUint32) Lower(value uint32) C.uint32_t {
+	return C.uint32_t(value)
+}
+
+func (FfiConverterUint32) Write(writer io.Writer, value uint32) {
+	writeUint32(writer, value)
+}
+
+func (FfiConverterUint32) Lift(value C.uint32_t) uint32 {
+	return uint32(value)
+}
+
+func (FfiConverterUint32) Read(reader io.Reader) uint32 {
+	return readUint32(reader)
+}
+
+type FfiDestroyerUint32 struct{}
+
+func (FfiDestroyerUint32) Destroy(_ uint32) {}
+
+type FfiConverterUint64 struct{}
+
+var FfiConverterUint64INSTANCE = FfiConverterUint64{}
+
+func (FfiConverterUint64) Lower(value uint64) C.uint64_t {
+	return C.uint64_t(value)
+}
+
+func (FfiConverterUint64) Write(writer io.Writer, value uint64) {
+	writeUint64(writer, value)
+}
+
+func (FfiConverterUint64) Lift(value C.uint64_t) uint64 {
+	return uint64(value)
+}
+
+func (FfiConverterUint64) Read(reader io.Reader) uint64 {
+	return readUint64(reader)
+}
+
+type FfiDestroyerUint64 struct{}
+
+func (FfiDestroyerUint64) Destroy(_ uint64) {}
+
+type FfiConverterInt64 struct{}
+
+var FfiConverterInt64INSTANCE = FfiConverterInt64{}
+
+func (FfiConverterInt64) Lower(value int64) C.int64_t {
+	return C.int64_t(value)
+}
+
+func (FfiConverterInt64) Write(writer io.Writer, value int64) {
+	writeInt64(writer, value)
+}
+
+func (FfiConverterInt64) Lift(value C.int64_t) int64 {
+	return int64(value)
+}
+
+func (FfiConverterInt64) Read(reader io.Reader) int64 {
+	return readInt64(reader)
+}
+
+type FfiDestroyerInt64 struct{}
+
+func (FfiDestroyerInt64) Destroy(_ int64) {}
 
 type FfiConverterBool struct{}
 
@@ -697,6 +1108,58 @@ type FfiDestroyerBytes struct{}
 
 func (FfiDestroyerBytes) Destroy(_ []byte) {}
 
+type BlockTxDetails struct {
+	StartHash   string
+	EndHash     string
+	StartHeight uint32
+	EndHeight   uint32
+	Txs         []VaultTxDetail
+}
+
+func (r *BlockTxDetails) Destroy() {
+	FfiDestroyerString{}.Destroy(r.StartHash)
+	FfiDestroyerString{}.Destroy(r.EndHash)
+	FfiDestroyerUint32{}.Destroy(r.StartHeight)
+	FfiDestroyerUint32{}.Destroy(r.EndHeight)
+	FfiDestroyerSequenceTypeVaultTxDetail{}.Destroy(r.Txs)
+}
+
+type FfiConverterTypeBlockTxDetails struct{}
+
+var FfiConverterTypeBlockTxDetailsINSTANCE = FfiConverterTypeBlockTxDetails{}
+
+func (c FfiConverterTypeBlockTxDetails) Lift(rb RustBufferI) BlockTxDetails {
+	return LiftFromRustBuffer[BlockTxDetails](c, rb)
+}
+
+func (c FfiConverterTypeBlockTxDetails) Read(reader io.Reader) BlockTxDetails {
+	return BlockTxDetails{
+		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+		FfiConverterSequenceTypeVaultTxDetailINSTANCE.Read(reader),
+	}
+}
+
+func (c FfiConverterTypeBlockTxDetails) Lower(value BlockTxDetails) RustBuffer {
+	return LowerIntoRustBuffer[BlockTxDetails](c, value)
+}
+
+func (c FfiConverterTypeBlockTxDetails) Write(writer io.Writer, value BlockTxDetails) {
+	FfiConverterStringINSTANCE.Write(writer, value.StartHash)
+	FfiConverterStringINSTANCE.Write(writer, value.EndHash)
+	FfiConverterUint32INSTANCE.Write(writer, value.StartHeight)
+	FfiConverterUint32INSTANCE.Write(writer, value.EndHeight)
+	FfiConverterSequenceTypeVaultTxDetailINSTANCE.Write(writer, value.Txs)
+}
+
+type FfiDestroyerTypeBlockTxDetails struct{}
+
+func (_ FfiDestroyerTypeBlockTxDetails) Destroy(value BlockTxDetails) {
+	value.Destroy()
+}
+
 type BlockTxs struct {
 	StartHash   string
 	EndHash     string
@@ -749,6 +1212,94 @@ func (_ FfiDestroyerTypeBlockTxs) Destroy(value BlockTxs) {
 	value.Destroy()
 }
 
+type ChainEvent struct {
+	Kind   ChainEventKind
+	Height uint32
+	Hash   string
+	Txs    []VaultTx
+}
+
+func (r *ChainEvent) Destroy() {
+	FfiDestroyerTypeChainEventKind{}.Destroy(r.Kind)
+	FfiDestroyerUint32{}.Destroy(r.Height)
+	FfiDestroyerString{}.Destroy(r.Hash)
+	FfiDestroyerSequenceTypeVaultTx{}.Destroy(r.Txs)
+}
+
+type FfiConverterTypeChainEvent struct{}
+
+var FfiConverterTypeChainEventINSTANCE = FfiConverterTypeChainEvent{}
+
+func (c FfiConverterTypeChainEvent) Lift(rb RustBufferI) ChainEvent {
+	return LiftFromRustBuffer[ChainEvent](c, rb)
+}
+
+func (c FfiConverterTypeChainEvent) Read(reader io.Reader) ChainEvent {
+	return ChainEvent{
+		FfiConverterTypeChainEventKindINSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterSequenceTypeVaultTxINSTANCE.Read(reader),
+	}
+}
+
+func (c FfiConverterTypeChainEvent) Lower(value ChainEvent) RustBuffer {
+	return LowerIntoRustBuffer[ChainEvent](c, value)
+}
+
+func (c FfiConverterTypeChainEvent) Write(writer io.Writer, value ChainEvent) {
+	FfiConverterTypeChainEventKindINSTANCE.Write(writer, value.Kind)
+	FfiConverterUint32INSTANCE.Write(writer, value.Height)
+	FfiConverterStringINSTANCE.Write(writer, value.Hash)
+	FfiConverterSequenceTypeVaultTxINSTANCE.Write(writer, value.Txs)
+}
+
+type FfiDestroyerTypeChainEvent struct{}
+
+func (_ FfiDestroyerTypeChainEvent) Destroy(value ChainEvent) {
+	value.Destroy()
+}
+
+type ConfirmationTier struct {
+	MinAmount     uint64
+	Confirmations uint32
+}
+
+func (r *ConfirmationTier) Destroy() {
+	FfiDestroyerUint64{}.Destroy(r.MinAmount)
+	FfiDestroyerUint32{}.Destroy(r.Confirmations)
+}
+
+type FfiConverterTypeConfirmationTier struct{}
+
+var FfiConverterTypeConfirmationTierINSTANCE = FfiConverterTypeConfirmationTier{}
+
+func (c FfiConverterTypeConfirmationTier) Lift(rb RustBufferI) ConfirmationTier {
+	return LiftFromRustBuffer[ConfirmationTier](c, rb)
+}
+
+func (c FfiConverterTypeConfirmationTier) Read(reader io.Reader) ConfirmationTier {
+	return ConfirmationTier{
+		FfiConverterUint64INSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+	}
+}
+
+func (c FfiConverterTypeConfirmationTier) Lower(value ConfirmationTier) RustBuffer {
+	return LowerIntoRustBuffer[ConfirmationTier](c, value)
+}
+
+func (c FfiConverterTypeConfirmationTier) Write(writer io.Writer, value ConfirmationTier) {
+	FfiConverterUint64INSTANCE.Write(writer, value.MinAmount)
+	FfiConverterUint32INSTANCE.Write(writer, value.Confirmations)
+}
+
+type FfiDestroyerTypeConfirmationTier struct{}
+
+func (_ FfiDestroyerTypeConfirmationTier) Destroy(value ConfirmationTier) {
+	value.Destroy()
+}
+
 type Height struct {
 	Number uint32
 	Hash   []byte
@@ -789,16 +1340,60 @@ func (_ FfiDestroyerTypeHeight) Destroy(value Height) {
 	value.Destroy()
 }
 
+type Memo struct {
+	Kind *MemoKind
+	Text string
+	Data []byte
+}
+
+func (r *Memo) Destroy() {
+	FfiDestroyerOptionalTypeMemoKind{}.Destroy(r.Kind)
+	FfiDestroyerString{}.Destroy(r.Text)
+	FfiDestroyerBytes{}.Destroy(r.Data)
+}
+
+type FfiConverterTypeMemo struct{}
+
+var FfiConverterTypeMemoINSTANCE = FfiConverterTypeMemo{}
+
+func (c FfiConverterTypeMemo) Lift(rb RustBufferI) Memo {
+	return LiftFromRustBuffer[Memo](c, rb)
+}
+
+func (c FfiConverterTypeMemo) Read(reader io.Reader) Memo {
+	return Memo{
+		FfiConverterOptionalTypeMemoKindINSTANCE.Read(reader),
+		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterBytesINSTANCE.Read(reader),
+	}
+}
+
+func (c FfiConverterTypeMemo) Lower(value Memo) RustBuffer {
+	return LowerIntoRustBuffer[Memo](c, value)
+}
+
+func (c FfiConverterTypeMemo) Write(writer io.Writer, value Memo) {
+	FfiConverterOptionalTypeMemoKindINSTANCE.Write(writer, value.Kind)
+	FfiConverterStringINSTANCE.Write(writer, value.Text)
+	FfiConverterBytesINSTANCE.Write(writer, value.Data)
+}
+
+type FfiDestroyerTypeMemo struct{}
+
+func (_ FfiDestroyerTypeMemo) Destroy(value Memo) {
+	value.Destroy()
+}
+
 type Output struct {
 	Address string
 	Amount  uint64
-	Memo    string
+	Memo    Memo
 }
 
 func (r *Output) Destroy() {
 	FfiDestroyerString{}.Destroy(r.Address)
 	FfiDestroyerUint64{}.Destroy(r.Amount)
-	FfiDestroyerString{}.Destroy(r.Memo)
+	FfiDestroyerTypeMemo{}.Destroy(r.Memo)
 }
 
 type FfiConverterTypeOutput struct{}
@@ -813,7 +1408,7 @@ func (c FfiConverterTypeOutput) Read(reader io.Reader) Output {
 	return Output{
 		FfiConverterStringINSTANCE.Read(reader),
 		FfiConverterUint64INSTANCE.Read(reader),
-		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterTypeMemoINSTANCE.Read(reader),
 	}
 }
 
@@ -824,7 +1419,7 @@ func (c FfiConverterTypeOutput) Lower(value Output) RustBuffer {
 func (c FfiConverterTypeOutput) Write(writer io.Writer, value Output) {
 	FfiConverterStringINSTANCE.Write(writer, value.Address)
 	FfiConverterUint64INSTANCE.Write(writer, value.Amount)
-	FfiConverterStringINSTANCE.Write(writer, value.Memo)
+	FfiConverterTypeMemoINSTANCE.Write(writer, value.Memo)
 }
 
 type FfiDestroyerTypeOutput struct{}
@@ -889,49 +1484,197 @@ func (_ FfiDestroyerTypePartialTx) Destroy(value PartialTx) {
 	value.Destroy()
 }
 
-type Sighashes struct {
-	Hashes [][]byte
+type ScriptPubKey struct {
+	Kind    ScriptKind
+	Address string
+	Data    []byte
+	Text    string
 }
 
-func (r *Sighashes) Destroy() {
-	FfiDestroyerSequenceBytes{}.Destroy(r.Hashes)
+func (r *ScriptPubKey) Destroy() {
+	FfiDestroyerTypeScriptKind{}.Destroy(r.Kind)
+	FfiDestroyerString{}.Destroy(r.Address)
+	FfiDestroyerBytes{}.Destroy(r.Data)
+	FfiDestroyerString{}.Destroy(r.Text)
 }
 
-type FfiConverterTypeSighashes struct{}
+type FfiConverterTypeScriptPubKey struct{}
 
-var FfiConverterTypeSighashesINSTANCE = FfiConverterTypeSighashes{}
+var FfiConverterTypeScriptPubKeyINSTANCE = FfiConverterTypeScriptPubKey{}
 
-func (c FfiConverterTypeSighashes) Lift(rb RustBufferI) Sighashes {
-	return LiftFromRustBuffer[Sighashes](c, rb)
+func (c FfiConverterTypeScriptPubKey) Lift(rb RustBufferI) ScriptPubKey {
+	return LiftFromRustBuffer[ScriptPubKey](c, rb)
 }
 
-func (c FfiConverterTypeSighashes) Read(reader io.Reader) Sighashes {
-	return Sighashes{
-		FfiConverterSequenceBytesINSTANCE.Read(reader),
+func (c FfiConverterTypeScriptPubKey) Read(reader io.Reader) ScriptPubKey {
+	return ScriptPubKey{
+		FfiConverterTypeScriptKindINSTANCE.Read(reader),
+		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterBytesINSTANCE.Read(reader),
+		FfiConverterStringINSTANCE.Read(reader),
 	}
 }
 
-func (c FfiConverterTypeSighashes) Lower(value Sighashes) RustBuffer {
-	return LowerIntoRustBuffer[Sighashes](c, value)
+func (c FfiConverterTypeScriptPubKey) Lower(value ScriptPubKey) RustBuffer {
+	return LowerIntoRustBuffer[ScriptPubKey](c, value)
 }
 
-func (c FfiConverterTypeSighashes) Write(writer io.Writer, value Sighashes) {
-	FfiConverterSequenceBytesINSTANCE.Write(writer, value.Hashes)
+func (c FfiConverterTypeScriptPubKey) Write(writer io.Writer, value ScriptPubKey) {
+	FfiConverterTypeScriptKindINSTANCE.Write(writer, value.Kind)
+	FfiConverterStringINSTANCE.Write(writer, value.Address)
+	FfiConverterBytesINSTANCE.Write(writer, value.Data)
+	FfiConverterStringINSTANCE.Write(writer, value.Text)
 }
 
-type FfiDestroyerTypeSighashes struct{}
+type FfiDestroyerTypeScriptPubKey struct{}
 
-func (_ FfiDestroyerTypeSighashes) Destroy(value Sighashes) {
+func (_ FfiDestroyerTypeScriptPubKey) Destroy(value ScriptPubKey) {
 	value.Destroy()
 }
 
-type TransparentKey struct {
-	Sk   []byte
-	Pk   []byte
-	Addr string
+type ShieldedNote struct {
+	Recipient []byte
+	Value     uint64
+	Rho       []byte
+	Rseed     []byte
+	Position  uint32
+	AuthPath  [][]byte
 }
 
-func (r *TransparentKey) Destroy() {
+func (r *ShieldedNote) Destroy() {
+	FfiDestroyerBytes{}.Destroy(r.Recipient)
+	FfiDestroyerUint64{}.Destroy(r.Value)
+	FfiDestroyerBytes{}.Destroy(r.Rho)
+	FfiDestroyerBytes{}.Destroy(r.Rseed)
+	FfiDestroyerUint32{}.Destroy(r.Position)
+	FfiDestroyerSequenceBytes{}.Destroy(r.AuthPath)
+}
+
+type FfiConverterTypeShieldedNote struct{}
+
+var FfiConverterTypeShieldedNoteINSTANCE = FfiConverterTypeShieldedNote{}
+
+func (c FfiConverterTypeShieldedNote) Lift(rb RustBufferI) ShieldedNote {
+	return LiftFromRustBuffer[ShieldedNote](c, rb)
+}
+
+func (c FfiConverterTypeShieldedNote) Read(reader io.Reader) ShieldedNote {
+	return ShieldedNote{
+		FfiConverterBytesINSTANCE.Read(reader),
+		FfiConverterUint64INSTANCE.Read(reader),
+		FfiConverterBytesINSTANCE.Read(reader),
+		FfiConverterBytesINSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+		FfiConverterSequenceBytesINSTANCE.Read(reader),
+	}
+}
+
+func (c FfiConverterTypeShieldedNote) Lower(value ShieldedNote) RustBuffer {
+	return LowerIntoRustBuffer[ShieldedNote](c, value)
+}
+
+func (c FfiConverterTypeShieldedNote) Write(writer io.Writer, value ShieldedNote) {
+	FfiConverterBytesINSTANCE.Write(writer, value.Recipient)
+	FfiConverterUint64INSTANCE.Write(writer, value.Value)
+	FfiConverterBytesINSTANCE.Write(writer, value.Rho)
+	FfiConverterBytesINSTANCE.Write(writer, value.Rseed)
+	FfiConverterUint32INSTANCE.Write(writer, value.Position)
+	FfiConverterSequenceBytesINSTANCE.Write(writer, value.AuthPath)
+}
+
+type FfiDestroyerTypeShieldedNote struct{}
+
+func (_ FfiDestroyerTypeShieldedNote) Destroy(value ShieldedNote) {
+	value.Destroy()
+}
+
+type Sighashes struct {
+	Hashes [][]byte
+}
+
+func (r *Sighashes) Destroy() {
+	FfiDestroyerSequenceBytes{}.Destroy(r.Hashes)
+}
+
+type FfiConverterTypeSighashes struct{}
+
+var FfiConverterTypeSighashesINSTANCE = FfiConverterTypeSighashes{}
+
+func (c FfiConverterTypeSighashes) Lift(rb RustBufferI) Sighashes {
+	return LiftFromRustBuffer[Sighashes](c, rb)
+}
+
+func (c FfiConverterTypeSighashes) Read(reader io.Reader) Sighashes {
+	return Sighashes{
+		FfiConverterSequenceBytesINSTANCE.Read(reader),
+	}
+}
+
+func (c FfiConverterTypeSighashes) Lower(value Sighashes) RustBuffer {
+	return LowerIntoRustBuffer[Sighashes](c, value)
+}
+
+func (c FfiConverterTypeSighashes) Write(writer io.Writer, value Sighashes) {
+	FfiConverterSequenceBytesINSTANCE.Write(writer, value.Hashes)
+}
+
+type FfiDestroyerTypeSighashes struct{}
+
+func (_ FfiDestroyerTypeSighashes) Destroy(value Sighashes) {
+	value.Destroy()
+}
+
+type StoredUtxo struct {
+	Utxo        Utxo
+	SpentTxid   string
+	SpentHeight uint32
+}
+
+func (r *StoredUtxo) Destroy() {
+	FfiDestroyerTypeUtxo{}.Destroy(r.Utxo)
+	FfiDestroyerString{}.Destroy(r.SpentTxid)
+	FfiDestroyerUint32{}.Destroy(r.SpentHeight)
+}
+
+type FfiConverterTypeStoredUTXO struct{}
+
+var FfiConverterTypeStoredUTXOINSTANCE = FfiConverterTypeStoredUTXO{}
+
+func (c FfiConverterTypeStoredUTXO) Lift(rb RustBufferI) StoredUtxo {
+	return LiftFromRustBuffer[StoredUtxo](c, rb)
+}
+
+func (c FfiConverterTypeStoredUTXO) Read(reader io.Reader) StoredUtxo {
+	return StoredUtxo{
+		FfiConverterTypeUTXOINSTANCE.Read(reader),
+		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+	}
+}
+
+func (c FfiConverterTypeStoredUTXO) Lower(value StoredUtxo) RustBuffer {
+	return LowerIntoRustBuffer[StoredUtxo](c, value)
+}
+
+func (c FfiConverterTypeStoredUTXO) Write(writer io.Writer, value StoredUtxo) {
+	FfiConverterTypeUTXOINSTANCE.Write(writer, value.Utxo)
+	FfiConverterStringINSTANCE.Write(writer, value.SpentTxid)
+	FfiConverterUint32INSTANCE.Write(writer, value.SpentHeight)
+}
+
+type FfiDestroyerTypeStoredUtxo struct{}
+
+func (_ FfiDestroyerTypeStoredUtxo) Destroy(value StoredUtxo) {
+	value.Destroy()
+}
+
+type TransparentKey struct {
+	Sk   []byte
+	Pk   []byte
+	Addr string
+}
+
+func (r *TransparentKey) Destroy() {
 	FfiDestroyerBytes{}.Destroy(r.Sk)
 	FfiDestroyerBytes{}.Destroy(r.Pk)
 	FfiDestroyerString{}.Destroy(r.Addr)
@@ -1009,6 +1752,170 @@ func (_ FfiDestroyerTypeTxBytes) Destroy(value TxBytes) {
 	value.Destroy()
 }
 
+type TxCacheStats struct {
+	Hits     uint64
+	Misses   uint64
+	Size     uint32
+	Capacity uint32
+}
+
+func (r *TxCacheStats) Destroy() {
+	FfiDestroyerUint64{}.Destroy(r.Hits)
+	FfiDestroyerUint64{}.Destroy(r.Misses)
+	FfiDestroyerUint32{}.Destroy(r.Size)
+	FfiDestroyerUint32{}.Destroy(r.Capacity)
+}
+
+type FfiConverterTypeTxCacheStats struct{}
+
+var FfiConverterTypeTxCacheStatsINSTANCE = FfiConverterTypeTxCacheStats{}
+
+func (c FfiConverterTypeTxCacheStats) Lift(rb RustBufferI) TxCacheStats {
+	return LiftFromRustBuffer[TxCacheStats](c, rb)
+}
+
+func (c FfiConverterTypeTxCacheStats) Read(reader io.Reader) TxCacheStats {
+	return TxCacheStats{
+		FfiConverterUint64INSTANCE.Read(reader),
+		FfiConverterUint64INSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+	}
+}
+
+func (c FfiConverterTypeTxCacheStats) Lower(value TxCacheStats) RustBuffer {
+	return LowerIntoRustBuffer[TxCacheStats](c, value)
+}
+
+func (c FfiConverterTypeTxCacheStats) Write(writer io.Writer, value TxCacheStats) {
+	FfiConverterUint64INSTANCE.Write(writer, value.Hits)
+	FfiConverterUint64INSTANCE.Write(writer, value.Misses)
+	FfiConverterUint32INSTANCE.Write(writer, value.Size)
+	FfiConverterUint32INSTANCE.Write(writer, value.Capacity)
+}
+
+type FfiDestroyerTypeTxCacheStats struct{}
+
+func (_ FfiDestroyerTypeTxCacheStats) Destroy(value TxCacheStats) {
+	value.Destroy()
+}
+
+type TxConfirmation struct {
+	Txid             string
+	Amount           uint64
+	Height           uint32
+	BlockHash        string
+	Confirmations    uint32
+	Required         uint32
+	InBestChain      bool
+	Reorged          bool
+	ThresholdCrossed bool
+}
+
+func (r *TxConfirmation) Destroy() {
+	FfiDestroyerString{}.Destroy(r.Txid)
+	FfiDestroyerUint64{}.Destroy(r.Amount)
+	FfiDestroyerUint32{}.Destroy(r.Height)
+	FfiDestroyerString{}.Destroy(r.BlockHash)
+	FfiDestroyerUint32{}.Destroy(r.Confirmations)
+	FfiDestroyerUint32{}.Destroy(r.Required)
+	FfiDestroyerBool{}.Destroy(r.InBestChain)
+	FfiDestroyerBool{}.Destroy(r.Reorged)
+	FfiDestroyerBool{}.Destroy(r.ThresholdCrossed)
+}
+
+type FfiConverterTypeTxConfirmation struct{}
+
+var FfiConverterTypeTxConfirmationINSTANCE = FfiConverterTypeTxConfirmation{}
+
+func (c FfiConverterTypeTxConfirmation) Lift(rb RustBufferI) TxConfirmation {
+	return LiftFromRustBuffer[TxConfirmation](c, rb)
+}
+
+func (c FfiConverterTypeTxConfirmation) Read(reader io.Reader) TxConfirmation {
+	return TxConfirmation{
+		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterUint64INSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+		FfiConverterBoolINSTANCE.Read(reader),
+		FfiConverterBoolINSTANCE.Read(reader),
+		FfiConverterBoolINSTANCE.Read(reader),
+	}
+}
+
+func (c FfiConverterTypeTxConfirmation) Lower(value TxConfirmation) RustBuffer {
+	return LowerIntoRustBuffer[TxConfirmation](c, value)
+}
+
+func (c FfiConverterTypeTxConfirmation) Write(writer io.Writer, value TxConfirmation) {
+	FfiConverterStringINSTANCE.Write(writer, value.Txid)
+	FfiConverterUint64INSTANCE.Write(writer, value.Amount)
+	FfiConverterUint32INSTANCE.Write(writer, value.Height)
+	FfiConverterStringINSTANCE.Write(writer, value.BlockHash)
+	FfiConverterUint32INSTANCE.Write(writer, value.Confirmations)
+	FfiConverterUint32INSTANCE.Write(writer, value.Required)
+	FfiConverterBoolINSTANCE.Write(writer, value.InBestChain)
+	FfiConverterBoolINSTANCE.Write(writer, value.Reorged)
+	FfiConverterBoolINSTANCE.Write(writer, value.ThresholdCrossed)
+}
+
+type FfiDestroyerTypeTxConfirmation struct{}
+
+func (_ FfiDestroyerTypeTxConfirmation) Destroy(value TxConfirmation) {
+	value.Destroy()
+}
+
+type TxInput struct {
+	Txid    string
+	Vout    uint32
+	Address string
+	Value   uint64
+}
+
+func (r *TxInput) Destroy() {
+	FfiDestroyerString{}.Destroy(r.Txid)
+	FfiDestroyerUint32{}.Destroy(r.Vout)
+	FfiDestroyerString{}.Destroy(r.Address)
+	FfiDestroyerUint64{}.Destroy(r.Value)
+}
+
+type FfiConverterTypeTxInput struct{}
+
+var FfiConverterTypeTxInputINSTANCE = FfiConverterTypeTxInput{}
+
+func (c FfiConverterTypeTxInput) Lift(rb RustBufferI) TxInput {
+	return LiftFromRustBuffer[TxInput](c, rb)
+}
+
+func (c FfiConverterTypeTxInput) Read(reader io.Reader) TxInput {
+	return TxInput{
+		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterUint64INSTANCE.Read(reader),
+	}
+}
+
+func (c FfiConverterTypeTxInput) Lower(value TxInput) RustBuffer {
+	return LowerIntoRustBuffer[TxInput](c, value)
+}
+
+func (c FfiConverterTypeTxInput) Write(writer io.Writer, value TxInput) {
+	FfiConverterStringINSTANCE.Write(writer, value.Txid)
+	FfiConverterUint32INSTANCE.Write(writer, value.Vout)
+	FfiConverterStringINSTANCE.Write(writer, value.Address)
+	FfiConverterUint64INSTANCE.Write(writer, value.Value)
+}
+
+type FfiDestroyerTypeTxInput struct{}
+
+func (_ FfiDestroyerTypeTxInput) Destroy(value TxInput) {
+	value.Destroy()
+}
+
 type Utxo struct {
 	Txid   string
 	Height uint32
@@ -1061,18 +1968,128 @@ func (_ FfiDestroyerTypeUtxo) Destroy(value Utxo) {
 	value.Destroy()
 }
 
+type UndecryptedOutput struct {
+	Pool  ShieldedPool
+	Index uint32
+	Cmx   []byte
+	Cv    []byte
+}
+
+func (r *UndecryptedOutput) Destroy() {
+	FfiDestroyerTypeShieldedPool{}.Destroy(r.Pool)
+	FfiDestroyerUint32{}.Destroy(r.Index)
+	FfiDestroyerBytes{}.Destroy(r.Cmx)
+	FfiDestroyerBytes{}.Destroy(r.Cv)
+}
+
+type FfiConverterTypeUndecryptedOutput struct{}
+
+var FfiConverterTypeUndecryptedOutputINSTANCE = FfiConverterTypeUndecryptedOutput{}
+
+func (c FfiConverterTypeUndecryptedOutput) Lift(rb RustBufferI) UndecryptedOutput {
+	return LiftFromRustBuffer[UndecryptedOutput](c, rb)
+}
+
+func (c FfiConverterTypeUndecryptedOutput) Read(reader io.Reader) UndecryptedOutput {
+	return UndecryptedOutput{
+		FfiConverterTypeShieldedPoolINSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+		FfiConverterBytesINSTANCE.Read(reader),
+		FfiConverterBytesINSTANCE.Read(reader),
+	}
+}
+
+func (c FfiConverterTypeUndecryptedOutput) Lower(value UndecryptedOutput) RustBuffer {
+	return LowerIntoRustBuffer[UndecryptedOutput](c, value)
+}
+
+func (c FfiConverterTypeUndecryptedOutput) Write(writer io.Writer, value UndecryptedOutput) {
+	FfiConverterTypeShieldedPoolINSTANCE.Write(writer, value.Pool)
+	FfiConverterUint32INSTANCE.Write(writer, value.Index)
+	FfiConverterBytesINSTANCE.Write(writer, value.Cmx)
+	FfiConverterBytesINSTANCE.Write(writer, value.Cv)
+}
+
+type FfiDestroyerTypeUndecryptedOutput struct{}
+
+func (_ FfiDestroyerTypeUndecryptedOutput) Destroy(value UndecryptedOutput) {
+	value.Destroy()
+}
+
+type VaultEvent struct {
+	Kind            VaultEventKind
+	Height          uint32
+	Hash            string
+	Tx              VaultTx
+	ConflictingTxid string
+}
+
+func (r *VaultEvent) Destroy() {
+	FfiDestroyerTypeVaultEventKind{}.Destroy(r.Kind)
+	FfiDestroyerUint32{}.Destroy(r.Height)
+	FfiDestroyerString{}.Destroy(r.Hash)
+	FfiDestroyerTypeVaultTx{}.Destroy(r.Tx)
+	FfiDestroyerString{}.Destroy(r.ConflictingTxid)
+}
+
+type FfiConverterTypeVaultEvent struct{}
+
+var FfiConverterTypeVaultEventINSTANCE = FfiConverterTypeVaultEvent{}
+
+func (c FfiConverterTypeVaultEvent) Lift(rb RustBufferI) VaultEvent {
+	return LiftFromRustBuffer[VaultEvent](c, rb)
+}
+
+func (c FfiConverterTypeVaultEvent) Read(reader io.Reader) VaultEvent {
+	return VaultEvent{
+		FfiConverterTypeVaultEventKindINSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterTypeVaultTxINSTANCE.Read(reader),
+		FfiConverterStringINSTANCE.Read(reader),
+	}
+}
+
+func (c FfiConverterTypeVaultEvent) Lower(value VaultEvent) RustBuffer {
+	return LowerIntoRustBuffer[VaultEvent](c, value)
+}
+
+func (c FfiConverterTypeVaultEvent) Write(writer io.Writer, value VaultEvent) {
+	FfiConverterTypeVaultEventKindINSTANCE.Write(writer, value.Kind)
+	FfiConverterUint32INSTANCE.Write(writer, value.Height)
+	FfiConverterStringINSTANCE.Write(writer, value.Hash)
+	FfiConverterTypeVaultTxINSTANCE.Write(writer, value.Tx)
+	FfiConverterStringINSTANCE.Write(writer, value.ConflictingTxid)
+}
+
+type FfiDestroyerTypeVaultEvent struct{}
+
+func (_ FfiDestroyerTypeVaultEvent) Destroy(value VaultEvent) {
+	value.Destroy()
+}
+
 type VaultTx struct {
-	Txid         string
-	Height       uint32
-	Counterparty Output
-	Direction    Direction
+	Txid                 string
+	Vault                []byte
+	Height               uint32
+	Counterparty         Output
+	Direction            Direction
+	Destinations         []Output
+	Undecrypted          []UndecryptedOutput
+	Incomplete           bool
+	ShieldedValueBalance int64
 }
 
 func (r *VaultTx) Destroy() {
 	FfiDestroyerString{}.Destroy(r.Txid)
+	FfiDestroyerBytes{}.Destroy(r.Vault)
 	FfiDestroyerUint32{}.Destroy(r.Height)
 	FfiDestroyerTypeOutput{}.Destroy(r.Counterparty)
 	FfiDestroyerTypeDirection{}.Destroy(r.Direction)
+	FfiDestroyerSequenceTypeOutput{}.Destroy(r.Destinations)
+	FfiDestroyerSequenceTypeUndecryptedOutput{}.Destroy(r.Undecrypted)
+	FfiDestroyerBool{}.Destroy(r.Incomplete)
+	FfiDestroyerInt64{}.Destroy(r.ShieldedValueBalance)
 }
 
 type FfiConverterTypeVaultTx struct{}
@@ -1086,9 +2103,14 @@ func (c FfiConverterTypeVaultTx) Lift(rb RustBufferI) VaultTx {
 func (c FfiConverterTypeVaultTx) Read(reader io.Reader) VaultTx {
 	return VaultTx{
 		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterBytesINSTANCE.Read(reader),
 		FfiConverterUint32INSTANCE.Read(reader),
 		FfiConverterTypeOutputINSTANCE.Read(reader),
 		FfiConverterTypeDirectionINSTANCE.Read(reader),
+		FfiConverterSequenceTypeOutputINSTANCE.Read(reader),
+		FfiConverterSequenceTypeUndecryptedOutputINSTANCE.Read(reader),
+		FfiConverterBoolINSTANCE.Read(reader),
+		FfiConverterInt64INSTANCE.Read(reader),
 	}
 }
 
@@ -1098,9 +2120,14 @@ func (c FfiConverterTypeVaultTx) Lower(value VaultTx) RustBuffer {
 
 func (c FfiConverterTypeVaultTx) Write(writer io.Writer, value VaultTx) {
 	FfiConverterStringINSTANCE.Write(writer, value.Txid)
+	FfiConverterBytesINSTANCE.Write(writer, value.Vault)
 	FfiConverterUint32INSTANCE.Write(writer, value.Height)
 	FfiConverterTypeOutputINSTANCE.Write(writer, value.Counterparty)
 	FfiConverterTypeDirectionINSTANCE.Write(writer, value.Direction)
+	FfiConverterSequenceTypeOutputINSTANCE.Write(writer, value.Destinations)
+	FfiConverterSequenceTypeUndecryptedOutputINSTANCE.Write(writer, value.Undecrypted)
+	FfiConverterBoolINSTANCE.Write(writer, value.Incomplete)
+	FfiConverterInt64INSTANCE.Write(writer, value.ShieldedValueBalance)
 }
 
 type FfiDestroyerTypeVaultTx struct{}
@@ -1109,40 +2136,303 @@ func (_ FfiDestroyerTypeVaultTx) Destroy(value VaultTx) {
 	value.Destroy()
 }
 
-type Direction uint
+type VaultTxDetail struct {
+	Tx            VaultTx
+	Inputs        []TxInput
+	Outputs       []Output
+	Fee           uint64
+	BlockHash     string
+	BlockTime     uint32
+	Position      uint32
+	Confirmations uint32
+}
 
-const (
-	DirectionIncoming Direction = 1
-	DirectionOutgoing Direction = 2
-)
+func (r *VaultTxDetail) Destroy() {
+	FfiDestroyerTypeVaultTx{}.Destroy(r.Tx)
+	FfiDestroyerSequenceTypeTxInput{}.Destroy(r.Inputs)
+	FfiDestroyerSequenceTypeOutput{}.Destroy(r.Outputs)
+	FfiDestroyerUint64{}.Destroy(r.Fee)
+	FfiDestroyerString{}.Destroy(r.BlockHash)
+	FfiDestroyerUint32{}.Destroy(r.BlockTime)
+	FfiDestroyerUint32{}.Destroy(r.Position)
+	FfiDestroyerUint32{}.Destroy(r.Confirmations)
+}
 
-type FfiConverterTypeDirection struct{}
+type FfiConverterTypeVaultTxDetail struct{}
 
-var FfiConverterTypeDirectionINSTANCE = FfiConverterTypeDirection{}
+var FfiConverterTypeVaultTxDetailINSTANCE = FfiConverterTypeVaultTxDetail{}
 
-func (c FfiConverterTypeDirection) Lift(rb RustBufferI) Direction {
-	return LiftFromRustBuffer[Direction](c, rb)
+func (c FfiConverterTypeVaultTxDetail) Lift(rb RustBufferI) VaultTxDetail {
+	return LiftFromRustBuffer[VaultTxDetail](c, rb)
 }
 
-func (c FfiConverterTypeDirection) Lower(value Direction) RustBuffer {
-	return LowerIntoRustBuffer[Direction](c, value)
+func (c FfiConverterTypeVaultTxDetail) Read(reader io.Reader) VaultTxDetail {
+	return VaultTxDetail{
+		FfiConverterTypeVaultTxINSTANCE.Read(reader),
+		FfiConverterSequenceTypeTxInputINSTANCE.Read(reader),
+		FfiConverterSequenceTypeOutputINSTANCE.Read(reader),
+		FfiConverterUint64INSTANCE.Read(reader),
+		FfiConverterStringINSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+		FfiConverterUint32INSTANCE.Read(reader),
+	}
 }
-func (FfiConverterTypeDirection) Read(reader io.Reader) Direction {
-	id := readInt32(reader)
-	return Direction(id)
+
+func (c FfiConverterTypeVaultTxDetail) Lower(value VaultTxDetail) RustBuffer {
+	return LowerIntoRustBuffer[VaultTxDetail](c, value)
 }
 
-func (FfiConverterTypeDirection) Write(writer io.Writer, value Direction) {
-	writeInt32(writer, int32(value))
+func (c FfiConverterTypeVaultTxDetail) Write(writer io.Writer, value VaultTxDetail) {
+	FfiConverterTypeVaultTxINSTANCE.Write(writer, value.Tx)
+	FfiConverterSequenceTypeTxInputINSTANCE.Write(writer, value.Inputs)
+	FfiConverterSequenceTypeOutputINSTANCE.Write(writer, value.Outputs)
+	FfiConverterUint64INSTANCE.Write(writer, value.Fee)
+	FfiConverterStringINSTANCE.Write(writer, value.BlockHash)
+	FfiConverterUint32INSTANCE.Write(writer, value.BlockTime)
+	FfiConverterUint32INSTANCE.Write(writer, value.Position)
+	FfiConverterUint32INSTANCE.Write(writer, value.Confirmations)
 }
 
-type FfiDestroyerTypeDirection struct{}
+type FfiDestroyerTypeVaultTxDetail struct{}
 
-func (_ FfiDestroyerTypeDirection) Destroy(value Direction) {
+func (_ FfiDestroyerTypeVaultTxDetail) Destroy(value VaultTxDetail) {
+	value.Destroy()
 }
 
-type ZcashError struct {
-	err error
+type ChainEventKind uint
+
+const (
+	ChainEventKindBlockConnected    ChainEventKind = 1
+	ChainEventKindBlockDisconnected ChainEventKind = 2
+)
+
+type FfiConverterTypeChainEventKind struct{}
+
+var FfiConverterTypeChainEventKindINSTANCE = FfiConverterTypeChainEventKind{}
+
+func (c FfiConverterTypeChainEventKind) Lift(rb RustBufferI) ChainEventKind {
+	return LiftFromRustBuffer[ChainEventKind](c, rb)
+}
+
+func (c FfiConverterTypeChainEventKind) Lower(value ChainEventKind) RustBuffer {
+	return LowerIntoRustBuffer[ChainEventKind](c, value)
+}
+func (FfiConverterTypeChainEventKind) Read(reader io.Reader) ChainEventKind {
+	id := readInt32(reader)
+	return ChainEventKind(id)
+}
+
+func (FfiConverterTypeChainEventKind) Write(writer io.Writer, value ChainEventKind) {
+	writeInt32(writer, int32(value))
+}
+
+type FfiDestroyerTypeChainEventKind struct{}
+
+func (_ FfiDestroyerTypeChainEventKind) Destroy(value ChainEventKind) {
+}
+
+type Direction uint
+
+const (
+	DirectionIncoming  Direction = 1
+	DirectionOutgoing  Direction = 2
+	DirectionInternal  Direction = 3
+	DirectionMigration Direction = 4
+)
+
+type FfiConverterTypeDirection struct{}
+
+var FfiConverterTypeDirectionINSTANCE = FfiConverterTypeDirection{}
+
+func (c FfiConverterTypeDirection) Lift(rb RustBufferI) Direction {
+	return LiftFromRustBuffer[Direction](c, rb)
+}
+
+func (c FfiConverterTypeDirection) Lower(value Direction) RustBuffer {
+	return LowerIntoRustBuffer[Direction](c, value)
+}
+func (FfiConverterTypeDirection) Read(reader io.Reader) Direction {
+	id := readInt32(reader)
+	return Direction(id)
+}
+
+func (FfiConverterTypeDirection) Write(writer io.Writer, value Direction) {
+	writeInt32(writer, int32(value))
+}
+
+type FfiDestroyerTypeDirection struct{}
+
+func (_ FfiDestroyerTypeDirection) Destroy(value Direction) {
+}
+
+type MemoKind uint
+
+const (
+	MemoKindText      MemoKind = 1
+	MemoKindArbitrary MemoKind = 2
+	MemoKindFuture    MemoKind = 3
+)
+
+type FfiConverterTypeMemoKind struct{}
+
+var FfiConverterTypeMemoKindINSTANCE = FfiConverterTypeMemoKind{}
+
+func (c FfiConverterTypeMemoKind) Lift(rb RustBufferI) MemoKind {
+	return LiftFromRustBuffer[MemoKind](c, rb)
+}
+
+func (c FfiConverterTypeMemoKind) Lower(value MemoKind) RustBuffer {
+	return LowerIntoRustBuffer[MemoKind](c, value)
+}
+func (FfiConverterTypeMemoKind) Read(reader io.Reader) MemoKind {
+	id := readInt32(reader)
+	return MemoKind(id)
+}
+
+func (FfiConverterTypeMemoKind) Write(writer io.Writer, value MemoKind) {
+	writeInt32(writer, int32(value))
+}
+
+type FfiDestroyerTypeMemoKind struct{}
+
+func (_ FfiDestroyerTypeMemoKind) Destroy(value MemoKind) {
+}
+
+type Network uint
+
+const (
+	NetworkMain    Network = 1
+	NetworkRegtest Network = 2
+)
+
+type FfiConverterTypeNetwork struct{}
+
+var FfiConverterTypeNetworkINSTANCE = FfiConverterTypeNetwork{}
+
+func (c FfiConverterTypeNetwork) Lift(rb RustBufferI) Network {
+	return LiftFromRustBuffer[Network](c, rb)
+}
+
+func (c FfiConverterTypeNetwork) Lower(value Network) RustBuffer {
+	return LowerIntoRustBuffer[Network](c, value)
+}
+func (FfiConverterTypeNetwork) Read(reader io.Reader) Network {
+	id := readInt32(reader)
+	return Network(id)
+}
+
+func (FfiConverterTypeNetwork) Write(writer io.Writer, value Network) {
+	writeInt32(writer, int32(value))
+}
+
+type FfiDestroyerTypeNetwork struct{}
+
+func (_ FfiDestroyerTypeNetwork) Destroy(value Network) {
+}
+
+type ScriptKind uint
+
+const (
+	ScriptKindP2Pkh    ScriptKind = 1
+	ScriptKindP2Sh     ScriptKind = 2
+	ScriptKindOpReturn ScriptKind = 3
+	ScriptKindUnknown  ScriptKind = 4
+)
+
+type FfiConverterTypeScriptKind struct{}
+
+var FfiConverterTypeScriptKindINSTANCE = FfiConverterTypeScriptKind{}
+
+func (c FfiConverterTypeScriptKind) Lift(rb RustBufferI) ScriptKind {
+	return LiftFromRustBuffer[ScriptKind](c, rb)
+}
+
+func (c FfiConverterTypeScriptKind) Lower(value ScriptKind) RustBuffer {
+	return LowerIntoRustBuffer[ScriptKind](c, value)
+}
+func (FfiConverterTypeScriptKind) Read(reader io.Reader) ScriptKind {
+	id := readInt32(reader)
+	return ScriptKind(id)
+}
+
+func (FfiConverterTypeScriptKind) Write(writer io.Writer, value ScriptKind) {
+	writeInt32(writer, int32(value))
+}
+
+type FfiDestroyerTypeScriptKind struct{}
+
+func (_ FfiDestroyerTypeScriptKind) Destroy(value ScriptKind) {
+}
+
+type ShieldedPool uint
+
+const (
+	ShieldedPoolSapling ShieldedPool = 1
+	ShieldedPoolOrchard ShieldedPool = 2
+)
+
+type FfiConverterTypeShieldedPool struct{}
+
+var FfiConverterTypeShieldedPoolINSTANCE = FfiConverterTypeShieldedPool{}
+
+func (c FfiConverterTypeShieldedPool) Lift(rb RustBufferI) ShieldedPool {
+	return LiftFromRustBuffer[ShieldedPool](c, rb)
+}
+
+func (c FfiConverterTypeShieldedPool) Lower(value ShieldedPool) RustBuffer {
+	return LowerIntoRustBuffer[ShieldedPool](c, value)
+}
+func (FfiConverterTypeShieldedPool) Read(reader io.Reader) ShieldedPool {
+	id := readInt32(reader)
+	return ShieldedPool(id)
+}
+
+func (FfiConverterTypeShieldedPool) Write(writer io.Writer, value ShieldedPool) {
+	writeInt32(writer, int32(value))
+}
+
+type FfiDestroyerTypeShieldedPool struct{}
+
+func (_ FfiDestroyerTypeShieldedPool) Destroy(value ShieldedPool) {
+}
+
+type VaultEventKind uint
+
+const (
+	VaultEventKindMempool    VaultEventKind = 1
+	VaultEventKindConfirmed  VaultEventKind = 2
+	VaultEventKindRemoved    VaultEventKind = 3
+	VaultEventKindConflicted VaultEventKind = 4
+)
+
+type FfiConverterTypeVaultEventKind struct{}
+
+var FfiConverterTypeVaultEventKindINSTANCE = FfiConverterTypeVaultEventKind{}
+
+func (c FfiConverterTypeVaultEventKind) Lift(rb RustBufferI) VaultEventKind {
+	return LiftFromRustBuffer[VaultEventKind](c, rb)
+}
+
+func (c FfiConverterTypeVaultEventKind) Lower(value VaultEventKind) RustBuffer {
+	return LowerIntoRustBuffer[VaultEventKind](c, value)
+}
+func (FfiConverterTypeVaultEventKind) Read(reader io.Reader) VaultEventKind {
+	id := readInt32(reader)
+	return VaultEventKind(id)
+}
+
+func (FfiConverterTypeVaultEventKind) Write(writer io.Writer, value VaultEventKind) {
+	writeInt32(writer, int32(value))
+}
+
+type FfiDestroyerTypeVaultEventKind struct{}
+
+func (_ FfiDestroyerTypeVaultEventKind) Destroy(value VaultEventKind) {
+}
+
+type ZcashError struct {
+	err error
 }
 
 func (err ZcashError) Error() string {
@@ -1454,6 +2744,80 @@ func (_ FfiDestroyerOptionalString) Destroy(value *string) {
 	}
 }
 
+type FfiConverterOptionalBytes struct{}
+
+var FfiConverterOptionalBytesINSTANCE = FfiConverterOptionalBytes{}
+
+func (c FfiConverterOptionalBytes) Lift(rb RustBufferI) *[]byte {
+	return LiftFromRustBuffer[*[]byte](c, rb)
+}
+
+func (_ FfiConverterOptionalBytes) Read(reader io.Reader) *[]byte {
+	if readInt8(reader) == 0 {
+		return nil
+	}
+	temp := FfiConverterBytesINSTANCE.Read(reader)
+	return &temp
+}
+
+func (c FfiConverterOptionalBytes) Lower(value *[]byte) RustBuffer {
+	return LowerIntoRustBuffer[*[]byte](c, value)
+}
+
+func (_ FfiConverterOptionalBytes) Write(writer io.Writer, value *[]byte) {
+	if value == nil {
+		writeInt8(writer, 0)
+	} else {
+		writeInt8(writer, 1)
+		FfiConverterBytesINSTANCE.Write(writer, *value)
+	}
+}
+
+type FfiDestroyerOptionalBytes struct{}
+
+func (_ FfiDestroyerOptionalBytes) Destroy(value *[]byte) {
+	if value != nil {
+		FfiDestroyerBytes{}.Destroy(*value)
+	}
+}
+
+type FfiConverterOptionalTypeBlockTxDetails struct{}
+
+var FfiConverterOptionalTypeBlockTxDetailsINSTANCE = FfiConverterOptionalTypeBlockTxDetails{}
+
+func (c FfiConverterOptionalTypeBlockTxDetails) Lift(rb RustBufferI) *BlockTxDetails {
+	return LiftFromRustBuffer[*BlockTxDetails](c, rb)
+}
+
+func (_ FfiConverterOptionalTypeBlockTxDetails) Read(reader io.Reader) *BlockTxDetails {
+	if readInt8(reader) == 0 {
+		return nil
+	}
+	temp := FfiConverterTypeBlockTxDetailsINSTANCE.Read(reader)
+	return &temp
+}
+
+func (c FfiConverterOptionalTypeBlockTxDetails) Lower(value *BlockTxDetails) RustBuffer {
+	return LowerIntoRustBuffer[*BlockTxDetails](c, value)
+}
+
+func (_ FfiConverterOptionalTypeBlockTxDetails) Write(writer io.Writer, value *BlockTxDetails) {
+	if value == nil {
+		writeInt8(writer, 0)
+	} else {
+		writeInt8(writer, 1)
+		FfiConverterTypeBlockTxDetailsINSTANCE.Write(writer, *value)
+	}
+}
+
+type FfiDestroyerOptionalTypeBlockTxDetails struct{}
+
+func (_ FfiDestroyerOptionalTypeBlockTxDetails) Destroy(value *BlockTxDetails) {
+	if value != nil {
+		FfiDestroyerTypeBlockTxDetails{}.Destroy(*value)
+	}
+}
+
 type FfiConverterOptionalTypeBlockTxs struct{}
 
 var FfiConverterOptionalTypeBlockTxsINSTANCE = FfiConverterOptionalTypeBlockTxs{}
@@ -1491,95 +2855,292 @@ func (_ FfiDestroyerOptionalTypeBlockTxs) Destroy(value *BlockTxs) {
 	}
 }
 
-type FfiConverterSequenceString struct{}
+type FfiConverterOptionalTypeHeight struct{}
 
-var FfiConverterSequenceStringINSTANCE = FfiConverterSequenceString{}
+var FfiConverterOptionalTypeHeightINSTANCE = FfiConverterOptionalTypeHeight{}
 
-func (c FfiConverterSequenceString) Lift(rb RustBufferI) []string {
-	return LiftFromRustBuffer[[]string](c, rb)
+func (c FfiConverterOptionalTypeHeight) Lift(rb RustBufferI) *Height {
+	return LiftFromRustBuffer[*Height](c, rb)
 }
 
-func (c FfiConverterSequenceString) Read(reader io.Reader) []string {
-	length := readInt32(reader)
-	if length == 0 {
+func (_ FfiConverterOptionalTypeHeight) Read(reader io.Reader) *Height {
+	if readInt8(reader) == 0 {
 		return nil
 	}
-	result := make([]string, 0, length)
-	for i := int32(0); i < length; i++ {
-		result = append(result, FfiConverterStringINSTANCE.Read(reader))
-	}
-	return result
+	temp := FfiConverterTypeHeightINSTANCE.Read(reader)
+	return &temp
 }
 
-func (c FfiConverterSequenceString) Lower(value []string) RustBuffer {
-	return LowerIntoRustBuffer[[]string](c, value)
+func (c FfiConverterOptionalTypeHeight) Lower(value *Height) RustBuffer {
+	return LowerIntoRustBuffer[*Height](c, value)
 }
 
-func (c FfiConverterSequenceString) Write(writer io.Writer, value []string) {
-	if len(value) > math.MaxInt32 {
-		panic("[]string is too large to fit into Int32")
-	}
-
-	writeInt32(writer, int32(len(value)))
-	for _, item := range value {
-		FfiConverterStringINSTANCE.Write(writer, item)
+func (_ FfiConverterOptionalTypeHeight) Write(writer io.Writer, value *Height) {
+	if value == nil {
+		writeInt8(writer, 0)
+	} else {
+		writeInt8(writer, 1)
+		FfiConverterTypeHeightINSTANCE.Write(writer, *value)
 	}
 }
 
-type FfiDestroyerSequenceString struct{}
+type FfiDestroyerOptionalTypeHeight struct{}
 
-func (FfiDestroyerSequenceString) Destroy(sequence []string) {
-	for _, value := range sequence {
-		FfiDestroyerString{}.Destroy(value)
+func (_ FfiDestroyerOptionalTypeHeight) Destroy(value *Height) {
+	if value != nil {
+		FfiDestroyerTypeHeight{}.Destroy(*value)
 	}
 }
 
-type FfiConverterSequenceBytes struct{}
+type FfiConverterOptionalTypeVaultTx struct{}
 
-var FfiConverterSequenceBytesINSTANCE = FfiConverterSequenceBytes{}
+var FfiConverterOptionalTypeVaultTxINSTANCE = FfiConverterOptionalTypeVaultTx{}
 
-func (c FfiConverterSequenceBytes) Lift(rb RustBufferI) [][]byte {
-	return LiftFromRustBuffer[[][]byte](c, rb)
+func (c FfiConverterOptionalTypeVaultTx) Lift(rb RustBufferI) *VaultTx {
+	return LiftFromRustBuffer[*VaultTx](c, rb)
 }
 
-func (c FfiConverterSequenceBytes) Read(reader io.Reader) [][]byte {
-	length := readInt32(reader)
-	if length == 0 {
+func (_ FfiConverterOptionalTypeVaultTx) Read(reader io.Reader) *VaultTx {
+	if readInt8(reader) == 0 {
 		return nil
 	}
-	result := make([][]byte, 0, length)
-	for i := int32(0); i < length; i++ {
-		result = append(result, FfiConverterBytesINSTANCE.Read(reader))
-	}
-	return result
+	temp := FfiConverterTypeVaultTxINSTANCE.Read(reader)
+	return &temp
 }
 
-func (c FfiConverterSequenceBytes) Lower(value [][]byte) RustBuffer {
-	return LowerIntoRustBuffer[[][]byte](c, value)
+func (c FfiConverterOptionalTypeVaultTx) Lower(value *VaultTx) RustBuffer {
+	return LowerIntoRustBuffer[*VaultTx](c, value)
 }
 
-func (c FfiConverterSequenceBytes) Write(writer io.Writer, value [][]byte) {
-	if len(value) > math.MaxInt32 {
-		panic("[][]byte is too large to fit into Int32")
-	}
-
-	writeInt32(writer, int32(len(value)))
-	for _, item := range value {
-		FfiConverterBytesINSTANCE.Write(writer, item)
+func (_ FfiConverterOptionalTypeVaultTx) Write(writer io.Writer, value *VaultTx) {
+	if value == nil {
+		writeInt8(writer, 0)
+	} else {
+		writeInt8(writer, 1)
+		FfiConverterTypeVaultTxINSTANCE.Write(writer, *value)
 	}
 }
 
-type FfiDestroyerSequenceBytes struct{}
+type FfiDestroyerOptionalTypeVaultTx struct{}
 
-func (FfiDestroyerSequenceBytes) Destroy(sequence [][]byte) {
-	for _, value := range sequence {
-		FfiDestroyerBytes{}.Destroy(value)
+func (_ FfiDestroyerOptionalTypeVaultTx) Destroy(value *VaultTx) {
+	if value != nil {
+		FfiDestroyerTypeVaultTx{}.Destroy(*value)
 	}
 }
 
-type FfiConverterSequenceTypeOutput struct{}
+type FfiConverterOptionalTypeMemoKind struct{}
 
-var FfiConverterSequenceTypeOutputINSTANCE = FfiConverterSequenceTypeOutput{}
+var FfiConverterOptionalTypeMemoKindINSTANCE = FfiConverterOptionalTypeMemoKind{}
+
+func (c FfiConverterOptionalTypeMemoKind) Lift(rb RustBufferI) *MemoKind {
+	return LiftFromRustBuffer[*MemoKind](c, rb)
+}
+
+func (_ FfiConverterOptionalTypeMemoKind) Read(reader io.Reader) *MemoKind {
+	if readInt8(reader) == 0 {
+		return nil
+	}
+	temp := FfiConverterTypeMemoKindINSTANCE.Read(reader)
+	return &temp
+}
+
+func (c FfiConverterOptionalTypeMemoKind) Lower(value *MemoKind) RustBuffer {
+	return LowerIntoRustBuffer[*MemoKind](c, value)
+}
+
+func (_ FfiConverterOptionalTypeMemoKind) Write(writer io.Writer, value *MemoKind) {
+	if value == nil {
+		writeInt8(writer, 0)
+	} else {
+		writeInt8(writer, 1)
+		FfiConverterTypeMemoKindINSTANCE.Write(writer, *value)
+	}
+}
+
+type FfiDestroyerOptionalTypeMemoKind struct{}
+
+func (_ FfiDestroyerOptionalTypeMemoKind) Destroy(value *MemoKind) {
+	if value != nil {
+		FfiDestroyerTypeMemoKind{}.Destroy(*value)
+	}
+}
+
+type FfiConverterSequenceString struct{}
+
+var FfiConverterSequenceStringINSTANCE = FfiConverterSequenceString{}
+
+func (c FfiConverterSequenceString) Lift(rb RustBufferI) []string {
+	return LiftFromRustBuffer[[]string](c, rb)
+}
+
+func (c FfiConverterSequenceString) Read(reader io.Reader) []string {
+	length := readInt32(reader)
+	if length == 0 {
+		return nil
+	}
+	result := make([]string, 0, length)
+	for i := int32(0); i < length; i++ {
+		result = append(result, FfiConverterStringINSTANCE.Read(reader))
+	}
+	return result
+}
+
+func (c FfiConverterSequenceString) Lower(value []string) RustBuffer {
+	return LowerIntoRustBuffer[[]string](c, value)
+}
+
+func (c FfiConverterSequenceString) Write(writer io.Writer, value []string) {
+	if len(value) > math.MaxInt32 {
+		panic("[]string is too large to fit into Int32")
+	}
+
+	writeInt32(writer, int32(len(value)))
+	for _, item := range value {
+		FfiConverterStringINSTANCE.Write(writer, item)
+	}
+}
+
+type FfiDestroyerSequenceString struct{}
+
+func (FfiDestroyerSequenceString) Destroy(sequence []string) {
+	for _, value := range sequence {
+		FfiDestroyerString{}.Destroy(value)
+	}
+}
+
+type FfiConverterSequenceBytes struct{}
+
+var FfiConverterSequenceBytesINSTANCE = FfiConverterSequenceBytes{}
+
+func (c FfiConverterSequenceBytes) Lift(rb RustBufferI) [][]byte {
+	return LiftFromRustBuffer[[][]byte](c, rb)
+}
+
+func (c FfiConverterSequenceBytes) Read(reader io.Reader) [][]byte {
+	length := readInt32(reader)
+	if length == 0 {
+		return nil
+	}
+	result := make([][]byte, 0, length)
+	for i := int32(0); i < length; i++ {
+		result = append(result, FfiConverterBytesINSTANCE.Read(reader))
+	}
+	return result
+}
+
+func (c FfiConverterSequenceBytes) Lower(value [][]byte) RustBuffer {
+	return LowerIntoRustBuffer[[][]byte](c, value)
+}
+
+func (c FfiConverterSequenceBytes) Write(writer io.Writer, value [][]byte) {
+	if len(value) > math.MaxInt32 {
+		panic("[][]byte is too large to fit into Int32")
+	}
+
+	writeInt32(writer, int32(len(value)))
+	for _, item := range value {
+		FfiConverterBytesINSTANCE.Write(writer, item)
+	}
+}
+
+type FfiDestroyerSequenceBytes struct{}
+
+func (FfiDestroyerSequenceBytes) Destroy(sequence [][]byte) {
+	for _, value := range sequence {
+		FfiDestroyerBytes{}.Destroy(value)
+	}
+}
+
+type FfiConverterSequenceTypeChainEvent struct{}
+
+var FfiConverterSequenceTypeChainEventINSTANCE = FfiConverterSequenceTypeChainEvent{}
+
+func (c FfiConverterSequenceTypeChainEvent) Lift(rb RustBufferI) []ChainEvent {
+	return LiftFromRustBuffer[[]ChainEvent](c, rb)
+}
+
+func (c FfiConverterSequenceTypeChainEvent) Read(reader io.Reader) []ChainEvent {
+	length := readInt32(reader)
+	if length == 0 {
+		return nil
+	}
+	result := make([]ChainEvent, 0, length)
+	for i := int32(0); i < length; i++ {
+		result = append(result, FfiConverterTypeChainEventINSTANCE.Read(reader))
+	}
+	return result
+}
+
+func (c FfiConverterSequenceTypeChainEvent) Lower(value []ChainEvent) RustBuffer {
+	return LowerIntoRustBuffer[[]ChainEvent](c, value)
+}
+
+func (c FfiConverterSequenceTypeChainEvent) Write(writer io.Writer, value []ChainEvent) {
+	if len(value) > math.MaxInt32 {
+		panic("[]ChainEvent is too large to fit into Int32")
+	}
+
+	writeInt32(writer, int32(len(value)))
+	for _, item := range value {
+		FfiConverterTypeChainEventINSTANCE.Write(writer, item)
+	}
+}
+
+type FfiDestroyerSequenceTypeChainEvent struct{}
+
+func (FfiDestroyerSequenceTypeChainEvent) Destroy(sequence []ChainEvent) {
+	for _, value := range sequence {
+		FfiDestroyerTypeChainEvent{}.Destroy(value)
+	}
+}
+
+type FfiConverterSequenceTypeConfirmationTier struct{}
+
+var FfiConverterSequenceTypeConfirmationTierINSTANCE = FfiConverterSequenceTypeConfirmationTier{}
+
+func (c FfiConverterSequenceTypeConfirmationTier) Lift(rb RustBufferI) []ConfirmationTier {
+	return LiftFromRustBuffer[[]ConfirmationTier](c, rb)
+}
+
+func (c FfiConverterSequenceTypeConfirmationTier) Read(reader io.Reader) []ConfirmationTier {
+	length := readInt32(reader)
+	if length == 0 {
+		return nil
+	}
+	result := make([]ConfirmationTier, 0, length)
+	for i := int32(0); i < length; i++ {
+		result = append(result, FfiConverterTypeConfirmationTierINSTANCE.Read(reader))
+	}
+	return result
+}
+
+func (c FfiConverterSequenceTypeConfirmationTier) Lower(value []ConfirmationTier) RustBuffer {
+	return LowerIntoRustBuffer[[]ConfirmationTier](c, value)
+}
+
+func (c FfiConverterSequenceTypeConfirmationTier) Write(writer io.Writer, value []ConfirmationTier) {
+	if len(value) > math.MaxInt32 {
+		panic("[]ConfirmationTier is too large to fit into Int32")
+	}
+
+	writeInt32(writer, int32(len(value)))
+	for _, item := range value {
+		FfiConverterTypeConfirmationTierINSTANCE.Write(writer, item)
+	}
+}
+
+type FfiDestroyerSequenceTypeConfirmationTier struct{}
+
+func (FfiDestroyerSequenceTypeConfirmationTier) Destroy(sequence []ConfirmationTier) {
+	for _, value := range sequence {
+		FfiDestroyerTypeConfirmationTier{}.Destroy(value)
+	}
+}
+
+type FfiConverterSequenceTypeOutput struct{}
+
+var FfiConverterSequenceTypeOutputINSTANCE = FfiConverterSequenceTypeOutput{}
 
 func (c FfiConverterSequenceTypeOutput) Lift(rb RustBufferI) []Output {
 	return LiftFromRustBuffer[[]Output](c, rb)
@@ -1620,6 +3181,221 @@ func (FfiDestroyerSequenceTypeOutput) Destroy(sequence []Output) {
 	}
 }
 
+type FfiConverterSequenceTypeShieldedNote struct{}
+
+var FfiConverterSequenceTypeShieldedNoteINSTANCE = FfiConverterSequenceTypeShieldedNote{}
+
+func (c FfiConverterSequenceTypeShieldedNote) Lift(rb RustBufferI) []ShieldedNote {
+	return LiftFromRustBuffer[[]ShieldedNote](c, rb)
+}
+
+func (c FfiConverterSequenceTypeShieldedNote) Read(reader io.Reader) []ShieldedNote {
+	length := readInt32(reader)
+	if length == 0 {
+		return nil
+	}
+	result := make([]ShieldedNote, 0, length)
+	for i := int32(0); i < length; i++ {
+		result = append(result, FfiConverterTypeShieldedNoteINSTANCE.Read(reader))
+	}
+	return result
+}
+
+func (c FfiConverterSequenceTypeShieldedNote) Lower(value []ShieldedNote) RustBuffer {
+	return LowerIntoRustBuffer[[]ShieldedNote](c, value)
+}
+
+func (c FfiConverterSequenceTypeShieldedNote) Write(writer io.Writer, value []ShieldedNote) {
+	if len(value) > math.MaxInt32 {
+		panic("[]ShieldedNote is too large to fit into Int32")
+	}
+
+	writeInt32(writer, int32(len(value)))
+	for _, item := range value {
+		FfiConverterTypeShieldedNoteINSTANCE.Write(writer, item)
+	}
+}
+
+type FfiDestroyerSequenceTypeShieldedNote struct{}
+
+func (FfiDestroyerSequenceTypeShieldedNote) Destroy(sequence []ShieldedNote) {
+	for _, value := range sequence {
+		FfiDestroyerTypeShieldedNote{}.Destroy(value)
+	}
+}
+
+type FfiConverterSequenceTypeStoredUTXO struct{}
+
+var FfiConverterSequenceTypeStoredUTXOINSTANCE = FfiConverterSequenceTypeStoredUTXO{}
+
+func (c FfiConverterSequenceTypeStoredUTXO) Lift(rb RustBufferI) []StoredUtxo {
+	return LiftFromRustBuffer[[]StoredUtxo](c, rb)
+}
+
+func (c FfiConverterSequenceTypeStoredUTXO) Read(reader io.Reader) []StoredUtxo {
+	length := readInt32(reader)
+	if length == 0 {
+		return nil
+	}
+	result := make([]StoredUtxo, 0, length)
+	for i := int32(0); i < length; i++ {
+		result = append(result, FfiConverterTypeStoredUTXOINSTANCE.Read(reader))
+	}
+	return result
+}
+
+func (c FfiConverterSequenceTypeStoredUTXO) Lower(value []StoredUtxo) RustBuffer {
+	return LowerIntoRustBuffer[[]StoredUtxo](c, value)
+}
+
+func (c FfiConverterSequenceTypeStoredUTXO) Write(writer io.Writer, value []StoredUtxo) {
+	if len(value) > math.MaxInt32 {
+		panic("[]StoredUtxo is too large to fit into Int32")
+	}
+
+	writeInt32(writer, int32(len(value)))
+	for _, item := range value {
+		FfiConverterTypeStoredUTXOINSTANCE.Write(writer, item)
+	}
+}
+
+type FfiDestroyerSequenceTypeStoredUtxo struct{}
+
+func (FfiDestroyerSequenceTypeStoredUtxo) Destroy(sequence []StoredUtxo) {
+	for _, value := range sequence {
+		FfiDestroyerTypeStoredUtxo{}.Destroy(value)
+	}
+}
+
+type FfiConverterSequenceTypeTransparentKey struct{}
+
+var FfiConverterSequenceTypeTransparentKeyINSTANCE = FfiConverterSequenceTypeTransparentKey{}
+
+func (c FfiConverterSequenceTypeTransparentKey) Lift(rb RustBufferI) []TransparentKey {
+	return LiftFromRustBuffer[[]TransparentKey](c, rb)
+}
+
+func (c FfiConverterSequenceTypeTransparentKey) Read(reader io.Reader) []TransparentKey {
+	length := readInt32(reader)
+	if length == 0 {
+		return nil
+	}
+	result := make([]TransparentKey, 0, length)
+	for i := int32(0); i < length; i++ {
+		result = append(result, FfiConverterTypeTransparentKeyINSTANCE.Read(reader))
+	}
+	return result
+}
+
+func (c FfiConverterSequenceTypeTransparentKey) Lower(value []TransparentKey) RustBuffer {
+	return LowerIntoRustBuffer[[]TransparentKey](c, value)
+}
+
+func (c FfiConverterSequenceTypeTransparentKey) Write(writer io.Writer, value []TransparentKey) {
+	if len(value) > math.MaxInt32 {
+		panic("[]TransparentKey is too large to fit into Int32")
+	}
+
+	writeInt32(writer, int32(len(value)))
+	for _, item := range value {
+		FfiConverterTypeTransparentKeyINSTANCE.Write(writer, item)
+	}
+}
+
+type FfiDestroyerSequenceTypeTransparentKey struct{}
+
+func (FfiDestroyerSequenceTypeTransparentKey) Destroy(sequence []TransparentKey) {
+	for _, value := range sequence {
+		FfiDestroyerTypeTransparentKey{}.Destroy(value)
+	}
+}
+
+type FfiConverterSequenceTypeTxConfirmation struct{}
+
+var FfiConverterSequenceTypeTxConfirmationINSTANCE = FfiConverterSequenceTypeTxConfirmation{}
+
+func (c FfiConverterSequenceTypeTxConfirmation) Lift(rb RustBufferI) []TxConfirmation {
+	return LiftFromRustBuffer[[]TxConfirmation](c, rb)
+}
+
+func (c FfiConverterSequenceTypeTxConfirmation) Read(reader io.Reader) []TxConfirmation {
+	length := readInt32(reader)
+	if length == 0 {
+		return nil
+	}
+	result := make([]TxConfirmation, 0, length)
+	for i := int32(0); i < length; i++ {
+		result = append(result, FfiConverterTypeTxConfirmationINSTANCE.Read(reader))
+	}
+	return result
+}
+
+func (c FfiConverterSequenceTypeTxConfirmation) Lower(value []TxConfirmation) RustBuffer {
+	return LowerIntoRustBuffer[[]TxConfirmation](c, value)
+}
+
+func (c FfiConverterSequenceTypeTxConfirmation) Write(writer io.Writer, value []TxConfirmation) {
+	if len(value) > math.MaxInt32 {
+		panic("[]TxConfirmation is too large to fit into Int32")
+	}
+
+	writeInt32(writer, int32(len(value)))
+	for _, item := range value {
+		FfiConverterTypeTxConfirmationINSTANCE.Write(writer, item)
+	}
+}
+
+type FfiDestroyerSequenceTypeTxConfirmation struct{}
+
+func (FfiDestroyerSequenceTypeTxConfirmation) Destroy(sequence []TxConfirmation) {
+	for _, value := range sequence {
+		FfiDestroyerTypeTxConfirmation{}.Destroy(value)
+	}
+}
+
+type FfiConverterSequenceTypeTxInput struct{}
+
+var FfiConverterSequenceTypeTxInputINSTANCE = FfiConverterSequenceTypeTxInput{}
+
+func (c FfiConverterSequenceTypeTxInput) Lift(rb RustBufferI) []TxInput {
+	return LiftFromRustBuffer[[]TxInput](c, rb)
+}
+
+func (c FfiConverterSequenceTypeTxInput) Read(reader io.Reader) []TxInput {
+	length := readInt32(reader)
+	if length == 0 {
+		return nil
+	}
+	result := make([]TxInput, 0, length)
+	for i := int32(0); i < length; i++ {
+		result = append(result, FfiConverterTypeTxInputINSTANCE.Read(reader))
+	}
+	return result
+}
+
+func (c FfiConverterSequenceTypeTxInput) Lower(value []TxInput) RustBuffer {
+	return LowerIntoRustBuffer[[]TxInput](c, value)
+}
+
+func (c FfiConverterSequenceTypeTxInput) Write(writer io.Writer, value []TxInput) {
+	if len(value) > math.MaxInt32 {
+		panic("[]TxInput is too large to fit into Int32")
+	}
+
+	writeInt32(writer, int32(len(value)))
+	for _, item := range value {
+		FfiConverterTypeTxInputINSTANCE.Write(writer, item)
+	}
+}
+
+type FfiDestroyerSequenceTypeTxInput struct{}
+
+func (FfiDestroyerSequenceTypeTxInput) Destroy(sequence []TxInput) {
+	for _, value := range sequence {
+		FfiDestroyerTypeTxInput{}.Destroy(value)
+	}
+}
+
 type FfiConverterSequenceTypeUTXO struct{}
 
 var FfiConverterSequenceTypeUTXOINSTANCE = FfiConverterSequenceTypeUTXO{}
@@ -1663,6 +3439,92 @@ func (FfiDestroyerSequenceTypeUtxo) Destroy(sequence []Utxo) {
 	}
 }
 
+type FfiConverterSequenceTypeUndecryptedOutput struct{}
+
+var FfiConverterSequenceTypeUndecryptedOutputINSTANCE = FfiConverterSequenceTypeUndecryptedOutput{}
+
+func (c FfiConverterSequenceTypeUndecryptedOutput) Lift(rb RustBufferI) []UndecryptedOutput {
+	return LiftFromRustBuffer[[]UndecryptedOutput](c, rb)
+}
+
+func (c FfiConverterSequenceTypeUndecryptedOutput) Read(reader io.Reader) []UndecryptedOutput {
+	length := readInt32(reader)
+	if length == 0 {
+		return nil
+	}
+	result := make([]UndecryptedOutput, 0, length)
+	for i := int32(0); i < length; i++ {
+		result = append(result, FfiConverterTypeUndecryptedOutputINSTANCE.Read(reader))
+	}
+	return result
+}
+
+func (c FfiConverterSequenceTypeUndecryptedOutput) Lower(value []UndecryptedOutput) RustBuffer {
+	return LowerIntoRustBuffer[[]UndecryptedOutput](c, value)
+}
+
+func (c FfiConverterSequenceTypeUndecryptedOutput) Write(writer io.Writer, value []UndecryptedOutput) {
+	if len(value) > math.MaxInt32 {
+		panic("[]UndecryptedOutput is too large to fit into Int32")
+	}
+
+	writeInt32(writer, int32(len(value)))
+	for _, item := range value {
+		FfiConverterTypeUndecryptedOutputINSTANCE.Write(writer, item)
+	}
+}
+
+type FfiDestroyerSequenceTypeUndecryptedOutput struct{}
+
+func (FfiDestroyerSequenceTypeUndecryptedOutput) Destroy(sequence []UndecryptedOutput) {
+	for _, value := range sequence {
+		FfiDestroyerTypeUndecryptedOutput{}.Destroy(value)
+	}
+}
+
+type FfiConverterSequenceTypeVaultEvent struct{}
+
+var FfiConverterSequenceTypeVaultEventINSTANCE = FfiConverterSequenceTypeVaultEvent{}
+
+func (c FfiConverterSequenceTypeVaultEvent) Lift(rb RustBufferI) []VaultEvent {
+	return LiftFromRustBuffer[[]VaultEvent](c, rb)
+}
+
+func (c FfiConverterSequenceTypeVaultEvent) Read(reader io.Reader) []VaultEvent {
+	length := readInt32(reader)
+	if length == 0 {
+		return nil
+	}
+	result := make([]VaultEvent, 0, length)
+	for i := int32(0); i < length; i++ {
+		result = append(result, FfiConverterTypeVaultEventINSTANCE.Read(reader))
+	}
+	return result
+}
+
+func (c FfiConverterSequenceTypeVaultEvent) Lower(value []VaultEvent) RustBuffer {
+	return LowerIntoRustBuffer[[]VaultEvent](c, value)
+}
+
+func (c FfiConverterSequenceTypeVaultEvent) Write(writer io.Writer, value []VaultEvent) {
+	if len(value) > math.MaxInt32 {
+		panic("[]VaultEvent is too large to fit into Int32")
+	}
+
+	writeInt32(writer, int32(len(value)))
+	for _, item := range value {
+		FfiConverterTypeVaultEventINSTANCE.Write(writer, item)
+	}
+}
+
+type FfiDestroyerSequenceTypeVaultEvent struct{}
+
+func (FfiDestroyerSequenceTypeVaultEvent) Destroy(sequence []VaultEvent) {
+	for _, value := range sequence {
+		FfiDestroyerTypeVaultEvent{}.Destroy(value)
+	}
+}
+
 type FfiConverterSequenceTypeVaultTx struct{}
 
 var FfiConverterSequenceTypeVaultTxINSTANCE = FfiConverterSequenceTypeVaultTx{}
@@ -1692,56 +3554,260 @@ func (c FfiConverterSequenceTypeVaultTx) Write(writer io.Writer, value []VaultTx
 		panic("[]VaultTx is too large to fit into Int32")
 	}
 
-	writeInt32(writer, int32(len(value)))
-	for _, item := range value {
-		FfiConverterTypeVaultTxINSTANCE.Write(writer, item)
+	writeInt32(writer, int32(len(value)))
+	for _, item := range value {
+		FfiConverterTypeVaultTxINSTANCE.Write(writer, item)
+	}
+}
+
+type FfiDestroyerSequenceTypeVaultTx struct{}
+
+func (FfiDestroyerSequenceTypeVaultTx) Destroy(sequence []VaultTx) {
+	for _, value := range sequence {
+		FfiDestroyerTypeVaultTx{}.Destroy(value)
+	}
+}
+
+type FfiConverterSequenceTypeVaultTxDetail struct{}
+
+var FfiConverterSequenceTypeVaultTxDetailINSTANCE = FfiConverterSequenceTypeVaultTxDetail{}
+
+func (c FfiConverterSequenceTypeVaultTxDetail) Lift(rb RustBufferI) []VaultTxDetail {
+	return LiftFromRustBuffer[[]VaultTxDetail](c, rb)
+}
+
+func (c FfiConverterSequenceTypeVaultTxDetail) Read(reader io.Reader) []VaultTxDetail {
+	length := readInt32(reader)
+	if length == 0 {
+		return nil
+	}
+	result := make([]VaultTxDetail, 0, length)
+	for i := int32(0); i < length; i++ {
+		result = append(result, FfiConverterTypeVaultTxDetailINSTANCE.Read(reader))
+	}
+	return result
+}
+
+func (c FfiConverterSequenceTypeVaultTxDetail) Lower(value []VaultTxDetail) RustBuffer {
+	return LowerIntoRustBuffer[[]VaultTxDetail](c, value)
+}
+
+func (c FfiConverterSequenceTypeVaultTxDetail) Write(writer io.Writer, value []VaultTxDetail) {
+	if len(value) > math.MaxInt32 {
+		panic("[]VaultTxDetail is too large to fit into Int32")
+	}
+
+	writeInt32(writer, int32(len(value)))
+	for _, item := range value {
+		FfiConverterTypeVaultTxDetailINSTANCE.Write(writer, item)
+	}
+}
+
+type FfiDestroyerSequenceTypeVaultTxDetail struct{}
+
+func (FfiDestroyerSequenceTypeVaultTxDetail) Destroy(sequence []VaultTxDetail) {
+	for _, value := range sequence {
+		FfiDestroyerTypeVaultTxDetail{}.Destroy(value)
+	}
+}
+
+func AnalyzeVaultTx(network Network, pubkey []byte, rawTx []byte, prevouts []TxInput, knownVaults [][]byte, ufvk string) (*VaultTx, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_analyze_vault_tx(FfiConverterTypeNetworkINSTANCE.Lower(network), FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterBytesINSTANCE.Lower(rawTx), FfiConverterSequenceTypeTxInputINSTANCE.Lower(prevouts), FfiConverterSequenceBytesINSTANCE.Lower(knownVaults), FfiConverterStringINSTANCE.Lower(ufvk), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue *VaultTx
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterOptionalTypeVaultTxINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func ApplyDepositSignatures(sources []TransparentKey, ptx PartialTx, signatures [][]byte) ([]byte, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_apply_deposit_signatures(FfiConverterSequenceTypeTransparentKeyINSTANCE.Lower(sources), FfiConverterTypePartialTxINSTANCE.Lower(ptx), FfiConverterSequenceBytesINSTANCE.Lower(signatures), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue []byte
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterBytesINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func ApplySignatures(pubkey []byte, ptx PartialTx, signatures [][]byte) ([]byte, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_apply_signatures(FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterTypePartialTxINSTANCE.Lower(ptx), FfiConverterSequenceBytesINSTANCE.Lower(signatures), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue []byte
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterBytesINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func BackfillVault(pubkey []byte, fromHeight uint32, toHeight uint32, knownVaults [][]byte) ([]VaultTx, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_backfill_vault(FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterUint32INSTANCE.Lower(fromHeight), FfiConverterUint32INSTANCE.Lower(toHeight), FfiConverterSequenceBytesINSTANCE.Lower(knownVaults), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue []VaultTx
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterSequenceTypeVaultTxINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func BestRecipientOfUa(address string) (string, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_best_recipient_of_ua(FfiConverterStringINSTANCE.Lower(address), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue string
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterStringINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func BlockWalkerBalance(walker uint64, pubkey []byte) (uint64, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) C.uint64_t {
+		return C.uniffi_maya_zcash_fn_func_block_walker_balance(FfiConverterUint64INSTANCE.Lower(walker), FfiConverterBytesINSTANCE.Lower(pubkey), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue uint64
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterUint64INSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func BlockWalkerClose(walker uint64) {
+	rustCall(func(_uniffiStatus *C.RustCallStatus) bool {
+		C.uniffi_maya_zcash_fn_func_block_walker_close(FfiConverterUint64INSTANCE.Lower(walker), _uniffiStatus)
+		return false
+	})
+}
+
+func BlockWalkerNew(pubkeys [][]byte, fromHash string, knownVaults [][]byte) (uint64, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) C.uint64_t {
+		return C.uniffi_maya_zcash_fn_func_block_walker_new(FfiConverterSequenceBytesINSTANCE.Lower(pubkeys), FfiConverterStringINSTANCE.Lower(fromHash), FfiConverterSequenceBytesINSTANCE.Lower(knownVaults), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue uint64
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterUint64INSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func BlockWalkerRewind(walker uint64, height uint32) error {
+	_, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) bool {
+		C.uniffi_maya_zcash_fn_func_block_walker_rewind(FfiConverterUint64INSTANCE.Lower(walker), FfiConverterUint32INSTANCE.Lower(height), _uniffiStatus)
+		return false
+	})
+	return _uniffiErr
+}
+
+func BlockWalkerScan(walker uint64, maxBlocks uint32) (*BlockTxs, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_block_walker_scan(FfiConverterUint64INSTANCE.Lower(walker), FfiConverterUint32INSTANCE.Lower(maxBlocks), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue *BlockTxs
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterOptionalTypeBlockTxsINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func BlockWalkerUtxos(walker uint64, pubkey []byte) ([]Utxo, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_block_walker_utxos(FfiConverterUint64INSTANCE.Lower(walker), FfiConverterBytesINSTANCE.Lower(pubkey), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue []Utxo
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterSequenceTypeUTXOINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func BroadcastRawTx(tx []byte) (string, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_broadcast_raw_tx(FfiConverterBytesINSTANCE.Lower(tx), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue string
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterStringINSTANCE.Lift(_uniffiRV), _uniffiErr
 	}
 }
 
-type FfiDestroyerSequenceTypeVaultTx struct{}
-
-func (FfiDestroyerSequenceTypeVaultTx) Destroy(sequence []VaultTx) {
-	for _, value := range sequence {
-		FfiDestroyerTypeVaultTx{}.Destroy(value)
+func BuildDepositToVault(height uint32, fromAddress string, fromPubkey []byte, vault []byte, amount uint64, memo Memo) (PartialTx, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_build_deposit_to_vault(FfiConverterUint32INSTANCE.Lower(height), FfiConverterStringINSTANCE.Lower(fromAddress), FfiConverterBytesINSTANCE.Lower(fromPubkey), FfiConverterBytesINSTANCE.Lower(vault), FfiConverterUint64INSTANCE.Lower(amount), FfiConverterTypeMemoINSTANCE.Lower(memo), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue PartialTx
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterTypePartialTxINSTANCE.Lift(_uniffiRV), _uniffiErr
 	}
 }
 
-func ApplySignatures(vault []byte, ptx PartialTx, signatures [][]byte) ([]byte, error) {
+func BuildDepositToVaultFrom(height uint32, sources []TransparentKey, changeAddress string, vault []byte, amount uint64, memo Memo) (PartialTx, error) {
 	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
-		return C.uniffi_maya_zcash_fn_func_apply_signatures(FfiConverterBytesINSTANCE.Lower(vault), FfiConverterTypePartialTxINSTANCE.Lower(ptx), FfiConverterSequenceBytesINSTANCE.Lower(signatures), _uniffiStatus)
+		return C.uniffi_maya_zcash_fn_func_build_deposit_to_vault_from(FfiConverterUint32INSTANCE.Lower(height), FfiConverterSequenceTypeTransparentKeyINSTANCE.Lower(sources), FfiConverterStringINSTANCE.Lower(changeAddress), FfiConverterBytesINSTANCE.Lower(vault), FfiConverterUint64INSTANCE.Lower(amount), FfiConverterTypeMemoINSTANCE.Lower(memo), _uniffiStatus)
 	})
 	if _uniffiErr != nil {
-		var _uniffiDefaultValue []byte
+		var _uniffiDefaultValue PartialTx
 		return _uniffiDefaultValue, _uniffiErr
 	} else {
-		return FfiConverterBytesINSTANCE.Lift(_uniffiRV), _uniffiErr
+		return FfiConverterTypePartialTxINSTANCE.Lift(_uniffiRV), _uniffiErr
 	}
 }
 
-func BestRecipientOfUa(address string) (string, error) {
-	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
-		return C.uniffi_maya_zcash_fn_func_best_recipient_of_ua(FfiConverterStringINSTANCE.Lower(address), _uniffiStatus)
+func ChainTrackerClose(tracker uint64) {
+	rustCall(func(_uniffiStatus *C.RustCallStatus) bool {
+		C.uniffi_maya_zcash_fn_func_chain_tracker_close(FfiConverterUint64INSTANCE.Lower(tracker), _uniffiStatus)
+		return false
+	})
+}
+
+func ChainTrackerNew(pubkeys [][]byte, fromHash string, depth uint32, knownVaults [][]byte) (uint64, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) C.uint64_t {
+		return C.uniffi_maya_zcash_fn_func_chain_tracker_new(FfiConverterSequenceBytesINSTANCE.Lower(pubkeys), FfiConverterStringINSTANCE.Lower(fromHash), FfiConverterUint32INSTANCE.Lower(depth), FfiConverterSequenceBytesINSTANCE.Lower(knownVaults), _uniffiStatus)
 	})
 	if _uniffiErr != nil {
-		var _uniffiDefaultValue string
+		var _uniffiDefaultValue uint64
 		return _uniffiDefaultValue, _uniffiErr
 	} else {
-		return FfiConverterStringINSTANCE.Lift(_uniffiRV), _uniffiErr
+		return FfiConverterUint64INSTANCE.Lift(_uniffiRV), _uniffiErr
 	}
 }
 
-func BroadcastRawTx(tx []byte) (string, error) {
+func ChainTrackerPoll(tracker uint64) ([]ChainEvent, error) {
 	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
-		return C.uniffi_maya_zcash_fn_func_broadcast_raw_tx(FfiConverterBytesINSTANCE.Lower(tx), _uniffiStatus)
+		return C.uniffi_maya_zcash_fn_func_chain_tracker_poll(FfiConverterUint64INSTANCE.Lower(tracker), _uniffiStatus)
 	})
 	if _uniffiErr != nil {
-		var _uniffiDefaultValue string
+		var _uniffiDefaultValue []ChainEvent
 		return _uniffiDefaultValue, _uniffiErr
 	} else {
-		return FfiConverterStringINSTANCE.Lift(_uniffiRV), _uniffiErr
+		return FfiConverterSequenceTypeChainEventINSTANCE.Lift(_uniffiRV), _uniffiErr
 	}
 }
 
+func CloseStore() {
+	rustCall(func(_uniffiStatus *C.RustCallStatus) bool {
+		C.uniffi_maya_zcash_fn_func_close_store(_uniffiStatus)
+		return false
+	})
+}
+
 func CombineVault(height uint32, vault []byte) (PartialTx, error) {
 	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
 		return C.uniffi_maya_zcash_fn_func_combine_vault(FfiConverterUint32INSTANCE.Lower(height), FfiConverterBytesINSTANCE.Lower(vault), _uniffiStatus)
@@ -1766,6 +3832,61 @@ func CombineVaultUtxos(height uint32, vault []byte, destinationVaults []Output,
 	}
 }
 
+func ConfirmationTrackerClose(tracker uint64) {
+	rustCall(func(_uniffiStatus *C.RustCallStatus) bool {
+		C.uniffi_maya_zcash_fn_func_confirmation_tracker_close(FfiConverterUint64INSTANCE.Lower(tracker), _uniffiStatus)
+		return false
+	})
+}
+
+func ConfirmationTrackerNew(tiers []ConfirmationTier) (uint64, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) C.uint64_t {
+		return C.uniffi_maya_zcash_fn_func_confirmation_tracker_new(FfiConverterSequenceTypeConfirmationTierINSTANCE.Lower(tiers), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue uint64
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterUint64INSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func ConfirmationTrackerPoll(tracker uint64) ([]TxConfirmation, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_confirmation_tracker_poll(FfiConverterUint64INSTANCE.Lower(tracker), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue []TxConfirmation
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterSequenceTypeTxConfirmationINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func ConfirmationTrackerUnwatch(tracker uint64, txid string) error {
+	_, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) bool {
+		C.uniffi_maya_zcash_fn_func_confirmation_tracker_unwatch(FfiConverterUint64INSTANCE.Lower(tracker), FfiConverterStringINSTANCE.Lower(txid), _uniffiStatus)
+		return false
+	})
+	return _uniffiErr
+}
+
+func ConfirmationTrackerWatch(tracker uint64, txid string, amount uint64) error {
+	_, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) bool {
+		C.uniffi_maya_zcash_fn_func_confirmation_tracker_watch(FfiConverterUint64INSTANCE.Lower(tracker), FfiConverterStringINSTANCE.Lower(txid), FfiConverterUint64INSTANCE.Lower(amount), _uniffiStatus)
+		return false
+	})
+	return _uniffiErr
+}
+
+func ConfirmationTrackerWatchVaultTxs(tracker uint64, txs []VaultTx) error {
+	_, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) bool {
+		C.uniffi_maya_zcash_fn_func_confirmation_tracker_watch_vault_txs(FfiConverterUint64INSTANCE.Lower(tracker), FfiConverterSequenceTypeVaultTxINSTANCE.Lower(txs), _uniffiStatus)
+		return false
+	})
+	return _uniffiErr
+}
+
 func GetBalance(address string) (uint64, error) {
 	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) C.uint64_t {
 		return C.uniffi_maya_zcash_fn_func_get_balance(FfiConverterStringINSTANCE.Lower(address), _uniffiStatus)
@@ -1802,6 +3923,12 @@ func GetOvk(pubkey []byte) ([]byte, error) {
 	}
 }
 
+func GetTxCacheStats() TxCacheStats {
+	return FfiConverterTypeTxCacheStatsINSTANCE.Lift(rustCall(func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_get_tx_cache_stats(_uniffiStatus)
+	}))
+}
+
 func GetVaultAddress(pubkey []byte) (string, error) {
 	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
 		return C.uniffi_maya_zcash_fn_func_get_vault_address(FfiConverterBytesINSTANCE.Lower(pubkey), _uniffiStatus)
@@ -1814,6 +3941,30 @@ func GetVaultAddress(pubkey []byte) (string, error) {
 	}
 }
 
+func GetVaultDepositAddress(pubkey []byte) (string, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_get_vault_deposit_address(FfiConverterBytesINSTANCE.Lower(pubkey), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue string
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterStringINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func GetVaultTxDetail(pubkey []byte, txid string, knownVaults [][]byte) (VaultTxDetail, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_get_vault_tx_detail(FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterStringINSTANCE.Lower(txid), FfiConverterSequenceBytesINSTANCE.Lower(knownVaults), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue VaultTxDetail
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterTypeVaultTxDetailINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
 func InitLogger() {
 	rustCall(func(_uniffiStatus *C.RustCallStatus) bool {
 		C.uniffi_maya_zcash_fn_func_init_logger(_uniffiStatus)
@@ -1857,9 +4008,23 @@ func MatchWithBlockchainReceiver(address string, receiver string) (bool, error)
 	}
 }
 
-func PayFromVault(height uint32, vault []byte, to string, amount uint64, memo string) (PartialTx, error) {
+func OpenStore(path string) error {
+	_, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) bool {
+		C.uniffi_maya_zcash_fn_func_open_store(FfiConverterStringINSTANCE.Lower(path), _uniffiStatus)
+		return false
+	})
+	return _uniffiErr
+}
+
+func ParseScriptPubkey(script []byte) ScriptPubKey {
+	return FfiConverterTypeScriptPubKeyINSTANCE.Lift(rustCall(func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_parse_script_pubkey(FfiConverterBytesINSTANCE.Lower(script), _uniffiStatus)
+	}))
+}
+
+func PayFromVault(height uint32, vault []byte, to string, amount uint64, memo Memo) (PartialTx, error) {
 	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
-		return C.uniffi_maya_zcash_fn_func_pay_from_vault(FfiConverterUint32INSTANCE.Lower(height), FfiConverterBytesINSTANCE.Lower(vault), FfiConverterStringINSTANCE.Lower(to), FfiConverterUint64INSTANCE.Lower(amount), FfiConverterStringINSTANCE.Lower(memo), _uniffiStatus)
+		return C.uniffi_maya_zcash_fn_func_pay_from_vault(FfiConverterUint32INSTANCE.Lower(height), FfiConverterBytesINSTANCE.Lower(vault), FfiConverterStringINSTANCE.Lower(to), FfiConverterUint64INSTANCE.Lower(amount), FfiConverterTypeMemoINSTANCE.Lower(memo), _uniffiStatus)
 	})
 	if _uniffiErr != nil {
 		var _uniffiDefaultValue PartialTx
@@ -1869,9 +4034,45 @@ func PayFromVault(height uint32, vault []byte, to string, amount uint64, memo st
 	}
 }
 
-func ScanBlocks(pubkey []byte, prevHashes []string) (*BlockTxs, error) {
+func ScanBlockRange(pubkey []byte, fromHash string, maxBlocks uint32, knownVaults [][]byte) (*BlockTxs, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_scan_block_range(FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterStringINSTANCE.Lower(fromHash), FfiConverterUint32INSTANCE.Lower(maxBlocks), FfiConverterSequenceBytesINSTANCE.Lower(knownVaults), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue *BlockTxs
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterOptionalTypeBlockTxsINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func ScanBlockRangeDetail(pubkey []byte, fromHash string, maxBlocks uint32, knownVaults [][]byte) (*BlockTxDetails, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_scan_block_range_detail(FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterStringINSTANCE.Lower(fromHash), FfiConverterUint32INSTANCE.Lower(maxBlocks), FfiConverterSequenceBytesINSTANCE.Lower(knownVaults), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue *BlockTxDetails
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterOptionalTypeBlockTxDetailsINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func ScanBlocks(pubkey []byte, prevHashes []string, knownVaults [][]byte) (*BlockTxs, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_scan_blocks(FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterSequenceStringINSTANCE.Lower(prevHashes), FfiConverterSequenceBytesINSTANCE.Lower(knownVaults), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue *BlockTxs
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterOptionalTypeBlockTxsINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func ScanBlocksMulti(pubkeys [][]byte, prevHashes []string, knownVaults [][]byte) (*BlockTxs, error) {
 	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
-		return C.uniffi_maya_zcash_fn_func_scan_blocks(FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterSequenceStringINSTANCE.Lower(prevHashes), _uniffiStatus)
+		return C.uniffi_maya_zcash_fn_func_scan_blocks_multi(FfiConverterSequenceBytesINSTANCE.Lower(pubkeys), FfiConverterSequenceStringINSTANCE.Lower(prevHashes), FfiConverterSequenceBytesINSTANCE.Lower(knownVaults), _uniffiStatus)
 	})
 	if _uniffiErr != nil {
 		var _uniffiDefaultValue *BlockTxs
@@ -1881,9 +4082,9 @@ func ScanBlocks(pubkey []byte, prevHashes []string) (*BlockTxs, error) {
 	}
 }
 
-func ScanMempool(pubkey []byte) ([]VaultTx, error) {
+func ScanMempool(pubkey []byte, knownVaults [][]byte) ([]VaultTx, error) {
 	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
-		return C.uniffi_maya_zcash_fn_func_scan_mempool(FfiConverterBytesINSTANCE.Lower(pubkey), _uniffiStatus)
+		return C.uniffi_maya_zcash_fn_func_scan_mempool(FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterSequenceBytesINSTANCE.Lower(knownVaults), _uniffiStatus)
 	})
 	if _uniffiErr != nil {
 		var _uniffiDefaultValue []VaultTx
@@ -1893,9 +4094,45 @@ func ScanMempool(pubkey []byte) ([]VaultTx, error) {
 	}
 }
 
-func SendToVault(expiryHeight uint32, sk []byte, from string, vault []byte, amount uint64, memo string) (TxBytes, error) {
+func ScanMempoolMulti(pubkeys [][]byte, knownVaults [][]byte) ([]VaultTx, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_scan_mempool_multi(FfiConverterSequenceBytesINSTANCE.Lower(pubkeys), FfiConverterSequenceBytesINSTANCE.Lower(knownVaults), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue []VaultTx
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterSequenceTypeVaultTxINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func SendShieldedToVault(expiryHeight uint32, saplingSk *[]byte, saplingNotes []ShieldedNote, orchardSk *[]byte, orchardNotes []ShieldedNote, vault []byte, amount uint64, memo Memo) (TxBytes, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_send_shielded_to_vault(FfiConverterUint32INSTANCE.Lower(expiryHeight), FfiConverterOptionalBytesINSTANCE.Lower(saplingSk), FfiConverterSequenceTypeShieldedNoteINSTANCE.Lower(saplingNotes), FfiConverterOptionalBytesINSTANCE.Lower(orchardSk), FfiConverterSequenceTypeShieldedNoteINSTANCE.Lower(orchardNotes), FfiConverterBytesINSTANCE.Lower(vault), FfiConverterUint64INSTANCE.Lower(amount), FfiConverterTypeMemoINSTANCE.Lower(memo), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue TxBytes
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterTypeTxBytesINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func SendToVault(expiryHeight uint32, sk []byte, from string, vault []byte, amount uint64, memo Memo) (TxBytes, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_send_to_vault(FfiConverterUint32INSTANCE.Lower(expiryHeight), FfiConverterBytesINSTANCE.Lower(sk), FfiConverterStringINSTANCE.Lower(from), FfiConverterBytesINSTANCE.Lower(vault), FfiConverterUint64INSTANCE.Lower(amount), FfiConverterTypeMemoINSTANCE.Lower(memo), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue TxBytes
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterTypeTxBytesINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func SendToVaultFrom(expiryHeight uint32, sources []TransparentKey, changeAddress string, vault []byte, amount uint64, memo Memo) (TxBytes, error) {
 	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
-		return C.uniffi_maya_zcash_fn_func_send_to_vault(FfiConverterUint32INSTANCE.Lower(expiryHeight), FfiConverterBytesINSTANCE.Lower(sk), FfiConverterStringINSTANCE.Lower(from), FfiConverterBytesINSTANCE.Lower(vault), FfiConverterUint64INSTANCE.Lower(amount), FfiConverterStringINSTANCE.Lower(memo), _uniffiStatus)
+		return C.uniffi_maya_zcash_fn_func_send_to_vault_from(FfiConverterUint32INSTANCE.Lower(expiryHeight), FfiConverterSequenceTypeTransparentKeyINSTANCE.Lower(sources), FfiConverterStringINSTANCE.Lower(changeAddress), FfiConverterBytesINSTANCE.Lower(vault), FfiConverterUint64INSTANCE.Lower(amount), FfiConverterTypeMemoINSTANCE.Lower(memo), _uniffiStatus)
 	})
 	if _uniffiErr != nil {
 		var _uniffiDefaultValue TxBytes
@@ -1905,6 +4142,21 @@ func SendToVault(expiryHeight uint32, sk []byte, from string, vault []byte, amou
 	}
 }
 
+func SetTxCacheSize(size uint32) {
+	rustCall(func(_uniffiStatus *C.RustCallStatus) bool {
+		C.uniffi_maya_zcash_fn_func_set_tx_cache_size(FfiConverterUint32INSTANCE.Lower(size), _uniffiStatus)
+		return false
+	})
+}
+
+func SetVaultViewingKey(pubkey []byte, ufvk string) error {
+	_, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) bool {
+		C.uniffi_maya_zcash_fn_func_set_vault_viewing_key(FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterStringINSTANCE.Lower(ufvk), _uniffiStatus)
+		return false
+	})
+	return _uniffiErr
+}
+
 func SignSighash(sk []byte, sighash []byte) ([]byte, error) {
 	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
 		return C.uniffi_maya_zcash_fn_func_sign_sighash(FfiConverterBytesINSTANCE.Lower(sk), FfiConverterBytesINSTANCE.Lower(sighash), _uniffiStatus)
@@ -1929,6 +4181,105 @@ func SkToPub(wif string) (TransparentKey, error) {
 	}
 }
 
+func StoreGetBalance(pubkey []byte) (uint64, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) C.uint64_t {
+		return C.uniffi_maya_zcash_fn_func_store_get_balance(FfiConverterBytesINSTANCE.Lower(pubkey), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue uint64
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterUint64INSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func StoreGetCursor(pubkey []byte) (*Height, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_store_get_cursor(FfiConverterBytesINSTANCE.Lower(pubkey), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue *Height
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterOptionalTypeHeightINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func StoreListTxs(pubkey []byte, fromHeight uint32) ([]VaultTx, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_store_list_txs(FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterUint32INSTANCE.Lower(fromHeight), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue []VaultTx
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterSequenceTypeVaultTxINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func StoreListUtxos(pubkey []byte, includeSpent bool) ([]StoredUtxo, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_store_list_utxos(FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterBoolINSTANCE.Lower(includeSpent), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue []StoredUtxo
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterSequenceTypeStoredUTXOINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func StoreRewind(pubkey []byte, height uint32) error {
+	_, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) bool {
+		C.uniffi_maya_zcash_fn_func_store_rewind(FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterUint32INSTANCE.Lower(height), _uniffiStatus)
+		return false
+	})
+	return _uniffiErr
+}
+
+func StoreScan(pubkey []byte, maxBlocks uint32, knownVaults [][]byte) (*BlockTxs, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_store_scan(FfiConverterBytesINSTANCE.Lower(pubkey), FfiConverterUint32INSTANCE.Lower(maxBlocks), FfiConverterSequenceBytesINSTANCE.Lower(knownVaults), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue *BlockTxs
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterOptionalTypeBlockTxsINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func SubscriptionClose(subscription uint64) {
+	rustCall(func(_uniffiStatus *C.RustCallStatus) bool {
+		C.uniffi_maya_zcash_fn_func_subscription_close(FfiConverterUint64INSTANCE.Lower(subscription), _uniffiStatus)
+		return false
+	})
+}
+
+func SubscriptionNew(pubkeys [][]byte, knownVaults [][]byte) (uint64, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) C.uint64_t {
+		return C.uniffi_maya_zcash_fn_func_subscription_new(FfiConverterSequenceBytesINSTANCE.Lower(pubkeys), FfiConverterSequenceBytesINSTANCE.Lower(knownVaults), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue uint64
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterUint64INSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
+func SubscriptionNext(subscription uint64, timeoutMs uint32) ([]VaultEvent, error) {
+	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) RustBufferI {
+		return C.uniffi_maya_zcash_fn_func_subscription_next(FfiConverterUint64INSTANCE.Lower(subscription), FfiConverterUint32INSTANCE.Lower(timeoutMs), _uniffiStatus)
+	})
+	if _uniffiErr != nil {
+		var _uniffiDefaultValue []VaultEvent
+		return _uniffiDefaultValue, _uniffiErr
+	} else {
+		return FfiConverterSequenceTypeVaultEventINSTANCE.Lift(_uniffiRV), _uniffiErr
+	}
+}
+
 func ValidateAddress(address string) (bool, error) {
 	_uniffiRV, _uniffiErr := rustCallWithError(FfiConverterTypeZcashError{}, func(_uniffiStatus *C.RustCallStatus) C.int8_t {
 		return C.uniffi_maya_zcash_fn_func_validate_address(FfiConverterStringINSTANCE.Lower(address), _uniffiStatus)
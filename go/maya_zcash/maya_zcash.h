@@ -68,23 +68,126 @@ int8_t uniffiForeignExecutorCallbackmaya_zcash(uint64_t, uint32_t, RustTaskCallb
 
 void uniffiFutureContinuationCallbackmaya_zcash(void*, int8_t);
 
+RustBuffer uniffi_maya_zcash_fn_func_analyze_vault_tx(
+	RustBuffer network,
+	RustBuffer pubkey,
+	RustBuffer raw_tx,
+	RustBuffer prevouts,
+	RustBuffer known_vaults,
+	RustBuffer ufvk,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_apply_deposit_signatures(
+	RustBuffer sources,
+	RustBuffer ptx,
+	RustBuffer signatures,
+	RustCallStatus* out_status
+);
+
 RustBuffer uniffi_maya_zcash_fn_func_apply_signatures(
-	RustBuffer vault,
+	RustBuffer pubkey,
 	RustBuffer ptx,
 	RustBuffer signatures,
 	RustCallStatus* out_status
 );
 
+RustBuffer uniffi_maya_zcash_fn_func_backfill_vault(
+	RustBuffer pubkey,
+	uint32_t from_height,
+	uint32_t to_height,
+	RustBuffer known_vaults,
+	RustCallStatus* out_status
+);
+
 RustBuffer uniffi_maya_zcash_fn_func_best_recipient_of_ua(
 	RustBuffer address,
 	RustCallStatus* out_status
 );
 
+uint64_t uniffi_maya_zcash_fn_func_block_walker_balance(
+	uint64_t walker,
+	RustBuffer pubkey,
+	RustCallStatus* out_status
+);
+
+void uniffi_maya_zcash_fn_func_block_walker_close(
+	uint64_t walker,
+	RustCallStatus* out_status
+);
+
+uint64_t uniffi_maya_zcash_fn_func_block_walker_new(
+	RustBuffer pubkeys,
+	RustBuffer from_hash,
+	RustBuffer known_vaults,
+	RustCallStatus* out_status
+);
+
+void uniffi_maya_zcash_fn_func_block_walker_rewind(
+	uint64_t walker,
+	uint32_t height,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_block_walker_scan(
+	uint64_t walker,
+	uint32_t max_blocks,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_block_walker_utxos(
+	uint64_t walker,
+	RustBuffer pubkey,
+	RustCallStatus* out_status
+);
+
 RustBuffer uniffi_maya_zcash_fn_func_broadcast_raw_tx(
 	RustBuffer tx,
 	RustCallStatus* out_status
 );
 
+RustBuffer uniffi_maya_zcash_fn_func_build_deposit_to_vault(
+	uint32_t height,
+	RustBuffer from_address,
+	RustBuffer from_pubkey,
+	RustBuffer vault,
+	uint64_t amount,
+	RustBuffer memo,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_build_deposit_to_vault_from(
+	uint32_t height,
+	RustBuffer sources,
+	RustBuffer change_address,
+	RustBuffer vault,
+	uint64_t amount,
+	RustBuffer memo,
+	RustCallStatus* out_status
+);
+
+void uniffi_maya_zcash_fn_func_chain_tracker_close(
+	uint64_t tracker,
+	RustCallStatus* out_status
+);
+
+uint64_t uniffi_maya_zcash_fn_func_chain_tracker_new(
+	RustBuffer pubkeys,
+	RustBuffer from_hash,
+	uint32_t depth,
+	RustBuffer known_vaults,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_chain_tracker_poll(
+	uint64_t tracker,
+	RustCallStatus* out_status
+);
+
+void uniffi_maya_zcash_fn_func_close_store(
+	RustCallStatus* out_status
+);
+
 RustBuffer uniffi_maya_zcash_fn_func_combine_vault(
 	uint32_t height,
 	RustBuffer vault,
@@ -99,6 +202,40 @@ RustBuffer uniffi_maya_zcash_fn_func_combine_vault_utxos(
 	RustCallStatus* out_status
 );
 
+void uniffi_maya_zcash_fn_func_confirmation_tracker_close(
+	uint64_t tracker,
+	RustCallStatus* out_status
+);
+
+uint64_t uniffi_maya_zcash_fn_func_confirmation_tracker_new(
+	RustBuffer tiers,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_confirmation_tracker_poll(
+	uint64_t tracker,
+	RustCallStatus* out_status
+);
+
+void uniffi_maya_zcash_fn_func_confirmation_tracker_unwatch(
+	uint64_t tracker,
+	RustBuffer txid,
+	RustCallStatus* out_status
+);
+
+void uniffi_maya_zcash_fn_func_confirmation_tracker_watch(
+	uint64_t tracker,
+	RustBuffer txid,
+	uint64_t amount,
+	RustCallStatus* out_status
+);
+
+void uniffi_maya_zcash_fn_func_confirmation_tracker_watch_vault_txs(
+	uint64_t tracker,
+	RustBuffer txs,
+	RustCallStatus* out_status
+);
+
 uint64_t uniffi_maya_zcash_fn_func_get_balance(
 	RustBuffer address,
 	RustCallStatus* out_status
@@ -113,11 +250,27 @@ RustBuffer uniffi_maya_zcash_fn_func_get_ovk(
 	RustCallStatus* out_status
 );
 
+RustBuffer uniffi_maya_zcash_fn_func_get_tx_cache_stats(
+	RustCallStatus* out_status
+);
+
 RustBuffer uniffi_maya_zcash_fn_func_get_vault_address(
 	RustBuffer pubkey,
 	RustCallStatus* out_status
 );
 
+RustBuffer uniffi_maya_zcash_fn_func_get_vault_deposit_address(
+	RustBuffer pubkey,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_get_vault_tx_detail(
+	RustBuffer pubkey,
+	RustBuffer txid,
+	RustBuffer known_vaults,
+	RustCallStatus* out_status
+);
+
 void uniffi_maya_zcash_fn_func_init_logger(
 	RustCallStatus* out_status
 );
@@ -140,6 +293,16 @@ int8_t uniffi_maya_zcash_fn_func_match_with_blockchain_receiver(
 	RustCallStatus* out_status
 );
 
+void uniffi_maya_zcash_fn_func_open_store(
+	RustBuffer path,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_parse_script_pubkey(
+	RustBuffer script,
+	RustCallStatus* out_status
+);
+
 RustBuffer uniffi_maya_zcash_fn_func_pay_from_vault(
 	uint32_t height,
 	RustBuffer vault,
@@ -149,14 +312,57 @@ RustBuffer uniffi_maya_zcash_fn_func_pay_from_vault(
 	RustCallStatus* out_status
 );
 
+RustBuffer uniffi_maya_zcash_fn_func_scan_block_range(
+	RustBuffer pubkey,
+	RustBuffer from_hash,
+	uint32_t max_blocks,
+	RustBuffer known_vaults,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_scan_block_range_detail(
+	RustBuffer pubkey,
+	RustBuffer from_hash,
+	uint32_t max_blocks,
+	RustBuffer known_vaults,
+	RustCallStatus* out_status
+);
+
 RustBuffer uniffi_maya_zcash_fn_func_scan_blocks(
 	RustBuffer pubkey,
 	RustBuffer prev_hashes,
+	RustBuffer known_vaults,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_scan_blocks_multi(
+	RustBuffer pubkeys,
+	RustBuffer prev_hashes,
+	RustBuffer known_vaults,
 	RustCallStatus* out_status
 );
 
 RustBuffer uniffi_maya_zcash_fn_func_scan_mempool(
 	RustBuffer pubkey,
+	RustBuffer known_vaults,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_scan_mempool_multi(
+	RustBuffer pubkeys,
+	RustBuffer known_vaults,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_send_shielded_to_vault(
+	uint32_t expiry_height,
+	RustBuffer sapling_sk,
+	RustBuffer sapling_notes,
+	RustBuffer orchard_sk,
+	RustBuffer orchard_notes,
+	RustBuffer vault,
+	uint64_t amount,
+	RustBuffer memo,
 	RustCallStatus* out_status
 );
 
@@ -170,6 +376,27 @@ RustBuffer uniffi_maya_zcash_fn_func_send_to_vault(
 	RustCallStatus* out_status
 );
 
+RustBuffer uniffi_maya_zcash_fn_func_send_to_vault_from(
+	uint32_t expiry_height,
+	RustBuffer sources,
+	RustBuffer change_address,
+	RustBuffer vault,
+	uint64_t amount,
+	RustBuffer memo,
+	RustCallStatus* out_status
+);
+
+void uniffi_maya_zcash_fn_func_set_tx_cache_size(
+	uint32_t size,
+	RustCallStatus* out_status
+);
+
+void uniffi_maya_zcash_fn_func_set_vault_viewing_key(
+	RustBuffer pubkey,
+	RustBuffer ufvk,
+	RustCallStatus* out_status
+);
+
 RustBuffer uniffi_maya_zcash_fn_func_sign_sighash(
 	RustBuffer sk,
 	RustBuffer sighash,
@@ -181,6 +408,58 @@ RustBuffer uniffi_maya_zcash_fn_func_sk_to_pub(
 	RustCallStatus* out_status
 );
 
+uint64_t uniffi_maya_zcash_fn_func_store_get_balance(
+	RustBuffer pubkey,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_store_get_cursor(
+	RustBuffer pubkey,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_store_list_txs(
+	RustBuffer pubkey,
+	uint32_t from_height,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_store_list_utxos(
+	RustBuffer pubkey,
+	int8_t include_spent,
+	RustCallStatus* out_status
+);
+
+void uniffi_maya_zcash_fn_func_store_rewind(
+	RustBuffer pubkey,
+	uint32_t height,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_store_scan(
+	RustBuffer pubkey,
+	uint32_t max_blocks,
+	RustBuffer known_vaults,
+	RustCallStatus* out_status
+);
+
+void uniffi_maya_zcash_fn_func_subscription_close(
+	uint64_t subscription,
+	RustCallStatus* out_status
+);
+
+uint64_t uniffi_maya_zcash_fn_func_subscription_new(
+	RustBuffer pubkeys,
+	RustBuffer known_vaults,
+	RustCallStatus* out_status
+);
+
+RustBuffer uniffi_maya_zcash_fn_func_subscription_next(
+	uint64_t subscription,
+	uint32_t timeout_ms,
+	RustCallStatus* out_status
+);
+
 int8_t uniffi_maya_zcash_fn_func_validate_address(
 	RustBuffer address,
 	RustCallStatus* out_status
@@ -485,18 +764,78 @@ void ffi_maya_zcash_rust_future_complete_void(
 	RustCallStatus* out_status
 );
 
+uint16_t uniffi_maya_zcash_checksum_func_analyze_vault_tx(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_apply_deposit_signatures(
+	RustCallStatus* out_status
+);
+
 uint16_t uniffi_maya_zcash_checksum_func_apply_signatures(
 	RustCallStatus* out_status
 );
 
+uint16_t uniffi_maya_zcash_checksum_func_backfill_vault(
+	RustCallStatus* out_status
+);
+
 uint16_t uniffi_maya_zcash_checksum_func_best_recipient_of_ua(
 	RustCallStatus* out_status
 );
 
+uint16_t uniffi_maya_zcash_checksum_func_block_walker_balance(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_block_walker_close(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_block_walker_new(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_block_walker_rewind(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_block_walker_scan(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_block_walker_utxos(
+	RustCallStatus* out_status
+);
+
 uint16_t uniffi_maya_zcash_checksum_func_broadcast_raw_tx(
 	RustCallStatus* out_status
 );
 
+uint16_t uniffi_maya_zcash_checksum_func_build_deposit_to_vault(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_build_deposit_to_vault_from(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_chain_tracker_close(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_chain_tracker_new(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_chain_tracker_poll(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_close_store(
+	RustCallStatus* out_status
+);
+
 uint16_t uniffi_maya_zcash_checksum_func_combine_vault(
 	RustCallStatus* out_status
 );
@@ -505,6 +844,30 @@ uint16_t uniffi_maya_zcash_checksum_func_combine_vault_utxos(
 	RustCallStatus* out_status
 );
 
+uint16_t uniffi_maya_zcash_checksum_func_confirmation_tracker_close(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_confirmation_tracker_new(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_confirmation_tracker_poll(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_confirmation_tracker_unwatch(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_confirmation_tracker_watch(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_confirmation_tracker_watch_vault_txs(
+	RustCallStatus* out_status
+);
+
 uint16_t uniffi_maya_zcash_checksum_func_get_balance(
 	RustCallStatus* out_status
 );
@@ -517,10 +880,22 @@ uint16_t uniffi_maya_zcash_checksum_func_get_ovk(
 	RustCallStatus* out_status
 );
 
+uint16_t uniffi_maya_zcash_checksum_func_get_tx_cache_stats(
+	RustCallStatus* out_status
+);
+
 uint16_t uniffi_maya_zcash_checksum_func_get_vault_address(
 	RustCallStatus* out_status
 );
 
+uint16_t uniffi_maya_zcash_checksum_func_get_vault_deposit_address(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_get_vault_tx_detail(
+	RustCallStatus* out_status
+);
+
 uint16_t uniffi_maya_zcash_checksum_func_init_logger(
 	RustCallStatus* out_status
 );
@@ -537,22 +912,62 @@ uint16_t uniffi_maya_zcash_checksum_func_match_with_blockchain_receiver(
 	RustCallStatus* out_status
 );
 
+uint16_t uniffi_maya_zcash_checksum_func_open_store(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_parse_script_pubkey(
+	RustCallStatus* out_status
+);
+
 uint16_t uniffi_maya_zcash_checksum_func_pay_from_vault(
 	RustCallStatus* out_status
 );
 
+uint16_t uniffi_maya_zcash_checksum_func_scan_block_range(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_scan_block_range_detail(
+	RustCallStatus* out_status
+);
+
 uint16_t uniffi_maya_zcash_checksum_func_scan_blocks(
 	RustCallStatus* out_status
 );
 
+uint16_t uniffi_maya_zcash_checksum_func_scan_blocks_multi(
+	RustCallStatus* out_status
+);
+
 uint16_t uniffi_maya_zcash_checksum_func_scan_mempool(
 	RustCallStatus* out_status
 );
 
+uint16_t uniffi_maya_zcash_checksum_func_scan_mempool_multi(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_send_shielded_to_vault(
+	RustCallStatus* out_status
+);
+
 uint16_t uniffi_maya_zcash_checksum_func_send_to_vault(
 	RustCallStatus* out_status
 );
 
+uint16_t uniffi_maya_zcash_checksum_func_send_to_vault_from(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_set_tx_cache_size(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_set_vault_viewing_key(
+	RustCallStatus* out_status
+);
+
 uint16_t uniffi_maya_zcash_checksum_func_sign_sighash(
 	RustCallStatus* out_status
 );
@@ -561,6 +976,42 @@ uint16_t uniffi_maya_zcash_checksum_func_sk_to_pub(
 	RustCallStatus* out_status
 );
 
+uint16_t uniffi_maya_zcash_checksum_func_store_get_balance(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_store_get_cursor(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_store_list_txs(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_store_list_utxos(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_store_rewind(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_store_scan(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_subscription_close(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_subscription_new(
+	RustCallStatus* out_status
+);
+
+uint16_t uniffi_maya_zcash_checksum_func_subscription_next(
+	RustCallStatus* out_status
+);
+
 uint16_t uniffi_maya_zcash_checksum_func_validate_address(
 	RustCallStatus* out_status
 );
@@ -0,0 +1,79 @@
+package mayamemo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Asset is a MAYAChain asset: CHAIN.SYMBOL for layer 1 assets
+// and CHAIN/SYMBOL for synths. Tokens have their contract
+// after the ticker, e.g. ETH.USDT-0XDAC17F958D2EE523A2206206994597C13D831EC7
+type Asset struct {
+	Chain  string
+	Symbol string
+	Synth  bool
+}
+
+// shortcodes are the one letter abbreviations of the
+// most used assets
+var shortcodes = map[string]Asset{
+	"b": {Chain: "BTC", Symbol: "BTC"},
+	"c": {Chain: "MAYA", Symbol: "CACAO"},
+	"d": {Chain: "DASH", Symbol: "DASH"},
+	"e": {Chain: "ETH", Symbol: "ETH"},
+	"r": {Chain: "THOR", Symbol: "RUNE"},
+	"z": {Chain: "ZEC", Symbol: "ZEC"},
+}
+
+// ParseAsset reads an asset in full or shortcode notation
+func ParseAsset(s string) (Asset, error) {
+	if a, ok := shortcodes[s]; ok {
+		return a, nil
+	}
+	s = strings.ToUpper(s)
+	synth := false
+	i := strings.IndexByte(s, '.')
+	if i < 0 {
+		i = strings.IndexByte(s, '/')
+		synth = true
+	}
+	if i <= 0 || i == len(s)-1 {
+		return Asset{}, fmt.Errorf("%w: %q", ErrInvalidAsset, s)
+	}
+	a := Asset{Chain: s[:i], Symbol: s[i+1:], Synth: synth}
+	if strings.ContainsAny(a.Symbol, "./") {
+		return Asset{}, fmt.Errorf("%w: %q", ErrInvalidAsset, s)
+	}
+	return a, nil
+}
+
+// Ticker is the symbol without the token contract
+func (a Asset) Ticker() string {
+	ticker, _, _ := strings.Cut(a.Symbol, "-")
+	return ticker
+}
+
+// IsEmpty is true for the zero Asset, used for optional assets
+func (a Asset) IsEmpty() bool {
+	return a.Chain == "" && a.Symbol == ""
+}
+
+func (a Asset) String() string {
+	if a.IsEmpty() {
+		return ""
+	}
+	if a.Synth {
+		return a.Chain + "/" + a.Symbol
+	}
+	return a.Chain + "." + a.Symbol
+}
+
+// Short is the shortcode of the asset if it has one
+func (a Asset) Short() string {
+	for code, asset := range shortcodes {
+		if asset == a {
+			return code
+		}
+	}
+	return a.String()
+}
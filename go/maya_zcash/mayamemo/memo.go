@@ -0,0 +1,386 @@
+// Package mayamemo parses and builds the MAYAChain memos
+// of the vault transactions, such as
+// SWAP:ETH.ETH:0x9d2a2ea4ff4d2d8d7fc7bd2b8f1dab8e8b4a3f4c:1e8/1/0:mayac:15
+package mayamemo
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxTransparentLen is the size limit of the OP_RETURN memo
+// of a transparent transaction
+const MaxTransparentLen = 80
+
+var (
+	ErrUnknownType    = errors.New("unknown memo type")
+	ErrInvalidMemo    = errors.New("invalid memo")
+	ErrInvalidAsset   = errors.New("invalid asset")
+	ErrInvalidAddress = errors.New("invalid address")
+	ErrTooLong        = errors.New("memo too long")
+)
+
+type Type int
+
+const (
+	TypeSwap Type = iota + 1
+	TypeAddLiquidity
+	TypeWithdrawLiquidity
+	TypeDonate
+	TypeOutbound
+	TypeRefund
+	TypeMigrate
+	TypeRagnarok
+	TypeConsolidate
+)
+
+// keywords has the full form of each type first
+// and its shortest abbreviation last
+var keywords = map[Type][]string{
+	TypeSwap:              {"SWAP", "S", "="},
+	TypeAddLiquidity:      {"ADD", "A", "+"},
+	TypeWithdrawLiquidity: {"WITHDRAW", "WD", "-"},
+	TypeDonate:            {"DONATE", "D"},
+	TypeOutbound:          {"OUT"},
+	TypeRefund:            {"REFUND"},
+	TypeMigrate:           {"MIGRATE"},
+	TypeRagnarok:          {"RAGNAROK"},
+	TypeConsolidate:       {"CONSOLIDATE"},
+}
+
+func (t Type) String() string {
+	if k, ok := keywords[t]; ok {
+		return k[0]
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// Memo is one of SwapMemo, AddLiquidityMemo, WithdrawLiquidityMemo,
+// DonateMemo, OutboundMemo, RefundMemo, MigrateMemo, RagnarokMemo
+// and ConsolidateMemo
+type Memo interface {
+	Type() Type
+	// the parts after the keyword, with the shorter
+	// notations if short is set
+	fields(short bool) []string
+}
+
+// SwapMemo swaps the inbound funds to Asset and sends them to
+// Address. Limit is the minimum output, Interval and Quantity
+// are the streaming swap parameters, 0 if not set.
+// AffiliateFee is in basis points
+type SwapMemo struct {
+	Asset        Asset
+	Address      string
+	Limit        uint64
+	Interval     uint64
+	Quantity     uint64
+	Affiliate    string
+	AffiliateFee uint64
+}
+
+// AddLiquidityMemo adds the inbound funds to Pool. PairedAddress
+// is the MAYAChain address of the other side, if any
+type AddLiquidityMemo struct {
+	Pool          Asset
+	PairedAddress string
+	Affiliate     string
+	AffiliateFee  uint64
+}
+
+// WithdrawLiquidityMemo withdraws BasisPoints of the liquidity
+// of the sender from Pool. Asset is set to withdraw a single side
+type WithdrawLiquidityMemo struct {
+	Pool        Asset
+	BasisPoints uint64
+	Asset       Asset
+}
+
+type DonateMemo struct {
+	Pool Asset
+}
+
+// OutboundMemo is the memo of a vault payment,
+// InTxID is the hash of the inbound tx it answers
+type OutboundMemo struct {
+	InTxID string
+}
+
+// RefundMemo is the memo of a refund of the inbound tx InTxID
+type RefundMemo struct {
+	InTxID string
+}
+
+// MigrateMemo moves funds to the new vaults after the churn at Height
+type MigrateMemo struct {
+	Height uint64
+}
+
+type RagnarokMemo struct {
+	Height uint64
+}
+
+type ConsolidateMemo struct{}
+
+func (SwapMemo) Type() Type              { return TypeSwap }
+func (AddLiquidityMemo) Type() Type      { return TypeAddLiquidity }
+func (WithdrawLiquidityMemo) Type() Type { return TypeWithdrawLiquidity }
+func (DonateMemo) Type() Type            { return TypeDonate }
+func (OutboundMemo) Type() Type          { return TypeOutbound }
+func (RefundMemo) Type() Type            { return TypeRefund }
+func (MigrateMemo) Type() Type           { return TypeMigrate }
+func (RagnarokMemo) Type() Type          { return TypeRagnarok }
+func (ConsolidateMemo) Type() Type       { return TypeConsolidate }
+
+func (m SwapMemo) fields(short bool) []string {
+	limit := ""
+	if m.Interval != 0 || m.Quantity != 0 {
+		limit = fmt.Sprintf("%s/%d/%d", formatAmount(m.Limit, short), m.Interval, m.Quantity)
+	} else if m.Limit != 0 {
+		limit = formatAmount(m.Limit, short)
+	}
+	return []string{asset(m.Asset, short), m.Address, limit, m.Affiliate, affiliateFee(m.Affiliate, m.AffiliateFee)}
+}
+
+func (m AddLiquidityMemo) fields(short bool) []string {
+	return []string{asset(m.Pool, short), m.PairedAddress, m.Affiliate, affiliateFee(m.Affiliate, m.AffiliateFee)}
+}
+
+func (m WithdrawLiquidityMemo) fields(short bool) []string {
+	return []string{asset(m.Pool, short), strconv.FormatUint(m.BasisPoints, 10), asset(m.Asset, short)}
+}
+
+func (m DonateMemo) fields(short bool) []string {
+	return []string{asset(m.Pool, short)}
+}
+
+func (m OutboundMemo) fields(bool) []string  { return []string{m.InTxID} }
+func (m RefundMemo) fields(bool) []string    { return []string{m.InTxID} }
+func (m MigrateMemo) fields(bool) []string   { return []string{strconv.FormatUint(m.Height, 10)} }
+func (m RagnarokMemo) fields(bool) []string  { return []string{strconv.FormatUint(m.Height, 10)} }
+func (ConsolidateMemo) fields(bool) []string { return nil }
+
+func asset(a Asset, short bool) string {
+	if short {
+		return a.Short()
+	}
+	return a.String()
+}
+
+func affiliateFee(affiliate string, fee uint64) string {
+	if affiliate == "" && fee == 0 {
+		return ""
+	}
+	return strconv.FormatUint(fee, 10)
+}
+
+// formatAmount uses the scientific notation (12e8) in short
+// memos when it saves bytes
+func formatAmount(v uint64, short bool) string {
+	s := strconv.FormatUint(v, 10)
+	if !short || v == 0 {
+		return s
+	}
+	mantissa := strings.TrimRight(s, "0")
+	exp := strconv.Itoa(len(s) - len(mantissa))
+	if len(mantissa)+1+len(exp) < len(s) {
+		return mantissa + "e" + exp
+	}
+	return s
+}
+
+func parseAmount(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	mantissa, exp, sci := strings.Cut(strings.ToLower(s), "e")
+	v, err := strconv.ParseUint(mantissa, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: amount %q", ErrInvalidMemo, s)
+	}
+	if !sci {
+		return v, nil
+	}
+	e, err := strconv.ParseUint(exp, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("%w: amount %q", ErrInvalidMemo, s)
+	}
+	for ; e > 0; e-- {
+		if v > ^uint64(0)/10 {
+			return 0, fmt.Errorf("%w: amount %q overflows", ErrInvalidMemo, s)
+		}
+		v *= 10
+	}
+	return v, nil
+}
+
+func parseTxID(s string) (string, error) {
+	if b, err := hex.DecodeString(s); err != nil || len(b) != 32 {
+		return "", fmt.Errorf("%w: tx hash %q", ErrInvalidMemo, s)
+	}
+	return s, nil
+}
+
+// Parse reads a memo in full or abbreviated notation
+func Parse(memo string) (Memo, error) {
+	parts := strings.Split(strings.TrimSpace(memo), ":")
+	var t Type
+	for typ, k := range keywords {
+		for _, keyword := range k {
+			if strings.EqualFold(parts[0], keyword) {
+				t = typ
+			}
+		}
+	}
+	if t == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownType, parts[0])
+	}
+	args := parts[1:]
+	arg := func(i int) string {
+		if i < len(args) {
+			return args[i]
+		}
+		return ""
+	}
+	maxArgs := map[Type]int{
+		TypeSwap: 5, TypeAddLiquidity: 4, TypeWithdrawLiquidity: 3, TypeDonate: 1,
+		TypeOutbound: 1, TypeRefund: 1, TypeMigrate: 1, TypeRagnarok: 1, TypeConsolidate: 0,
+	}[t]
+	if len(args) > maxArgs {
+		return nil, fmt.Errorf("%w: too many fields for %s", ErrInvalidMemo, t)
+	}
+
+	switch t {
+	case TypeSwap:
+		m := SwapMemo{Address: arg(1), Affiliate: arg(3)}
+		a, err := ParseAsset(arg(0))
+		if err != nil {
+			return nil, err
+		}
+		m.Asset = a
+		limits := strings.Split(arg(2), "/")
+		if len(limits) != 1 && len(limits) != 3 {
+			return nil, fmt.Errorf("%w: limit %q", ErrInvalidMemo, arg(2))
+		}
+		if m.Limit, err = parseAmount(limits[0]); err != nil {
+			return nil, err
+		}
+		if len(limits) == 3 {
+			if m.Interval, err = parseAmount(limits[1]); err != nil {
+				return nil, err
+			}
+			if m.Quantity, err = parseAmount(limits[2]); err != nil {
+				return nil, err
+			}
+		}
+		if m.AffiliateFee, err = parseAmount(arg(4)); err != nil {
+			return nil, err
+		}
+		return m, nil
+	case TypeAddLiquidity:
+		m := AddLiquidityMemo{PairedAddress: arg(1), Affiliate: arg(2)}
+		a, err := ParseAsset(arg(0))
+		if err != nil {
+			return nil, err
+		}
+		m.Pool = a
+		if m.AffiliateFee, err = parseAmount(arg(3)); err != nil {
+			return nil, err
+		}
+		return m, nil
+	case TypeWithdrawLiquidity:
+		var m WithdrawLiquidityMemo
+		a, err := ParseAsset(arg(0))
+		if err != nil {
+			return nil, err
+		}
+		m.Pool = a
+		if m.BasisPoints, err = parseAmount(arg(1)); err != nil {
+			return nil, err
+		}
+		if m.BasisPoints == 0 || m.BasisPoints > 10_000 {
+			return nil, fmt.Errorf("%w: basis points %q", ErrInvalidMemo, arg(1))
+		}
+		if arg(2) != "" {
+			if m.Asset, err = ParseAsset(arg(2)); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case TypeDonate:
+		a, err := ParseAsset(arg(0))
+		if err != nil {
+			return nil, err
+		}
+		return DonateMemo{Pool: a}, nil
+	case TypeOutbound, TypeRefund:
+		txid, err := parseTxID(arg(0))
+		if err != nil {
+			return nil, err
+		}
+		if t == TypeOutbound {
+			return OutboundMemo{InTxID: txid}, nil
+		}
+		return RefundMemo{InTxID: txid}, nil
+	case TypeMigrate, TypeRagnarok:
+		height, err := strconv.ParseUint(arg(0), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: height %q", ErrInvalidMemo, arg(0))
+		}
+		if t == TypeMigrate {
+			return MigrateMemo{Height: height}, nil
+		}
+		return RagnarokMemo{Height: height}, nil
+	default:
+		return ConsolidateMemo{}, nil
+	}
+}
+
+func format(m Memo, short bool) string {
+	k := keywords[m.Type()]
+	keyword := k[0]
+	if short {
+		keyword = k[len(k)-1]
+	}
+	parts := append([]string{keyword}, m.fields(short)...)
+	// optional trailing fields are left out
+	for len(parts) > 1 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, ":")
+}
+
+// Build writes a memo for a transparent transaction. It falls back
+// to the abbreviations, the asset shortcodes and the scientific
+// notation of the limit when the full notation does not fit
+// in MaxTransparentLen bytes
+func Build(m Memo) (string, error) {
+	if s := format(m, false); len(s) <= MaxTransparentLen {
+		return s, nil
+	}
+	s := format(m, true)
+	if len(s) > MaxTransparentLen {
+		return "", fmt.Errorf("%w: %d bytes", ErrTooLong, len(s))
+	}
+	return s, nil
+}
+
+// Validate checks the destination of swaps to ZEC with
+// validateAddress, which should be maya_zcash.ValidateAddress
+func Validate(m Memo, validateAddress func(string) (bool, error)) error {
+	swap, ok := m.(SwapMemo)
+	if !ok || swap.Asset.Chain != "ZEC" || swap.Asset.Synth || swap.Address == "" {
+		return nil
+	}
+	valid, err := validateAddress(swap.Address)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("%w: %s", ErrInvalidAddress, swap.Address)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package mayamemo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const ethAddr = "0x9d2a2ea4ff4d2d8d7fc7bd2b8f1dab8e8b4a3f4c"
+const txid = "d120e67dac6ccdb49915542544ae2673fd4aef6adc1fa4eac9012134c9f3ddd0"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		memo string
+		want Memo
+	}{
+		{"SWAP:ETH.ETH:" + ethAddr, SwapMemo{Asset: Asset{Chain: "ETH", Symbol: "ETH"}, Address: ethAddr}},
+		{"=:e:" + ethAddr + ":1234567890/1/0:mayac:15", SwapMemo{
+			Asset: Asset{Chain: "ETH", Symbol: "ETH"}, Address: ethAddr,
+			Limit: 1234567890, Interval: 1, Affiliate: "mayac", AffiliateFee: 15,
+		}},
+		{"s:eth.usdt-0xdac17f958d2ee523a2206206994597c13d831ec7:" + ethAddr + ":15e7", SwapMemo{
+			Asset: Asset{Chain: "ETH", Symbol: "USDT-0XDAC17F958D2EE523A2206206994597C13D831EC7"}, Address: ethAddr,
+			Limit: 150_000_000,
+		}},
+		{"=:BTC/BTC:maya1qv3wnwu8nnv4cj5d6mmh5jg4xz2mqtyfevyd6x", SwapMemo{
+			Asset: Asset{Chain: "BTC", Symbol: "BTC", Synth: true}, Address: "maya1qv3wnwu8nnv4cj5d6mmh5jg4xz2mqtyfevyd6x",
+		}},
+		{"+:ZEC.ZEC", AddLiquidityMemo{Pool: Asset{Chain: "ZEC", Symbol: "ZEC"}}},
+		{"ADD:z:maya1qv3wnwu8nnv4cj5d6mmh5jg4xz2mqtyfevyd6x", AddLiquidityMemo{
+			Pool: Asset{Chain: "ZEC", Symbol: "ZEC"}, PairedAddress: "maya1qv3wnwu8nnv4cj5d6mmh5jg4xz2mqtyfevyd6x",
+		}},
+		{"wd:ZEC.ZEC:10000:MAYA.CACAO", WithdrawLiquidityMemo{
+			Pool: Asset{Chain: "ZEC", Symbol: "ZEC"}, BasisPoints: 10_000, Asset: Asset{Chain: "MAYA", Symbol: "CACAO"},
+		}},
+		{"DONATE:ZEC.ZEC", DonateMemo{Pool: Asset{Chain: "ZEC", Symbol: "ZEC"}}},
+		{"OUT:" + txid, OutboundMemo{InTxID: txid}},
+		{"REFUND:" + txid, RefundMemo{InTxID: txid}},
+		{"MIGRATE:1234", MigrateMemo{Height: 1234}},
+		{"RAGNAROK:1234", RagnarokMemo{Height: 1234}},
+		{"CONSOLIDATE", ConsolidateMemo{}},
+	}
+	for _, test := range tests {
+		m, err := Parse(test.memo)
+		if err != nil {
+			t.Errorf("Parse(%q) = %v", test.memo, err)
+			continue
+		}
+		if m != test.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", test.memo, m, test.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		memo string
+		err  error
+	}{
+		{"MEMO", ErrUnknownType},
+		{"SWAP:ETH", ErrInvalidAsset},
+		{"SWAP:ETH.ETH:" + ethAddr + ":1/2", ErrInvalidMemo},
+		{"SWAP:ETH.ETH:" + ethAddr + ":1e30", ErrInvalidMemo},
+		{"WITHDRAW:ZEC.ZEC:10001", ErrInvalidMemo},
+		{"OUT:1234", ErrInvalidMemo},
+		{"MIGRATE:", ErrInvalidMemo},
+		{"CONSOLIDATE:1", ErrInvalidMemo},
+	}
+	for _, test := range tests {
+		if _, err := Parse(test.memo); !errors.Is(err, test.err) {
+			t.Errorf("Parse(%q) = %v, want %v", test.memo, err, test.err)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	swap := SwapMemo{Asset: Asset{Chain: "ETH", Symbol: "ETH"}, Address: ethAddr, Limit: 123_450_000}
+	s, err := Build(swap)
+	if err != nil || s != "SWAP:ETH.ETH:"+ethAddr+":123450000" {
+		t.Errorf("Build = %q, %v", s, err)
+	}
+
+	// too long for the full notation
+	swap.Interval, swap.Quantity = 1, 0
+	swap.Affiliate, swap.AffiliateFee = "mayachainaffiliate", 15
+	s, err = Build(swap)
+	if err != nil || s != "=:e:"+ethAddr+":12345e4/1/0:mayachainaffiliate:15" {
+		t.Errorf("Build = %q, %v", s, err)
+	}
+	m, err := Parse(s)
+	if err != nil || m != swap {
+		t.Errorf("Parse(Build) = %+v, %v", m, err)
+	}
+
+	swap.Affiliate = strings.Repeat("a", 40)
+	if _, err := Build(swap); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Build = %v, want %v", err, ErrTooLong)
+	}
+
+	for _, m := range []Memo{
+		WithdrawLiquidityMemo{Pool: Asset{Chain: "ZEC", Symbol: "ZEC"}, BasisPoints: 5000},
+		OutboundMemo{InTxID: txid},
+		MigrateMemo{Height: 1234},
+		ConsolidateMemo{},
+	} {
+		s, err := Build(m)
+		if err != nil {
+			t.Errorf("Build(%+v) = %v", m, err)
+			continue
+		}
+		if p, err := Parse(s); err != nil || p != m {
+			t.Errorf("Parse(%q) = %+v, %v", s, p, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	validate := func(address string) (bool, error) {
+		return strings.HasPrefix(address, "t1"), nil
+	}
+	zec := Asset{Chain: "ZEC", Symbol: "ZEC"}
+	if err := Validate(SwapMemo{Asset: zec, Address: "t1RyCw14wRXrh3mp21uxgr9ynjem7cNUkMH"}, validate); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+	if err := Validate(SwapMemo{Asset: zec, Address: ethAddr}, validate); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("Validate = %v, want %v", err, ErrInvalidAddress)
+	}
+	// only the ZEC destinations are checked
+	eth := Asset{Chain: "ETH", Symbol: "ETH"}
+	if err := Validate(SwapMemo{Asset: eth, Address: ethAddr}, validate); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package maya_zcash
+
+import "mayazcash_go/maya_zcash/mayamemo"
+
+// NoMemo is an empty memo, the same as the zero Memo
+func NoMemo() Memo {
+	return Memo{}
+}
+
+// TextMemo is a UTF-8 memo. Transparent memos are limited
+// to 80 bytes and shielded memos to 512 bytes.
+func TextMemo(text string) Memo {
+	kind := MemoKindText
+	return Memo{Kind: &kind, Text: text, Data: []byte(text)}
+}
+
+// BytesMemo is a memo of arbitrary bytes. Shielded memos
+// are limited to 511 bytes, the first byte is the 0xFF tag.
+func BytesMemo(data []byte) Memo {
+	kind := MemoKindArbitrary
+	return Memo{Kind: &kind, Data: data}
+}
+
+// String is the text of a text memo and empty for the other kinds
+func (m Memo) String() string {
+	if m.Kind == nil || *m.Kind != MemoKindText {
+		return ""
+	}
+	return m.Text
+}
+
+// ParseMayaMemo reads the MAYAChain memo of a vault tx and checks
+// the destination address of swaps to ZEC
+func ParseMayaMemo(memo Memo) (mayamemo.Memo, error) {
+	m, err := mayamemo.Parse(memo.String())
+	if err != nil {
+		return nil, err
+	}
+	if err := mayamemo.Validate(m, ValidateAddress); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// BuildMayaMemo is the text memo of a deposit to a vault
+// It is abbreviated if needed to fit in an OP_RETURN output
+func BuildMayaMemo(m mayamemo.Memo) (Memo, error) {
+	if err := mayamemo.Validate(m, ValidateAddress); err != nil {
+		return Memo{}, err
+	}
+	s, err := mayamemo.Build(m)
+	if err != nil {
+		return Memo{}, err
+	}
+	return TextMemo(s), nil
+}
@@ -1,14 +1,24 @@
+//go:build !mainnet
+
 package maya_zcash
 
 import (
+	"bytes"
+	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"mayazcash_go/maya_zcash/mayamemo"
 )
 
 func TestMain(t *testing.M) {
     InitLogger();
-    t.Run();
+    os.Exit(runWithFixtures(t, "regtest"));
 }
 
 func TestLatestHeight(t *testing.T) {
@@ -28,6 +38,28 @@ func TestVaultAddress(t *testing.T) {
     // regtest: tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6
 }
 
+func TestVaultDepositAddress(t *testing.T) {
+    bytes, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    // no viewing key yet
+    _, err := GetVaultDepositAddress(bytes)
+    if !errors.Is(err, ErrZcashErrorAssertError) {
+        t.Errorf(`TestVaultDepositAddress = %v`, err)
+    }
+    err = SetVaultViewingKey(bytes, "not a viewing key")
+    if !errors.Is(err, ErrZcashErrorAssertError) {
+        t.Errorf(`TestVaultDepositAddress = %v`, err)
+    }
+    err = SetVaultViewingKey([]byte{1, 2, 3}, "")
+    if !errors.Is(err, ErrZcashErrorInvalidVaultPubkey) {
+        t.Errorf(`TestVaultDepositAddress = %v`, err)
+    }
+    // removing a missing key is fine
+    err = SetVaultViewingKey(bytes, "")
+    if err != nil {
+        t.Errorf(`TestVaultDepositAddress = %v`, err)
+    }
+}
+
 func TestValidateAddress(t *testing.T) {
     valid, err := ValidateAddress("tmWksakBYGg7Lqtm1EqSqvPkVYJHYxGq6Za")
     if err != nil {
@@ -121,12 +153,100 @@ func TestSendToVault(t *testing.T) {
     // secret key of the user account: L1sjrupHTXwtX847jZhXpkACVYE6d4edPeJK9762j7AeCYL4c32z
     sk, _ := hex.DecodeString("8ae9c0c958937eeec71e034650e889085c10e91ae1ab94a26c26182f9516a37f")
     vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
-    _, err := SendToVault(200, sk, "tmP9jLgTnhDdKdWJCm4BT2t6acGnxqP14yU", vault, 10000000, "MEMO")
+    _, err := SendToVault(200, sk, "tmP9jLgTnhDdKdWJCm4BT2t6acGnxqP14yU", vault, 10000000, TextMemo("MEMO"))
     if err != nil {
         t.Errorf(`TestSendToVault = %v`, err)
     }
 }
 
+func TestBuildDepositToVault(t *testing.T) {
+    // user account: L1sjrupHTXwtX847jZhXpkACVYE6d4edPeJK9762j7AeCYL4c32z
+    k, err := SkToPub("L1sjrupHTXwtX847jZhXpkACVYE6d4edPeJK9762j7AeCYL4c32z")
+    if err != nil {
+        t.Fatalf(`TestBuildDepositToVault = %v`, err)
+    }
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    ptx, err := BuildDepositToVault(200, k.Addr, k.Pk, vault, 10000000, TextMemo("MEMO"))
+    if err != nil {
+        t.Fatalf(`TestBuildDepositToVault = %v`, err)
+    }
+    if len(ptx.Sighashes.Hashes) != len(ptx.Inputs) {
+        t.Errorf(`Expected one sighash per input`)
+    }
+    // the signatures would normally come from the external signer
+    signatures := make([][]byte, 0)
+    for _, sighash := range ptx.Sighashes.Hashes {
+        signature, _ := SignSighash(k.Sk, sighash)
+        signatures = append(signatures, signature)
+    }
+    _, err = ApplySignatures(k.Pk, ptx, signatures)
+    if err != nil {
+        t.Errorf(`TestBuildDepositToVault = %v`, err)
+    }
+}
+
+func TestSendToVaultFrom(t *testing.T) {
+    user, _ := SkToPub("L1sjrupHTXwtX847jZhXpkACVYE6d4edPeJK9762j7AeCYL4c32z")
+    other, _ := SkToPub("L1rrP7J2tqVfC5sj5wi8Gn4M2f4kyX1dByHPVHCa6Mzyz8eahu77")
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    _, err := SendToVaultFrom(200, []TransparentKey{user, other}, user.Addr, vault, 10000000, TextMemo("MEMO"))
+    if err != nil {
+        t.Errorf(`TestSendToVaultFrom = %v`, err)
+    }
+}
+
+func TestSendToVaultFromDuplicateSource(t *testing.T) {
+    user, _ := SkToPub("L1sjrupHTXwtX847jZhXpkACVYE6d4edPeJK9762j7AeCYL4c32z")
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    _, err := SendToVaultFrom(200, []TransparentKey{user, user}, user.Addr, vault, 10000000, TextMemo("MEMO"))
+    if !errors.Is(err, ErrZcashErrorAssertError) {
+        t.Errorf(`TestSendToVaultFromDuplicateSource = %v`, err)
+    }
+}
+
+func TestBuildDepositToVaultFrom(t *testing.T) {
+    user, _ := SkToPub("L1sjrupHTXwtX847jZhXpkACVYE6d4edPeJK9762j7AeCYL4c32z")
+    other, _ := SkToPub("L1rrP7J2tqVfC5sj5wi8Gn4M2f4kyX1dByHPVHCa6Mzyz8eahu77")
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    // external signers do not share their secret keys
+    sources := []TransparentKey{
+        TransparentKey{Pk: user.Pk, Addr: user.Addr},
+        TransparentKey{Pk: other.Pk, Addr: other.Addr},
+    }
+    ptx, err := BuildDepositToVaultFrom(200, sources, user.Addr, vault, 10000000, TextMemo("MEMO"))
+    if err != nil {
+        t.Fatalf(`TestBuildDepositToVaultFrom = %v`, err)
+    }
+    userUtxos, _ := ListUtxos(user.Addr)
+    owned := make(map[string]bool)
+    for _, u := range userUtxos {
+        owned[fmt.Sprintf("%s:%d", u.Txid, u.Vout)] = true
+    }
+    signatures := make([][]byte, 0)
+    for i, sighash := range ptx.Sighashes.Hashes {
+        input := ptx.Inputs[i]
+        sk := other.Sk
+        if owned[fmt.Sprintf("%s:%d", input.Txid, input.Vout)] {
+            sk = user.Sk
+        }
+        signature, _ := SignSighash(sk, sighash)
+        signatures = append(signatures, signature)
+    }
+    _, err = ApplyDepositSignatures(sources, ptx, signatures)
+    if err != nil {
+        t.Errorf(`TestBuildDepositToVaultFrom = %v`, err)
+    }
+}
+
+func TestSendShieldedToVaultNoNotes(t *testing.T) {
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    // the notes come from the user wallet, none here
+    _, err := SendShieldedToVault(200, nil, []ShieldedNote{}, nil, []ShieldedNote{}, vault, 10000000, TextMemo("MEMO"))
+    if !errors.Is(err, ErrZcashErrorNotEnoughFunds) {
+        t.Errorf(`TestSendShieldedToVaultNoNotes = %v`, err)
+    }
+}
+
 func TestBroadcast(t *testing.T) {
     // Skip this test because hardcoding a raw tx does not work
     t.SkipNow()
@@ -143,7 +263,7 @@ func TestBroadcast(t *testing.T) {
 
 func TestPayFromVault(t *testing.T) {
     vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
-    ptx, err := PayFromVault(200, vault, "tmP9jLgTnhDdKdWJCm4BT2t6acGnxqP14yU", 500000, "MEMO OUT")
+    ptx, err := PayFromVault(200, vault, "tmP9jLgTnhDdKdWJCm4BT2t6acGnxqP14yU", 500000, TextMemo("MEMO OUT"))
     if err != nil {
         t.Errorf(`TestPayFromVault = %v`, err)
     }
@@ -174,7 +294,7 @@ func TestCombineVaultUTXOs(t *testing.T) {
         Output{
             Address: "tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6",
             Amount:  540000000,
-            Memo:    "CONSOLIDATE",
+            Memo:    TextMemo("CONSOLIDATE"),
         },
     }, utxos)
     if err != nil {
@@ -185,6 +305,28 @@ func TestCombineVaultUTXOs(t *testing.T) {
     }
 }
 
+func TestCombineVaultUTXOsWithoutMemo(t *testing.T) {
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    utxos, err := ListUtxos("tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6")
+    if err != nil {
+        t.Errorf(`TestCombineVaultUTXOsWithoutMemo = %v`, err)
+    }
+    // the zero Memo is an empty memo
+    ptx, err := CombineVaultUtxos(200, vault,
+    []Output {
+        Output{
+            Address: "tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6",
+            Amount:  540000000,
+        },
+    }, utxos)
+    if err != nil {
+        t.Fatalf(`TestCombineVaultUTXOsWithoutMemo = %v`, err)
+    }
+    if ptx.Outputs[0].Memo.Kind != nil {
+        t.Errorf(`Unexpected memo %v`, ptx.Outputs[0].Memo)
+    }
+}
+
 func TestSignSighash(t *testing.T) {
     sk, _ := hex.DecodeString("8ae9c0c958937eeec71e034650e889085c10e91ae1ab94a26c26182f9516a37f")
     sighash, _ := hex.DecodeString("32fe38e61df5290198ec736e7b0a1b7cb8a372e42d26c2e3aabcfed29977e911")
@@ -201,7 +343,7 @@ func TestApplySignatures(t *testing.T) {
     // transpa: tm9j9tS8nTnNQqoJuw8ToinJCapd3WdzGVu
     // sapling: zregtestsapling18ywlqhk60zglax5drk3kwltkmcatf5eptxyrkrx20hcqma5nsvrgh63843seye923qk5wfvxpnr
     // orchard: uregtest1w7mhyq5xd5h8zrlqfdnf8kqrd0g8n8q9hg8502e63sr5xuenhyvama2jytdul0k2krj2kq86x86ch8x9eejxh4se8en4jpwdkse7l0gl
-    ptx, _ := PayFromVault(200, vault, "tm9j9tS8nTnNQqoJuw8ToinJCapd3WdzGVu", 500000, "MEMO OUT")
+    ptx, _ := PayFromVault(200, vault, "tm9j9tS8nTnNQqoJuw8ToinJCapd3WdzGVu", 500000, TextMemo("MEMO OUT"))
     sighashes := ptx.Sighashes;
     signatures := make([][]byte, 0)
     for _, sighash := range sighashes.Hashes {
@@ -217,9 +359,53 @@ func TestApplySignatures(t *testing.T) {
     fmt.Printf("txid: %s\n", txid)
 }
 
+func TestApplySignaturesDeterministic(t *testing.T) {
+    vault_sk, _ := hex.DecodeString("8a74dce839bc2228428ed5de3c2edbabb5c9713f5e6eeb808f9c56640921c6c9")
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    // pay to the orchard receiver so that the tx has a proof and binding signature
+    ptx, err := PayFromVault(200, vault, "uregtest1w7mhyq5xd5h8zrlqfdnf8kqrd0g8n8q9hg8502e63sr5xuenhyvama2jytdul0k2krj2kq86x86ch8x9eejxh4se8en4jpwdkse7l0gl", 500000, TextMemo("MEMO OUT"))
+    if err != nil {
+        t.Fatalf(`TestApplySignaturesDeterministic = %v`, err)
+    }
+    signatures := make([][]byte, 0)
+    for _, sighash := range ptx.Sighashes.Hashes {
+        signature, _ := SignSighash(vault_sk, sighash)
+        signatures = append(signatures, signature)
+    }
+    txb1, err := ApplySignatures(vault, ptx, signatures)
+    if err != nil {
+        t.Fatalf(`TestApplySignaturesDeterministic = %v`, err)
+    }
+    txb2, err := ApplySignatures(vault, ptx, signatures)
+    if err != nil {
+        t.Fatalf(`TestApplySignaturesDeterministic = %v`, err)
+    }
+    if !bytes.Equal(txb1, txb2) {
+        t.Errorf("Finalizing the same partial tx twice should produce the same bytes")
+    }
+}
+
+func TestBinaryMemo(t *testing.T) {
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    memo := BytesMemo(bytes.Repeat([]byte{0xde, 0xad}, 150))
+    // 300 bytes fit in a shielded memo
+    ptx, err := PayFromVault(200, vault, "uregtest1w7mhyq5xd5h8zrlqfdnf8kqrd0g8n8q9hg8502e63sr5xuenhyvama2jytdul0k2krj2kq86x86ch8x9eejxh4se8en4jpwdkse7l0gl", 500000, memo)
+    if err != nil {
+        t.Fatalf(`TestBinaryMemo = %v`, err)
+    }
+    if ptx.Outputs[0].Memo.Kind == nil || *ptx.Outputs[0].Memo.Kind != MemoKindArbitrary || !bytes.Equal(ptx.Outputs[0].Memo.Data, memo.Data) {
+        t.Errorf("Unexpected memo %v", ptx.Outputs[0].Memo)
+    }
+    // but not in an OP_RETURN output
+    _, err = BuildDepositToVault(200, "tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6", vault, vault, 10000000, memo)
+    if err == nil {
+        t.Errorf("A transparent memo of 300 bytes should be rejected")
+    }
+}
+
 func TestScanMempool(t *testing.T) {
     bytes, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
-    txs, err := ScanMempool(bytes)
+    txs, err := ScanMempool(bytes, [][]byte {})
     if err != nil {
         t.Errorf(`TestScanMempool = %v`, err)
     }
@@ -229,10 +415,417 @@ func TestScanMempool(t *testing.T) {
     fmt.Printf("txs: %v\n", txs)
 }
 
+func TestKnownVaults(t *testing.T) {
+    other, _ := SkToPub("L1rrP7J2tqVfC5sj5wi8Gn4M2f4kyX1dByHPVHCa6Mzyz8eahu77")
+    bytes, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    _, err := ScanMempool(bytes, [][]byte{[]byte{1, 2, 3}})
+    if !errors.Is(err, ErrZcashErrorInvalidVaultPubkey) {
+        t.Errorf(`TestKnownVaults = %v`, err)
+    }
+
+    txs, err := ScanMempool(bytes, [][]byte{other.Pk})
+    if err != nil {
+        t.Errorf(`TestKnownVaults = %v`, err)
+    }
+    for _, tx := range txs {
+        if len(tx.Destinations) == 0 {
+            t.Errorf("Missing destinations for %v", tx.Txid)
+        }
+    }
+}
+
 func TestScanBlocks(t *testing.T) {
     bytes, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
-    _, err := ScanBlocks(bytes, []string {})
+    _, err := ScanBlocks(bytes, []string {}, [][]byte {})
     if err != nil {
         t.Errorf(`TestScanBlocks = %v`, err)
     }
 }
+
+func TestScanBlockRange(t *testing.T) {
+    bytes, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    all, err := ScanBlocks(bytes, []string {}, [][]byte {})
+    if err != nil || all == nil {
+        t.Fatalf(`TestScanBlockRange = %v`, err)
+    }
+    expected := 0
+    for _, tx := range all.Txs {
+        if tx.Height > all.StartHeight {
+            expected += 1
+        }
+    }
+    // page through the same blocks, 10 at a time
+    count := 0
+    from := all.StartHash
+    for {
+        page, err := ScanBlockRange(bytes, from, 10, [][]byte {})
+        if err != nil {
+            t.Fatalf(`TestScanBlockRange = %v`, err)
+        }
+        if page == nil {
+            break
+        }
+        if page.EndHeight - page.StartHeight >= 10 {
+            t.Errorf("Page too large: %v-%v", page.StartHeight, page.EndHeight)
+        }
+        count += len(page.Txs)
+        from = page.EndHash
+    }
+    if count < expected {
+        t.Errorf("Found %v txs, expected at least %v", count, expected)
+    }
+}
+
+func TestBackfillVault(t *testing.T) {
+    bytes, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    all, err := ScanBlocks(bytes, []string {}, [][]byte {})
+    if err != nil || all == nil {
+        t.Fatalf(`TestBackfillVault = %v`, err)
+    }
+    txs, err := BackfillVault(bytes, all.StartHeight, all.EndHeight, [][]byte {})
+    if err != nil {
+        t.Fatalf(`TestBackfillVault = %v`, err)
+    }
+    // the same history without scanning the blocks
+    if len(txs) != len(all.Txs) {
+        t.Errorf("Found %v txs, expected %v", len(txs), len(all.Txs))
+    }
+    for i := 1; i < len(txs); i++ {
+        if txs[i].Height < txs[i-1].Height {
+            t.Errorf("Txs out of order at %v", i)
+        }
+    }
+    _, err = BackfillVault(bytes, 10, 9, [][]byte {})
+    if err == nil {
+        t.Errorf("An empty range should be rejected")
+    }
+}
+
+func TestScanMulti(t *testing.T) {
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    other, _ := SkToPub("L1rrP7J2tqVfC5sj5wi8Gn4M2f4kyX1dByHPVHCa6Mzyz8eahu77")
+    pubkeys := [][]byte{vault, other.Pk}
+    txs, err := ScanMempoolMulti(pubkeys, [][]byte {})
+    if err != nil {
+        t.Errorf(`TestScanMulti = %v`, err)
+    }
+    for _, tx := range txs {
+        if !bytes.Equal(tx.Vault, vault) && !bytes.Equal(tx.Vault, other.Pk) {
+            t.Errorf("Unknown vault for %v", tx.Txid)
+        }
+    }
+    btxs, err := ScanBlocksMulti(pubkeys, []string {}, [][]byte {})
+    if err != nil {
+        t.Errorf(`TestScanMulti = %v`, err)
+    }
+    single, _ := ScanBlocks(vault, []string {}, [][]byte {})
+    if btxs != nil && single != nil {
+        count := 0
+        for _, tx := range btxs.Txs {
+            if bytes.Equal(tx.Vault, vault) {
+                count += 1
+            }
+        }
+        if count != len(single.Txs) {
+            t.Errorf("Multi scan found %v txs for the vault instead of %v", count, len(single.Txs))
+        }
+    }
+}
+
+func TestGetVaultTxDetail(t *testing.T) {
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    btxs, err := ScanBlocks(vault, []string {}, [][]byte {})
+    if err != nil || btxs == nil || len(btxs.Txs) == 0 {
+        t.Fatalf(`TestGetVaultTxDetail = %v`, err)
+    }
+    tx := btxs.Txs[0]
+    detail, err := GetVaultTxDetail(vault, tx.Txid, [][]byte {})
+    if err != nil {
+        t.Fatalf(`TestGetVaultTxDetail = %v`, err)
+    }
+    if detail.Tx.Height != tx.Height || detail.BlockHash == "" || detail.Confirmations == 0 {
+        t.Errorf("Invalid block info: %v", detail)
+    }
+    var in, out uint64
+    for _, i := range detail.Inputs {
+        in += i.Value
+    }
+    for _, o := range detail.Outputs {
+        out += o.Amount
+    }
+    // all the outputs are transparent or recovered by the vault
+    if len(detail.Inputs) > 0 && in != out + detail.Fee {
+        t.Errorf("Inputs %v != Outputs %v + Fee %v", in, out, detail.Fee)
+    }
+    // the vault OVK recovers every shielded output the vault pays
+    if detail.Tx.Incomplete {
+        t.Errorf("Incomplete tx: %v", detail.Tx.Undecrypted)
+    }
+}
+
+func TestScanBlockRangeDetail(t *testing.T) {
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    all, err := ScanBlocks(vault, []string {}, [][]byte {})
+    if err != nil || all == nil {
+        t.Fatalf(`TestScanBlockRangeDetail = %v`, err)
+    }
+    from := all.StartHash
+    btxs, err := ScanBlockRange(vault, from, 10, [][]byte {})
+    if err != nil || btxs == nil {
+        t.Fatalf(`TestScanBlockRangeDetail = %v`, err)
+    }
+    details, err := ScanBlockRangeDetail(vault, from, 10, [][]byte {})
+    if err != nil || details == nil {
+        t.Fatalf(`TestScanBlockRangeDetail = %v`, err)
+    }
+    if details.EndHash != btxs.EndHash || len(details.Txs) != len(btxs.Txs) {
+        t.Fatalf("Scans differ: %v %v", details, btxs)
+    }
+    for i, detail := range details.Txs {
+        if detail.Tx.Txid != btxs.Txs[i].Txid || detail.BlockHash == "" || detail.Confirmations == 0 {
+            t.Errorf("Invalid detail: %v", detail)
+        }
+        same, err := GetVaultTxDetail(vault, detail.Tx.Txid, [][]byte {})
+        if err != nil || same.Fee != detail.Fee || same.Position != detail.Position {
+            t.Errorf("Detail differs: %v %v %v", err, same, detail)
+        }
+    }
+}
+
+func TestAnalyzeVaultTx(t *testing.T) {
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    txb, _ := hex.DecodeString("050000800a27a7265510e7c800000000f00000000186b1a9c7f46c7550e48fa0781495ef891ed81b48506ef53a28c3e83a223f6482000000006a473044022014e4bc7f7ab7034fe1992ee128484e72864e7d08380b2e663b43c2d490aa26190220643a69a289195a62f9c85c208a27d417f847fd1ea94af086cce435ce6eb9f89f012103243597856d5bd7c8f91f77446a53db425ce10d237c1d6928f2268acdc538797effffffff030000000000000000066a044d454d4f80969800000000001976a914e6d4b9d2c408bf6bd44523b3b6607de4853b806088ace83c8e06000000001976a914936667ff8d2d41361a4df4a370b309fb15380eac88ac0000002024")
+    // this deposit goes to another address
+    tx, err := AnalyzeVaultTx(NetworkRegtest, vault, txb, []TxInput{}, [][]byte {}, "")
+    if err != nil {
+        t.Fatalf(`TestAnalyzeVaultTx = %v`, err)
+    }
+    if tx != nil {
+        t.Errorf("Unexpected vault tx: %v", tx)
+    }
+    _, err = AnalyzeVaultTx(NetworkRegtest, vault, txb[:20], []TxInput{}, [][]byte {}, "")
+    if err == nil {
+        t.Errorf("Truncated tx should fail")
+    }
+}
+
+func TestStore(t *testing.T) {
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    path := filepath.Join(t.TempDir(), "vaults.db")
+    err := OpenStore(path)
+    if err != nil {
+        t.Fatalf(`TestStore = %v`, err)
+    }
+    defer CloseStore()
+
+    for {
+        btxs, err := StoreScan(vault, 100, [][]byte {})
+        if err != nil {
+            t.Fatalf(`TestStore = %v`, err)
+        }
+        if btxs == nil {
+            break
+        }
+    }
+    cursor, err := StoreGetCursor(vault)
+    tip, _ := GetLatestHeight()
+    if err != nil || cursor == nil || cursor.Number != tip.Number {
+        t.Fatalf("Cursor %v is not at the tip %v", cursor, tip)
+    }
+
+    balance, err := StoreGetBalance(vault)
+    if err != nil {
+        t.Fatalf(`TestStore = %v`, err)
+    }
+    expected, _ := GetBalance("tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6")
+    if balance != expected {
+        t.Errorf("Store balance %d != node balance %d", balance, expected)
+    }
+
+    // the state survives a restart
+    txs, _ := StoreListTxs(vault, 0)
+    CloseStore()
+    err = OpenStore(path)
+    if err != nil {
+        t.Fatalf(`TestStore = %v`, err)
+    }
+    txs2, _ := StoreListTxs(vault, 0)
+    if len(txs) != len(txs2) {
+        t.Errorf("Lost txs after reopening: %d != %d", len(txs), len(txs2))
+    }
+
+    // rewinding forgets the recent blocks
+    err = StoreRewind(vault, 1)
+    if err != nil {
+        t.Fatalf(`TestStore = %v`, err)
+    }
+    utxos, _ := StoreListUtxos(vault, true)
+    if len(utxos) != 0 {
+        t.Errorf("UTXOs left after rewind: %v", utxos)
+    }
+}
+
+func TestSubscribe(t *testing.T) {
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
+    defer cancel()
+    sub, err := Subscribe(ctx, [][]byte{vault}, [][]byte {})
+    if err != nil {
+        t.Fatalf(`TestSubscribe = %v`, err)
+    }
+    // the subscription starts at the tip: nothing can be removed
+    // and no deposit can be double spent
+    for e := range sub.Events() {
+        if e.Kind == VaultEventKindRemoved || e.Kind == VaultEventKindConflicted {
+            t.Errorf("Unexpected event %v", e)
+        }
+    }
+    // and the channel closes with the context
+    if !errors.Is(sub.Err(), context.DeadlineExceeded) {
+        t.Errorf("Subscription ended early: %v", sub.Err())
+    }
+}
+
+func TestConfirmationTracker(t *testing.T) {
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    btxs, err := ScanBlocks(vault, []string {}, [][]byte {})
+    if err != nil || btxs == nil || len(btxs.Txs) == 0 {
+        t.Fatalf(`TestConfirmationTracker = %v`, err)
+    }
+    tracker, err := NewConfirmationTracker([]ConfirmationTier{
+        {MinAmount: 0, Confirmations: 1},
+        {MinAmount: 100000000000, Confirmations: 1000000},
+    })
+    if err != nil {
+        t.Fatalf(`TestConfirmationTracker = %v`, err)
+    }
+    defer tracker.Close()
+    tracker.WatchVaultTxs(btxs.Txs[:1])
+    tracker.Watch("0000000000000000000000000000000000000000000000000000000000000000", 100000000000)
+
+    confirmations, err := tracker.Poll()
+    if err != nil || len(confirmations) != 2 {
+        t.Fatalf(`TestConfirmationTracker = %v %v`, confirmations, err)
+    }
+    mined := confirmations[0]
+    if !mined.InBestChain || mined.Height != btxs.Txs[0].Height || mined.Required != 1 || !mined.ThresholdCrossed {
+        t.Errorf("Invalid confirmation: %v", mined)
+    }
+    unknown := confirmations[1]
+    if unknown.InBestChain || unknown.Required != 1000000 || unknown.ThresholdCrossed {
+        t.Errorf("Invalid confirmation: %v", unknown)
+    }
+
+    // the threshold is only crossed once
+    confirmations, _ = tracker.Poll()
+    if confirmations[0].ThresholdCrossed {
+        t.Errorf("Threshold crossed twice: %v", confirmations[0])
+    }
+}
+
+func TestParseScriptPubkey(t *testing.T) {
+    script, _ := hex.DecodeString("76a914e6d4b9d2c408bf6bd44523b3b6607de4853b806088ac")
+    spk := ParseScriptPubkey(script)
+    if spk.Kind != ScriptKindP2Pkh || spk.Address != "tmWksakBYGg7Lqtm1EqSqvPkVYJHYxGq6Za" {
+        t.Errorf("Invalid P2PKH script: %v", spk)
+    }
+    // OP_RETURN OP_PUSHDATA1 with a 76 byte swap memo
+    memo := "=:ETH.ETH:0x9d2a2ea4ff4d2d8d7fc7bd2b8f1dab8e8b4a3f4c:1234567890/1/0:mayac:15"
+    script = append([]byte{0x6a, 0x4c, byte(len(memo))}, []byte(memo)...)
+    spk = ParseScriptPubkey(script)
+    if spk.Kind != ScriptKindOpReturn || spk.Text != memo || !bytes.Equal(spk.Data, []byte(memo)) {
+        t.Errorf("Invalid OP_RETURN script: %v", spk)
+    }
+    // binary data has no text
+    spk = ParseScriptPubkey([]byte{0x6a, 0x02, 0xff, 0x00})
+    if spk.Kind != ScriptKindOpReturn || spk.Text != "" || !bytes.Equal(spk.Data, []byte{0xff, 0x00}) {
+        t.Errorf("Invalid OP_RETURN script: %v", spk)
+    }
+    // truncated push
+    spk = ParseScriptPubkey([]byte{0x6a, 0x05, 0x41})
+    if spk.Kind != ScriptKindUnknown {
+        t.Errorf("Invalid script: %v", spk)
+    }
+}
+
+func TestChainTracker(t *testing.T) {
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    tip, _ := GetLatestHeight()
+    tracker, err := NewChainTracker([][]byte{vault}, "", 100, [][]byte {})
+    if err != nil {
+        t.Fatalf(`TestChainTracker = %v`, err)
+    }
+    defer tracker.Close()
+    events, err := tracker.Poll()
+    if err != nil {
+        t.Fatalf(`TestChainTracker = %v`, err)
+    }
+    // no block was mined since the tracker started at the tip
+    for _, e := range events {
+        if e.Kind == ChainEventKindBlockDisconnected || e.Height <= tip.Number {
+            t.Errorf("Unexpected event %v", e)
+        }
+    }
+}
+
+func TestBlockWalker(t *testing.T) {
+    vault, _ := hex.DecodeString("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+    walker, err := NewBlockWalker([][]byte{vault}, "", [][]byte {})
+    if err != nil {
+        t.Fatalf(`TestBlockWalker = %v`, err)
+    }
+    defer walker.Close()
+    var height uint32
+    for {
+        btxs, err := walker.Scan(100)
+        if err != nil {
+            t.Fatalf(`TestBlockWalker = %v`, err)
+        }
+        if btxs == nil {
+            break
+        }
+        height = btxs.EndHeight
+    }
+    // undo the last blocks and walk them again
+    err = walker.Rewind(height - 10)
+    if err != nil {
+        t.Fatalf(`TestBlockWalker = %v`, err)
+    }
+    btxs, err := walker.Scan(100)
+    if err != nil || btxs == nil || btxs.EndHeight != height {
+        t.Fatalf(`TestBlockWalker = %v, %v`, btxs, err)
+    }
+    // must agree with the address index
+    balance, _ := GetBalance("tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6")
+    walkerBalance, err := walker.Balance(vault)
+    if err != nil || walkerBalance != balance {
+        t.Errorf("Balance %v != %v, %v", walkerBalance, balance, err)
+    }
+    utxos, _ := ListUtxos("tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6")
+    walkerUtxos, _ := walker.Utxos(vault)
+    if len(walkerUtxos) != len(utxos) {
+        t.Errorf("UTXOs %v != %v", len(walkerUtxos), len(utxos))
+    }
+}
+
+func TestMayaMemo(t *testing.T) {
+    zec := mayamemo.Asset{Chain: "ZEC", Symbol: "ZEC"}
+    memo, err := BuildMayaMemo(mayamemo.SwapMemo{Asset: zec, Address: "tmP9jLgTnhDdKdWJCm4BT2t6acGnxqP14yU", Limit: 100000000})
+    if err != nil {
+        t.Fatalf(`TestMayaMemo = %v`, err)
+    }
+    if memo.Text != "SWAP:ZEC.ZEC:tmP9jLgTnhDdKdWJCm4BT2t6acGnxqP14yU:100000000" {
+        t.Errorf(`Unexpected memo %s`, memo.Text)
+    }
+    m, err := ParseMayaMemo(memo)
+    if err != nil {
+        t.Fatalf(`TestMayaMemo = %v`, err)
+    }
+    if m.(mayamemo.SwapMemo).Limit != 100000000 {
+        t.Errorf(`Unexpected swap %+v`, m)
+    }
+    _, err = ParseMayaMemo(TextMemo("=:ZEC.ZEC:t1invalidaddress"))
+    if !errors.Is(err, mayamemo.ErrInvalidAddress) {
+        t.Errorf(`The destination should be invalid: %v`, err)
+    }
+}
@@ -0,0 +1,282 @@
+// Package rpcfixture records the JSON-RPC traffic between the library
+// and zcashd to a fixture file, and replays it without any node.
+//
+// The transport is a local HTTP server that the library reaches
+// through ZCASHD_URL. In record mode it forwards every request to
+// the real node and keeps the replies. In replay mode it answers
+// from the fixture file.
+//
+// The request ids are random, so the replies are matched on the
+// method and the params. A request made several times gets the
+// recorded replies in order, then the last one again. A request
+// that was never recorded gets an error. The shielded transactions
+// are only the same from one run to the next with a fixed
+// rng_seed in the config of the library.
+package rpcfixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Mode of the transport, usually taken from MAYA_RPC_MODE
+type Mode string
+
+const (
+	// Live talks to the node directly, without the transport
+	Live Mode = ""
+	// Record forwards to the node and saves the replies
+	Record Mode = "record"
+	// Replay answers from the fixture file
+	Replay Mode = "replay"
+)
+
+const (
+	// ModeEnv selects the mode
+	ModeEnv = "MAYA_RPC_MODE"
+	// FixturesEnv overrides the path of the fixture file
+	FixturesEnv = "MAYA_RPC_FIXTURES"
+	// URLEnv is the node URL of the library config
+	URLEnv = "ZCASHD_URL"
+)
+
+// Entry is a request and its reply
+type Entry struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// Transport is the local server that records or replays
+type Transport struct {
+	mode     Mode
+	path     string
+	upstream string
+	server   *httptest.Server
+
+	mu      sync.Mutex
+	entries []Entry
+	used    []bool
+	byKey   map[string][]int
+}
+
+type request struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type reply struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  json.RawMessage `json:"error"`
+}
+
+// New starts a transport. In record mode, `upstream` is the URL
+// of the node. In replay mode, the fixture file must exist
+func New(mode Mode, path string, upstream string) (*Transport, error) {
+	t := &Transport{
+		mode:     mode,
+		path:     path,
+		upstream: upstream,
+		byKey:    map[string][]int{},
+	}
+	switch mode {
+	case Record:
+		if upstream == "" {
+			return nil, errors.New("rpcfixture: no node to record from")
+		}
+	case Replay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("rpcfixture: %w", err)
+		}
+		var entries []Entry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("rpcfixture: invalid fixture file %v: %w", path, err)
+		}
+		for _, e := range entries {
+			t.add(e)
+		}
+	default:
+		return nil, fmt.Errorf("rpcfixture: unknown mode %q", mode)
+	}
+	t.server = httptest.NewServer(t)
+	return t, nil
+}
+
+// Start reads the mode from MAYA_RPC_MODE and points ZCASHD_URL
+// at a new transport unless the mode is live. It must run before
+// the first call to the library, which reads its config once.
+// MAYA_RPC_FIXTURES overrides `path`. Returns nil in live mode
+func Start(path string) (*Transport, error) {
+	mode := Mode(os.Getenv(ModeEnv))
+	if mode == Live {
+		return nil, nil
+	}
+	if p := os.Getenv(FixturesEnv); p != "" {
+		path = p
+	}
+	t, err := New(mode, path, os.Getenv(URLEnv))
+	if err != nil {
+		return nil, err
+	}
+	os.Setenv(URLEnv, t.URL())
+	return t, nil
+}
+
+// URL of the transport
+func (t *Transport) URL() string {
+	return t.server.URL
+}
+
+// Close stops the server and, when recording, writes the fixture file
+func (t *Transport) Close() error {
+	t.server.Close()
+	if t.mode != Record {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	data, err := json.MarshalIndent(t.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(t.path, append(data, '\n'), 0o644)
+}
+
+func (t *Transport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if t.mode == Record {
+		t.forward(w, r, body, req)
+		return
+	}
+
+	rep := reply{ID: req.ID}
+	if e, ok := t.lookup(req); ok {
+		rep.Result = e.Result
+		rep.Error = e.Error
+	} else {
+		rep.Error, _ = json.Marshal(map[string]any{
+			"code":    -32601,
+			"message": "rpcfixture: no recorded reply for " + key(req.Method, req.Params),
+		})
+	}
+	if rep.Result == nil {
+		rep.Result = json.RawMessage("null")
+	}
+	if rep.Error == nil {
+		rep.Error = json.RawMessage("null")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rep)
+}
+
+// forward sends the request to the node with its credentials
+// and records the reply
+func (t *Transport) forward(w http.ResponseWriter, r *http.Request, body []byte, req request) {
+	up, err := http.NewRequest(http.MethodPost, t.upstream, bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	up.Header = r.Header.Clone()
+	resp, err := http.DefaultClient.Do(up)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	// zcashd replies with an error status to failed calls,
+	// they are recorded too
+	var rep reply
+	if err := json.Unmarshal(data, &rep); err == nil {
+		e := Entry{Method: req.Method, Params: req.Params, Result: rep.Result}
+		if !isNull(rep.Error) {
+			e.Error = rep.Error
+		}
+		t.mu.Lock()
+		t.add(e)
+		t.mu.Unlock()
+	}
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+	w.Write(data)
+}
+
+func (t *Transport) add(e Entry) {
+	i := len(t.entries)
+	t.entries = append(t.entries, e)
+	t.used = append(t.used, false)
+	k := key(e.Method, e.Params)
+	t.byKey[k] = append(t.byKey[k], i)
+}
+
+// lookup finds the reply of a request, see the package doc
+func (t *Transport) lookup(req request) (Entry, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if i, ok := t.next(t.byKey[key(req.Method, req.Params)]); ok {
+		return t.entries[i], true
+	}
+	return Entry{}, false
+}
+
+// next takes the first unused entry of the list, or the
+// last one if they are all used
+func (t *Transport) next(indices []int) (int, bool) {
+	if len(indices) == 0 {
+		return 0, false
+	}
+	for _, i := range indices {
+		if !t.used[i] {
+			t.used[i] = true
+			return i, true
+		}
+	}
+	return indices[len(indices)-1], true
+}
+
+// key is the method and the params without formatting
+// differences, so that equal requests match
+func key(method string, params json.RawMessage) string {
+	var v any
+	d := json.NewDecoder(bytes.NewReader(params))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil || v == nil {
+		v = []any{}
+	}
+	data, _ := json.Marshal(v)
+	return method + " " + string(data)
+}
+
+func isNull(v json.RawMessage) bool {
+	return len(v) == 0 || string(v) == "null"
+}
@@ -0,0 +1,151 @@
+package rpcfixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeNode counts the blocks and checks the credentials
+func fakeNode() *httptest.Server {
+	var height atomic.Int64
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _, ok := r.BasicAuth(); !ok || user != "mayachain" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req request
+		json.NewDecoder(r.Body).Decode(&req)
+		rep := map[string]any{"id": req.ID, "result": nil, "error": nil}
+		switch req.Method {
+		case "getblockcount":
+			rep["result"] = 100 + height.Load()
+		case "generate":
+			height.Add(1)
+			rep["result"] = []string{"hash"}
+		case "getblockhash":
+			var params []int
+			json.Unmarshal(req.Params, &params)
+			rep["result"] = params[0] * 2
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			rep["error"] = map[string]any{"code": -32601, "message": "Method not found"}
+		}
+		json.NewEncoder(w).Encode(rep)
+	}))
+}
+
+// call makes a request like the library, with a random id
+func call(t *testing.T, url string, id string, method string, params string) reply {
+	body := `{"jsonrpc":"1.0","id":"` + id + `","method":"` + method + `","params":` + params + `}`
+	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader([]byte(body)))
+	req.SetBasicAuth("mayachain", "password")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var rep reply
+	if err := json.NewDecoder(resp.Body).Decode(&rep); err != nil {
+		t.Fatal(err)
+	}
+	return rep
+}
+
+func TestRecordReplay(t *testing.T) {
+	node := fakeNode()
+	defer node.Close()
+	path := filepath.Join(t.TempDir(), "testdata", "fixtures.json")
+
+	rec, err := New(Record, path, node.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call(t, rec.URL(), "1", "getblockcount", `[]`)
+	call(t, rec.URL(), "2", "generate", `[1]`)
+	call(t, rec.URL(), "3", "getblockcount", `[]`)
+	call(t, rec.URL(), "4", "getblockhash", `[10]`)
+	call(t, rec.URL(), "5", "getblockhash", `[20]`)
+	call(t, rec.URL(), "6", "unknown", `[]`)
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := New(Replay, path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rep.Close()
+	tests := []struct {
+		method string
+		params string
+		result string
+		error  bool
+	}{
+		// the same request gets the replies in order
+		{"getblockcount", `[]`, "100", false},
+		{"getblockcount", `[]`, "101", false},
+		// then the last one again
+		{"getblockcount", `[]`, "101", false},
+		// params match without the formatting
+		{"getblockhash", `[ 20 ]`, "40", false},
+		// params that were never recorded fail
+		{"getblockhash", `[30]`, "null", true},
+		{"unknown", `[]`, "null", true},
+		{"getbalance", `[]`, "null", true},
+	}
+	for i, tt := range tests {
+		id := string(rune('a' + i))
+		r := call(t, rep.URL(), id, tt.method, tt.params)
+		if string(r.ID) != `"`+id+`"` {
+			t.Errorf("%v %v: id %s", tt.method, tt.params, r.ID)
+		}
+		if string(r.Result) != tt.result || isNull(r.Error) == tt.error {
+			t.Errorf("%v %v = %s, %s", tt.method, tt.params, r.Result, r.Error)
+		}
+	}
+}
+
+func TestMissingFixtures(t *testing.T) {
+	_, err := New(Replay, filepath.Join(t.TempDir(), "none.json"), "")
+	if err == nil {
+		t.Errorf("Replay without fixtures should fail")
+	}
+	_, err = New(Record, filepath.Join(t.TempDir(), "none.json"), "")
+	if err == nil {
+		t.Errorf("Record without node should fail")
+	}
+}
+
+func TestStart(t *testing.T) {
+	t.Setenv(ModeEnv, "")
+	t.Setenv(URLEnv, "http://127.0.0.1:18232")
+	tr, err := Start("fixtures.json")
+	if tr != nil || err != nil {
+		t.Errorf("Start = %v, %v", tr, err)
+	}
+	if os.Getenv(URLEnv) != "http://127.0.0.1:18232" {
+		t.Errorf("Live mode changed %v", URLEnv)
+	}
+
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	os.WriteFile(path, []byte(`[{"method":"getblockcount","params":[],"result":100}]`), 0o644)
+	t.Setenv(ModeEnv, string(Replay))
+	t.Setenv(FixturesEnv, path)
+	tr, err = Start("fixtures.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+	if os.Getenv(URLEnv) != tr.URL() {
+		t.Errorf("%v is not the transport", URLEnv)
+	}
+	if r := call(t, tr.URL(), "1", "getblockcount", `[]`); string(r.Result) != "100" {
+		t.Errorf("getblockcount = %s", r.Result)
+	}
+}
@@ -0,0 +1,84 @@
+package maya_zcash
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// pollInterval is how long Subscribe waits for a node notification
+// before it looks at the mempool and the tip anyway
+const pollInterval uint32 = 1000
+
+// Subscription delivers the vault events of Subscribe
+type Subscription struct {
+	events chan VaultEvent
+	mu     sync.Mutex
+	err    error
+}
+
+// Events returns the channel of the vault events. It is closed
+// when ctx is done or after a reorg deeper than 100 blocks.
+func (s *Subscription) Events() <-chan VaultEvent {
+	return s.events
+}
+
+// Err returns why the Events channel was closed: the error of ctx,
+// or ErrZcashErrorReorg after a reorg deeper than 100 blocks.
+// While the channel is open, it returns the error of the last
+// attempt to reach the node, which is retried, or nil.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Subscription) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Subscribe follows the mempool and the blocks for the vaults
+// and sends the changes of their transactions on the Events channel:
+// Mempool when a tx enters the mempool, Confirmed when it is mined and
+// Removed when it leaves the mempool unmined (double spend, expiry)
+// or when its block is orphaned, and Conflicted when another tx
+// (ConflictingTxid) double spends the inputs of an inbound deposit.
+// The first events are the vault txs already in the mempool.
+//
+// If "zmq" is set in the config, the node pushes its hashtx and
+// hashblock notifications; otherwise, or if ZMQ is unavailable,
+// the node is polled every second.
+//
+// Transfers to or from the knownVaults are reported as migrations.
+func Subscribe(ctx context.Context, pubkeys [][]byte, knownVaults [][]byte) (*Subscription, error) {
+	handle, err := SubscriptionNew(pubkeys, knownVaults)
+	if err != nil {
+		return nil, err
+	}
+	s := &Subscription{events: make(chan VaultEvent)}
+	go func() {
+		defer close(s.events)
+		defer SubscriptionClose(handle)
+		for ctx.Err() == nil {
+			batch, err := SubscriptionNext(handle, pollInterval)
+			s.setErr(err)
+			if errors.Is(err, ErrZcashErrorReorg) {
+				return
+			}
+			// other errors come from the node and we retry
+			// after the next wait
+			for _, e := range batch {
+				select {
+				case s.events <- e:
+				case <-ctx.Done():
+					s.setErr(ctx.Err())
+					return
+				}
+			}
+		}
+		s.setErr(ctx.Err())
+	}()
+	return s, nil
+}
@@ -1,4 +1,6 @@
 #!/bin/sh
 set -x
+go test ./maya_zcash/mayamemo
+go test ./maya_zcash/rpcfixture
 go test ./maya_zcash -c .
 ./maya_zcash.test
@@ -1,5 +1,8 @@
 #!/bin/sh
 set -x
+# the scans need the indexes of docs/zcash.conf
+mkdir -p regtest
+[ -f regtest/zcash.conf ] || cp docs/zcash.conf regtest/
 zcashd --datadir=regtest --daemon
 sleep 10
 zcash-cli --datadir=regtest generate 150
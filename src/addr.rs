@@ -9,17 +9,18 @@ use zcash_primitives::legacy::TransparentAddress;
 use crate::{uniffi_export, ZcashError};
 
 pub fn get_vault_address(pubkey: Vec<u8>) -> Result<String, ZcashError> {
-    let _ = PublicKey::from_slice(&pubkey).map_err(|_| ZcashError::InvalidVaultPubkey)?;
-    let taddr = uniffi_export!(context, {
-        let network = context.config.network();
-        let sha = sha2::Sha256::digest(&pubkey);
-        let pkh: [u8; 20] = ripemd::Ripemd160::digest(&sha).into();
-        let tkey = TransparentAddress::PublicKeyHash(pkh);
-        let taddr = zcash_client_backend::address::Address::Transparent(tkey);
-        let taddr = taddr.encode(&network);
-        taddr
-    });
-    Ok(taddr)
+    let network = uniffi_export!(context, { context.config.network() });
+    vault_address(&network, &pubkey)
+}
+
+/// Same as `get_vault_address` on the given network
+pub(crate) fn vault_address(network: &Network, pubkey: &[u8]) -> Result<String, ZcashError> {
+    let _ = PublicKey::from_slice(pubkey).map_err(|_| ZcashError::InvalidVaultPubkey)?;
+    let sha = sha2::Sha256::digest(pubkey);
+    let pkh: [u8; 20] = ripemd::Ripemd160::digest(&sha).into();
+    let tkey = TransparentAddress::PublicKeyHash(pkh);
+    let taddr = zcash_client_backend::address::Address::Transparent(tkey);
+    Ok(taddr.encode(network))
 }
 
 pub fn get_ovk(pubkey: Vec<u8>) -> Result<Vec<u8>, ZcashError> {
@@ -0,0 +1,469 @@
+use std::collections::HashSet;
+
+use anyhow::anyhow;
+use orchard::note_encryption::OrchardDomain;
+use sapling_crypto::note_encryption::{SaplingDomain, Zip212Enforcement};
+use zcash_keys::{address::UnifiedAddress, encoding::AddressCodec};
+use zcash_primitives::transaction::{components::TxOut, Transaction, TxId};
+use zcash_protocol::consensus::BranchId;
+
+use crate::{
+    addr::vault_address,
+    memo::Memo,
+    network::Network,
+    pay::Output,
+    scan::{ShieldedPool, TOut, TxInput, UndecryptedOutput, VaultKeys, VaultTx, VaultTxDecrypted},
+    script::{parse_script, script_address, ParsedScript},
+    to_ba, to_zcasherror, ZcashError,
+};
+
+/// Analyze a transaction from its raw bytes, without any node
+/// and without the context: it does not need `init`.
+/// `prevouts` are the outputs spent by the transparent inputs.
+/// The inputs that are not in `prevouts` have an unknown
+/// address and value. `ufvk` is the viewing key of the shielded
+/// deposits of the vault, empty if it has none.
+/// Returns None if the transaction does not involve the vault
+pub fn analyze_vault_tx(
+    network: Network,
+    pubkey: Vec<u8>,
+    raw_tx: Vec<u8>,
+    prevouts: Vec<TxInput>,
+    known_vaults: Vec<Vec<u8>>,
+    ufvk: String,
+) -> Result<Option<VaultTx>, ZcashError> {
+    let vault = VaultKeys::with_network(&network, pubkey, &ufvk)?;
+    let known_vaults = known_vaults
+        .iter()
+        .map(|pubkey| vault_address(&network, pubkey))
+        .collect::<Result<HashSet<_>, _>>()?;
+    let tx = read_tx(&raw_tx)?;
+    analyze_tx(&network, &tx, &prevouts, 0, &vault, &known_vaults)
+}
+
+pub(crate) fn analyze_tx(
+    network: &Network,
+    tx: &Transaction,
+    prevouts: &[TxInput],
+    height: u32,
+    vault: &VaultKeys,
+    known_vaults: &HashSet<String>,
+) -> Result<Option<VaultTx>, ZcashError> {
+    let ptouts = tx_inputs(tx)
+        .into_iter()
+        .map(|(txid, vout)| {
+            prevouts
+                .iter()
+                .find(|p| p.txid == txid && p.vout == vout)
+                .map(|p| TOut {
+                    address: p.address.clone(),
+                    value: p.value,
+                    memo: None,
+                })
+                .unwrap_or(TOut {
+                    address: String::new(),
+                    value: 0,
+                    memo: None,
+                })
+        })
+        .collect::<Vec<_>>();
+    let outputs = vault_outputs(network, tx, vault)?;
+    if !outputs.outputs.iter().any(|o| vault.owns(&o.address))
+        && !ptouts.iter().any(|o| o.address == vault.address)
+    {
+        return Ok(None);
+    }
+    let txd = VaultTxDecrypted {
+        txid: tx.txid().to_string(),
+        ptouts,
+        outputs: outputs.outputs,
+        undecrypted: outputs.undecrypted,
+        shielded_value: outputs.shielded_value,
+        value_balance: shielded_value_balance(tx),
+    };
+    VaultTx::from_decrypted(height, &txd, vault, known_vaults).map(Some)
+}
+
+pub(crate) fn read_tx(data: &[u8]) -> Result<Transaction, ZcashError> {
+    // the branch id is only used for the signature hashes
+    // of v4 transactions, that we do not check
+    Transaction::read(data, BranchId::Nu6)
+        .map_err(to_zcasherror(anyhow!("Cannot parse transaction")))
+}
+
+/// Outpoints (txid, vout) spent by the transparent inputs
+pub(crate) fn tx_inputs(tx: &Transaction) -> Vec<(String, u32)> {
+    tx.transparent_bundle()
+        .map(|b| {
+            b.vin
+                .iter()
+                .map(|tin| {
+                    (
+                        TxId::from_bytes(*tin.prevout.hash()).to_string(),
+                        tin.prevout.n(),
+                    )
+                })
+                .collect()
+        })
+        .unwrap_or_default()
+}
+
+pub(crate) fn tx_outputs(tx: &Transaction) -> &[TxOut] {
+    tx.transparent_bundle()
+        .map(|b| b.vout.as_slice())
+        .unwrap_or_default()
+}
+
+/// Address of a transparent output, empty if it is not
+/// a standard P2PKH/P2SH output
+pub(crate) fn tout_address(network: &Network, tout: &TxOut) -> String {
+    script_address(network, &tout.script_pubkey.0)
+}
+
+/// Net value going from the shielded pools to the transparent pool
+pub(crate) fn shielded_value_balance(tx: &Transaction) -> i64 {
+    tx.sapling_bundle()
+        .map(|b| i64::from(*b.value_balance()))
+        .unwrap_or_default()
+        + tx.orchard_bundle()
+            .map(|b| i64::from(*b.value_balance()))
+            .unwrap_or_default()
+}
+
+/// The outputs of a tx that we can read
+pub(crate) struct TxOutputs {
+    pub(crate) outputs: Vec<Output>,
+    // the shielded outputs that the ovk cannot recover
+    pub(crate) undecrypted: Vec<UndecryptedOutput>,
+    // value of the shielded outputs recovered with the ovk
+    pub(crate) shielded_value: u64,
+}
+
+/// The outputs of `decrypt_outputs` and the notes
+/// received with the viewing key of the vault
+pub(crate) fn vault_outputs(
+    network: &Network,
+    tx: &Transaction,
+    vault: &VaultKeys,
+) -> Result<TxOutputs, ZcashError> {
+    let mut outputs = decrypt_outputs(network, tx, to_ba(&vault.ovk)?);
+    if let Some(vk) = &vault.viewing_key {
+        outputs.outputs.extend(vk.decrypt_incoming(tx));
+    }
+    Ok(outputs)
+}
+
+/// The transparent outputs and the shielded outputs
+/// we can recover with the ovk
+pub(crate) fn decrypt_outputs(network: &Network, tx: &Transaction, ovk: [u8; 32]) -> TxOutputs {
+    let mut outputs = vec![];
+    let mut undecrypted = vec![];
+    let mut shielded_value = 0;
+
+    let mut tmemo = None;
+    for tout in tx_outputs(tx) {
+        let address = tout_address(network, tout);
+        if address.is_empty() {
+            match parse_script(&tout.script_pubkey.0) {
+                ParsedScript::OpReturn(memo) => tmemo = Some(Memo::from_op_return(memo)),
+                _ => continue,
+            }
+        }
+        outputs.push(Output {
+            address,
+            amount: tout.value.into_u64(),
+            memo: Memo::default(),
+        });
+    }
+    if let Some(tmemo) = tmemo {
+        for o in outputs.iter_mut() {
+            o.memo = tmemo.clone();
+        }
+    }
+
+    if let Some(bundle) = tx.sapling_bundle() {
+        let d = SaplingDomain::new(Zip212Enforcement::On);
+        let ovk = sapling_crypto::keys::OutgoingViewingKey(ovk);
+        for (i, output) in bundle.shielded_outputs().iter().enumerate() {
+            if let Some((note, address, memo)) = zcash_note_encryption::try_output_recovery_with_ovk(
+                &d,
+                &ovk,
+                output,
+                output.cv(),
+                output.out_ciphertext(),
+            ) {
+                let memo = Memo::from_memo_bytes(&memo);
+                shielded_value += note.value().inner();
+                outputs.push(Output {
+                    address: address.encode(network),
+                    amount: note.value().inner(),
+                    memo,
+                });
+            } else {
+                undecrypted.push(UndecryptedOutput {
+                    pool: ShieldedPool::Sapling,
+                    index: i as u32,
+                    cmx: output.cmu().to_bytes().to_vec(),
+                    cv: output.cv().to_bytes().to_vec(),
+                });
+            }
+        }
+    }
+
+    if let Some(bundle) = tx.orchard_bundle() {
+        let ovk = orchard::keys::OutgoingViewingKey::from(ovk);
+        for (i, action) in bundle.actions().iter().enumerate() {
+            let d = OrchardDomain::for_action(action);
+            if let Some((note, address, memo)) = zcash_note_encryption::try_output_recovery_with_ovk(
+                &d,
+                &ovk,
+                action,
+                action.cv_net(),
+                &action.encrypted_note().out_ciphertext,
+            ) {
+                let address = UnifiedAddress::from_receivers(Some(address), None, None).unwrap();
+                let memo = Memo::from_memo_bytes(&memo);
+                shielded_value += note.value().inner();
+                outputs.push(Output {
+                    address: address.encode(network),
+                    amount: note.value().inner(),
+                    memo,
+                });
+            } else {
+                undecrypted.push(UndecryptedOutput {
+                    pool: ShieldedPool::Orchard,
+                    index: i as u32,
+                    cmx: action.cmx().to_bytes().to_vec(),
+                    cv: action.cv_net().to_bytes().to_vec(),
+                });
+            }
+        }
+    }
+
+    TxOutputs {
+        outputs,
+        undecrypted,
+        shielded_value,
+    }
+}
+
+#[cfg(test)]
+pub(crate) mod tests {
+    use crate::scan::Direction;
+
+    use super::*;
+
+    // deposit of 0.1 ZEC with the memo "MEMO"
+    pub(crate) const DEPOSIT: &str = "050000800a27a7265510e7c800000000f00000000186b1a9c7f46c7550e48fa0781495ef891ed81b48506ef53a28c3e83a223f6482000000006a473044022014e4bc7f7ab7034fe1992ee128484e72864e7d08380b2e663b43c2d490aa26190220643a69a289195a62f9c85c208a27d417f847fd1ea94af086cce435ce6eb9f89f012103243597856d5bd7c8f91f77446a53db425ce10d237c1d6928f2268acdc538797effffffff030000000000000000066a044d454d4f80969800000000001976a914e6d4b9d2c408bf6bd44523b3b6607de4853b806088ace83c8e06000000001976a914936667ff8d2d41361a4df4a370b309fb15380eac88ac0000002024";
+    const VAULT_ADDR: &str = "tmWksakBYGg7Lqtm1EqSqvPkVYJHYxGq6Za";
+    const FROM_ADDR: &str = "tmP9jLgTnhDdKdWJCm4BT2t6acGnxqP14yU";
+
+    fn vault(address: &str) -> VaultKeys {
+        VaultKeys {
+            pubkey: vec![],
+            address: address.to_string(),
+            ovk: vec![0u8; 32],
+            viewing_key: None,
+        }
+    }
+
+    #[test]
+    fn test_analyze_deposit() {
+        let tx = read_tx(&hex::decode(DEPOSIT).unwrap()).unwrap();
+        let prevouts = vec![TxInput {
+            txid: "82643f223ae8c3283af56e50481bd81e89ef951478a08fe450756cf4c7a9b186".to_string(),
+            vout: 0,
+            address: FROM_ADDR.to_string(),
+            value: 120_000_000,
+        }];
+        let vtx = analyze_tx(
+            &Network::Regtest,
+            &tx,
+            &prevouts,
+            0,
+            &vault(VAULT_ADDR),
+            &HashSet::new(),
+        )
+        .unwrap()
+        .unwrap();
+        assert_eq!(
+            vtx.txid,
+            "d120e67dac6ccdb49915542544ae2673fd4aef6adc1fa4eac9012134c9f3ddd0"
+        );
+        assert!(matches!(vtx.direction, Direction::Incoming));
+        assert_eq!(vtx.counterparty.address, FROM_ADDR);
+        assert_eq!(vtx.counterparty.amount, 10_000_000);
+        assert_eq!(vtx.counterparty.memo, Memo::text("MEMO"));
+    }
+
+    #[test]
+    fn test_analyze_unrelated() {
+        let tx = read_tx(&hex::decode(DEPOSIT).unwrap()).unwrap();
+        let vtx = analyze_tx(
+            &Network::Regtest,
+            &tx,
+            &[],
+            0,
+            &vault("tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6"),
+            &HashSet::new(),
+        )
+        .unwrap();
+        assert!(vtx.is_none());
+    }
+
+    #[test]
+    fn test_analyze_without_context() {
+        // the vault tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6
+        let pubkey =
+            hex::decode("03c622fa3be76cd25180d5a61387362181caca77242023be11775134fd37f403f7")
+                .unwrap();
+        let vtx = analyze_vault_tx(
+            Network::Regtest,
+            pubkey.clone(),
+            hex::decode(DEPOSIT).unwrap(),
+            vec![],
+            vec![],
+            String::new(),
+        )
+        .unwrap();
+        assert!(vtx.is_none());
+
+        let known_vaults = vec![vec![1, 2, 3]];
+        let r = analyze_vault_tx(
+            Network::Regtest,
+            pubkey,
+            hex::decode(DEPOSIT).unwrap(),
+            vec![],
+            known_vaults,
+            String::new(),
+        );
+        assert!(matches!(r, Err(ZcashError::InvalidVaultPubkey)));
+    }
+
+    #[test]
+    fn test_spend_without_change() {
+        // the vault spends its only utxo to a single recipient
+        let txd = VaultTxDecrypted {
+            txid: "a".to_string(),
+            ptouts: vec![TOut {
+                address: VAULT_ADDR.to_string(),
+                value: 100_000_000,
+                memo: None,
+            }],
+            outputs: vec![Output {
+                address: FROM_ADDR.to_string(),
+                amount: 99_990_000,
+                memo: Memo::text("OUT"),
+            }],
+            undecrypted: vec![],
+            shielded_value: 0,
+            value_balance: 0,
+        };
+        let vtx = VaultTx::from_decrypted(0, &txd, &vault(VAULT_ADDR), &HashSet::new()).unwrap();
+        assert!(matches!(vtx.direction, Direction::Outgoing));
+        assert!(!vtx.incomplete);
+        assert_eq!(vtx.counterparty.address, FROM_ADDR);
+        assert_eq!(vtx.counterparty.amount, 99_990_000);
+        assert_eq!(vtx.counterparty.memo, Memo::text("OUT"));
+        assert_eq!(vtx.destinations.len(), 1);
+    }
+
+    #[test]
+    fn test_batched_payout() {
+        // the vault pays two recipients and gets the change back
+        let output = |address: &str, amount: u64| Output {
+            address: address.to_string(),
+            amount,
+            memo: Memo::default(),
+        };
+        let txd = VaultTxDecrypted {
+            txid: "a".to_string(),
+            ptouts: vec![TOut {
+                address: VAULT_ADDR.to_string(),
+                value: 100_000_000,
+                memo: None,
+            }],
+            outputs: vec![
+                output(FROM_ADDR, 10_000_000),
+                output("tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6", 20_000_000),
+                output(VAULT_ADDR, 69_985_000),
+            ],
+            undecrypted: vec![],
+            shielded_value: 0,
+            value_balance: 0,
+        };
+        let vtx = VaultTx::from_decrypted(0, &txd, &vault(VAULT_ADDR), &HashSet::new()).unwrap();
+        assert!(matches!(vtx.direction, Direction::Outgoing));
+        assert_eq!(vtx.counterparty.address, FROM_ADDR);
+        assert_eq!(vtx.destinations.len(), 2);
+        assert_eq!(vtx.destinations[1].amount, 20_000_000);
+
+        // the same payout to other vaults is a migration
+        let known_vaults = HashSet::from([
+            FROM_ADDR.to_string(),
+            "tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6".to_string(),
+        ]);
+        let vtx = VaultTx::from_decrypted(0, &txd, &vault(VAULT_ADDR), &known_vaults).unwrap();
+        assert!(matches!(vtx.direction, Direction::Migration));
+        assert_eq!(vtx.destinations.len(), 2);
+    }
+
+    // the vault spends 1 ZEC, gets 0.5 ZEC back and pays
+    // an Orchard recipient, with a dummy action
+    fn orchard_payment(shielded_value: u64) -> VaultTxDecrypted {
+        let action = |index: u32| UndecryptedOutput {
+            pool: ShieldedPool::Orchard,
+            index,
+            cmx: vec![index as u8; 32],
+            cv: vec![index as u8; 32],
+        };
+        let mut outputs = vec![Output {
+            address: VAULT_ADDR.to_string(),
+            amount: 50_000_000,
+            memo: Memo::default(),
+        }];
+        let mut undecrypted = vec![action(1)];
+        if shielded_value > 0 {
+            outputs.push(Output {
+                address: "uregtest1recipient".to_string(),
+                amount: shielded_value,
+                memo: Memo::text("OUT"),
+            });
+        } else {
+            undecrypted.insert(0, action(0));
+        }
+        VaultTxDecrypted {
+            txid: "a".to_string(),
+            ptouts: vec![TOut {
+                address: VAULT_ADDR.to_string(),
+                value: 100_000_000,
+                memo: None,
+            }],
+            outputs,
+            undecrypted,
+            shielded_value,
+            value_balance: -49_985_000,
+        }
+    }
+
+    #[test]
+    fn test_undecrypted_payment() {
+        let vault = vault(VAULT_ADDR);
+        let vtx = VaultTx::from_decrypted(0, &orchard_payment(0), &vault, &HashSet::new()).unwrap();
+        // not a consolidation
+        assert!(matches!(vtx.direction, Direction::Outgoing));
+        assert!(vtx.incomplete);
+        assert_eq!(vtx.undecrypted.len(), 2);
+        assert_eq!(vtx.counterparty.address, "");
+        assert_eq!(vtx.counterparty.amount, 49_985_000);
+        assert_eq!(vtx.shielded_value_balance, -49_985_000);
+
+        // only the dummy action is left
+        let vtx = VaultTx::from_decrypted(0, &orchard_payment(49_985_000), &vault, &HashSet::new())
+            .unwrap();
+        assert!(matches!(vtx.direction, Direction::Outgoing));
+        assert!(!vtx.incomplete);
+        assert_eq!(vtx.undecrypted.len(), 1);
+        assert_eq!(vtx.counterparty.memo, Memo::text("OUT"));
+    }
+}
@@ -0,0 +1,125 @@
+use std::collections::HashSet;
+
+use anyhow::Context as _;
+use futures::{stream, StreamExt as _, TryStreamExt as _};
+use serde::Deserialize;
+use serde_json::json;
+use uuid::Uuid;
+
+use crate::{
+    analyze::{analyze_tx, read_tx},
+    config::Config,
+    rpc::{json_request, map_rpc_error},
+    scan::{
+        fetch_prevouts, known_vault_addresses, tx_request_permit, VaultKeys, VaultTx,
+        MAX_CONCURRENT_TXS,
+    },
+    uniffi_async_export, ZcashError,
+};
+
+// Only the fields we need of the verbose getrawtransaction reply
+#[derive(Deserialize)]
+struct MinedTx {
+    hex: String,
+    height: Option<u32>,
+}
+
+/// Rebuild the history of a vault between `from_height` and
+/// `to_height` (included) from the address index of the node
+/// instead of scanning every block since the genesis.
+/// The txs keep the order of the address index, by height and
+/// position in their block. This relies on getaddresstxids being
+/// called with the vault address only: for several addresses
+/// zcashd sorts its reply by height and txid.
+/// Shielded deposits are not in the address index, use
+/// `scan_block_range` for the vaults with a viewing key
+pub fn backfill_vault(
+    pubkey: Vec<u8>,
+    from_height: u32,
+    to_height: u32,
+    known_vaults: Vec<Vec<u8>>,
+) -> Result<Vec<VaultTx>, ZcashError> {
+    if from_height == 0 || from_height > to_height {
+        return Err(ZcashError::AssertError(format!(
+            "Invalid height range {from_height}-{to_height}"
+        )));
+    }
+    let vault = VaultKeys::new(pubkey)?;
+    let known_vaults = known_vault_addresses(known_vaults)?;
+    uniffi_async_export!(context, {
+        let config = &context.config;
+        let txids = get_address_txids(config, &vault.address, from_height, to_height).await?;
+
+        let txs: Vec<Option<VaultTx>> = stream::iter(txids)
+            .map(|txid| backfill_tx(config, txid, &vault, &known_vaults))
+            .buffered(MAX_CONCURRENT_TXS)
+            .try_collect()
+            .await?;
+        Ok(txs.into_iter().flatten().collect())
+    })
+}
+
+async fn get_address_txids(
+    config: &Config,
+    address: &str,
+    start_height: u32,
+    end_height: u32,
+) -> Result<Vec<String>, ZcashError> {
+    let id = Uuid::new_v4().to_string();
+    let rep = json_request(
+        config,
+        &id,
+        "getaddresstxids",
+        vec![json!({
+            "addresses": [address],
+            "start": start_height,
+            "end": end_height
+        })],
+    )
+    .await
+    .map_err(map_rpc_error)?;
+    let txids: Vec<String> = serde_json::from_value(rep)
+        .context("Cannot parse getaddresstxids reply")
+        .map_err(map_rpc_error)?;
+    Ok(txids)
+}
+
+/// Classify a tx of the address index
+/// None if the tx is not mined anymore or does not concern the vault
+async fn backfill_tx(
+    config: &Config,
+    txid: String,
+    vault: &VaultKeys,
+    known_vaults: &HashSet<String>,
+) -> Result<Option<VaultTx>, ZcashError> {
+    let permit = tx_request_permit().await;
+    let id = Uuid::new_v4().to_string();
+    let rep = json_request(
+        config,
+        &id,
+        "getrawtransaction",
+        vec![txid.into(), 1.into()],
+    )
+    .await
+    .map_err(map_rpc_error)?;
+    // released before the prevouts take their own
+    drop(permit);
+    let mined: MinedTx = serde_json::from_value(rep)
+        .context("Cannot parse getrawtransaction reply")
+        .map_err(map_rpc_error)?;
+    let Some(height) = mined.height else {
+        return Ok(None);
+    };
+    let data = hex::decode(&mined.hex).context("Cannot parse getrawtransaction reply")?;
+    let tx = read_tx(&data)?;
+    let prevouts = fetch_prevouts(config, &tx).await?;
+    let vtx = analyze_tx(
+        &config.network(),
+        &tx,
+        &prevouts,
+        height,
+        vault,
+        known_vaults,
+    )?;
+    Ok(vtx)
+}
@@ -0,0 +1,143 @@
+use std::{
+    collections::{BTreeMap, HashMap},
+    sync::Arc,
+};
+
+use parking_lot::Mutex;
+use zcash_primitives::transaction::Transaction;
+
+use crate::uniffi_export;
+
+pub(crate) const DEFAULT_TX_CACHE_SIZE: usize = 10_000;
+
+/// Counters of the raw tx cache since the start
+/// or the last `set_tx_cache_size`
+pub struct TxCacheStats {
+    pub hits: u64,
+    pub misses: u64,
+    pub size: u32,
+    pub capacity: u32,
+}
+
+/// Least recently used cache, keyed by txid
+pub(crate) struct LruCache<V> {
+    capacity: usize,
+    tick: u64,
+    // value and time of last use
+    entries: HashMap<String, (V, u64)>,
+    // time of last use -> key
+    lru: BTreeMap<u64, String>,
+    hits: u64,
+    misses: u64,
+}
+
+impl<V: Clone> LruCache<V> {
+    pub(crate) fn new(capacity: usize) -> Self {
+        LruCache {
+            capacity,
+            tick: 0,
+            entries: HashMap::new(),
+            lru: BTreeMap::new(),
+            hits: 0,
+            misses: 0,
+        }
+    }
+
+    pub(crate) fn get(&mut self, key: &str) -> Option<V> {
+        self.tick += 1;
+        let Some((value, used)) = self.entries.get_mut(key) else {
+            self.misses += 1;
+            return None;
+        };
+        self.hits += 1;
+        let k = self.lru.remove(used).unwrap();
+        *used = self.tick;
+        self.lru.insert(self.tick, k);
+        Some(value.clone())
+    }
+
+    pub(crate) fn insert(&mut self, key: String, value: V) {
+        if self.capacity == 0 {
+            return;
+        }
+        self.tick += 1;
+        if let Some((_, used)) = self.entries.insert(key.clone(), (value, self.tick)) {
+            self.lru.remove(&used);
+        }
+        self.lru.insert(self.tick, key);
+        self.evict();
+    }
+
+    /// Resize and reset the counters
+    pub(crate) fn set_capacity(&mut self, capacity: usize) {
+        self.capacity = capacity;
+        self.hits = 0;
+        self.misses = 0;
+        self.evict();
+    }
+
+    fn evict(&mut self) {
+        while self.entries.len() > self.capacity {
+            let (_, key) = self.lru.pop_first().unwrap();
+            self.entries.remove(&key);
+        }
+    }
+
+    pub(crate) fn stats(&self) -> TxCacheStats {
+        TxCacheStats {
+            hits: self.hits,
+            misses: self.misses,
+            size: self.entries.len() as u32,
+            capacity: self.capacity as u32,
+        }
+    }
+}
+
+lazy_static::lazy_static! {
+    // parsed raw txs, see `fetch_raw_tx`
+    pub(crate) static ref TX_CACHE: Mutex<LruCache<Arc<Transaction>>> =
+        Mutex::new(LruCache::new(DEFAULT_TX_CACHE_SIZE));
+}
+
+/// Number of parsed raw txs kept in memory, 0 disables the cache
+/// The default comes from `tx_cache_size` in the config
+pub fn set_tx_cache_size(size: u32) {
+    uniffi_export!(_context, {
+        TX_CACHE.lock().set_capacity(size as usize);
+    })
+}
+
+pub fn get_tx_cache_stats() -> TxCacheStats {
+    uniffi_export!(_context, { TX_CACHE.lock().stats() })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_lru() {
+        let mut cache = LruCache::new(2);
+        cache.insert("a".into(), 1);
+        cache.insert("b".into(), 2);
+        assert_eq!(cache.get("a"), Some(1));
+        // b is the least recently used
+        cache.insert("c".into(), 3);
+        assert_eq!(cache.get("b"), None);
+        assert_eq!(cache.get("a"), Some(1));
+        assert_eq!(cache.get("c"), Some(3));
+        // updating a key does not grow the cache
+        cache.insert("c".into(), 4);
+        assert_eq!(cache.get("c"), Some(4));
+        let stats = cache.stats();
+        assert_eq!((stats.hits, stats.misses, stats.size), (4, 1, 2));
+
+        cache.set_capacity(1);
+        assert_eq!(cache.get("a"), None);
+        assert_eq!(cache.get("c"), Some(4));
+        cache.set_capacity(0);
+        cache.insert("d".into(), 5);
+        assert_eq!(cache.get("d"), None);
+        assert_eq!(cache.stats().size, 0);
+    }
+}
@@ -2,12 +2,16 @@ use std::{collections::HashMap, env, fs::File, io::Read, path::PathBuf};
 
 use anyhow::Result;
 use orchard::circuit::ProvingKey;
+use parking_lot::Mutex;
+use rand_chacha::ChaCha20Rng;
+use rand_core::{OsRng, SeedableRng};
+use rusqlite::Connection;
 use tokio::runtime::Runtime;
 
 use serde::Deserialize;
 use zcash_proofs::prover::LocalTxProver;
 
-use crate::network::Network;
+use crate::{network::Network, uniffi_export, viewing::VaultViewingKey};
 
 #[derive(Deserialize, Debug)]
 pub struct Server {
@@ -21,6 +25,14 @@ pub struct Config {
     pub server: Server,
     pub mainnet: bool,
     pub sapling_params_dir: String,
+    // zcashd -zmqpubhashtx/-zmqpubhashblock endpoint,
+    // for example tcp://127.0.0.1:28332
+    pub zmq: Option<String>,
+    // number of parsed raw txs kept in memory, 10000 by default
+    pub tx_cache_size: Option<usize>,
+    // fixed seed of the randomness of the txs, only to record
+    // and replay the tests, see `tx_rng`
+    pub rng_seed: Option<u64>,
 }
 
 impl Config {
@@ -38,6 +50,23 @@ pub struct Context {
     pub runtime: Runtime,
     pub sapling_prover: LocalTxProver,
     pub orchard_prover: ProvingKey,
+    // shielded deposit keys by vault address
+    pub viewing_keys: Mutex<HashMap<String, VaultViewingKey>>,
+    // optional persistent scan state, see `open_store`
+    pub store: Mutex<Option<Connection>>,
+    // seeded from `rng_seed`, if set
+    pub rng: Mutex<Option<ChaCha20Rng>>,
+}
+
+/// Randomness of a new tx, from the OS unless the config
+/// has a `rng_seed`
+pub(crate) fn tx_rng() -> ChaCha20Rng {
+    uniffi_export!(context, {
+        match context.rng.lock().as_mut() {
+            Some(rng) => ChaCha20Rng::from_rng(rng).unwrap(),
+            None => ChaCha20Rng::from_rng(OsRng).unwrap(),
+        }
+    })
 }
 
 pub fn read_config(name: &str) -> Result<Config> {
@@ -0,0 +1,326 @@
+use std::{collections::HashMap, sync::Arc};
+
+use anyhow::Context as _;
+use parking_lot::Mutex;
+use serde::Deserialize;
+use uuid::Uuid;
+
+use crate::{
+    config::Config,
+    rpc::{is_node_error, json_request, map_rpc_error},
+    scan::VaultTx,
+    uniffi_async_export, ZcashError,
+};
+
+/// Transactions of at least `min_amount` zats need
+/// `confirmations` confirmations
+#[derive(Clone, Debug)]
+pub struct ConfirmationTier {
+    pub min_amount: u64,
+    pub confirmations: u32,
+}
+
+/// Where a watched tx stands
+/// `height` and `block_hash` are 0 and empty while the tx is
+/// not in the best chain.
+pub struct TxConfirmation {
+    pub txid: String,
+    pub amount: u64,
+    pub height: u32,
+    pub block_hash: String,
+    pub confirmations: u32,
+    pub required: u32,
+    // mined in a block of the best chain
+    pub in_best_chain: bool,
+    // the block it was mined in was orphaned since the last poll
+    pub reorged: bool,
+    // `confirmations` reached `required` since the last poll
+    pub threshold_crossed: bool,
+}
+
+#[derive(Deserialize)]
+struct TxChainInfo {
+    #[serde(rename = "blockhash")]
+    block_hash: Option<String>,
+    // -1 if the block is not on the best chain
+    height: Option<i64>,
+    confirmations: Option<u32>,
+}
+
+struct WatchedTx {
+    txid: String,
+    amount: u64,
+    required: u32,
+    block_hash: Option<String>,
+    reached: bool,
+}
+
+impl WatchedTx {
+    /// Update with the chain info of the tx, None if the node
+    /// does not know the tx anymore
+    fn update(&mut self, info: Option<TxChainInfo>) -> TxConfirmation {
+        let (block_hash, height, confirmations) = match info {
+            // a tx in an orphaned block still has its blockhash
+            // but no confirmation
+            Some(TxChainInfo {
+                block_hash: Some(block_hash),
+                height: Some(height),
+                confirmations: Some(confirmations),
+            }) if confirmations > 0 && height > 0 => {
+                (Some(block_hash), height as u32, confirmations)
+            }
+            _ => (None, 0, 0),
+        };
+        let reorged = self.block_hash.is_some() && self.block_hash != block_hash;
+        let reached = confirmations >= self.required;
+        let threshold_crossed = reached && !self.reached;
+        self.block_hash = block_hash.clone();
+        self.reached = reached;
+
+        TxConfirmation {
+            txid: self.txid.clone(),
+            amount: self.amount,
+            height,
+            block_hash: block_hash.unwrap_or_default(),
+            confirmations,
+            required: self.required,
+            in_best_chain: confirmations > 0,
+            reorged,
+            threshold_crossed,
+        }
+    }
+}
+
+/// Follows the confirmations of transactions until
+/// they reach the number their amount requires
+pub struct ConfirmationTracker {
+    // by decreasing min_amount
+    tiers: Vec<ConfirmationTier>,
+    txs: Vec<WatchedTx>,
+}
+
+impl ConfirmationTracker {
+    fn new(mut tiers: Vec<ConfirmationTier>) -> Self {
+        tiers.sort_by(|a, b| b.min_amount.cmp(&a.min_amount));
+        ConfirmationTracker { tiers, txs: vec![] }
+    }
+
+    /// Amounts below every tier need a single confirmation
+    fn required_confirmations(&self, amount: u64) -> u32 {
+        self.tiers
+            .iter()
+            .find(|t| amount >= t.min_amount)
+            .map(|t| t.confirmations)
+            .unwrap_or(1)
+    }
+
+    fn watch(&mut self, txid: String, amount: u64) {
+        if self.txs.iter().any(|tx| tx.txid == txid) {
+            return;
+        }
+        let required = self.required_confirmations(amount);
+        self.txs.push(WatchedTx {
+            txid,
+            amount,
+            required,
+            block_hash: None,
+            reached: false,
+        });
+    }
+
+    async fn poll(&mut self, config: &Config) -> Result<Vec<TxConfirmation>, ZcashError> {
+        // fetch everything before updating any tx
+        let mut infos = vec![];
+        for tx in self.txs.iter() {
+            infos.push(get_tx_chain_info(config, &tx.txid).await?);
+        }
+        let confirmations = self
+            .txs
+            .iter_mut()
+            .zip(infos)
+            .map(|(tx, info)| tx.update(info))
+            .collect();
+        Ok(confirmations)
+    }
+}
+
+async fn get_tx_chain_info(config: &Config, txid: &str) -> Result<Option<TxChainInfo>, ZcashError> {
+    let id = Uuid::new_v4().to_string();
+    let rep = match json_request(
+        config,
+        &id,
+        "getrawtransaction",
+        vec![txid.into(), 1.into()],
+    )
+    .await
+    {
+        Ok(rep) => rep,
+        // the node dropped the tx: double spent or expired
+        Err(e) if is_node_error(&e, "No such mempool or blockchain transaction") => {
+            return Ok(None)
+        }
+        Err(e) => return Err(map_rpc_error(e)),
+    };
+    let info: TxChainInfo = serde_json::from_value(rep)
+        .context("Cannot parse getrawtransaction reply")
+        .map_err(map_rpc_error)?;
+    Ok(Some(info))
+}
+
+type SharedTracker = Arc<Mutex<ConfirmationTracker>>;
+
+lazy_static::lazy_static! {
+    static ref CONFIRMATION_TRACKERS: Mutex<(u64, HashMap<u64, SharedTracker>)> =
+        Mutex::new((0, HashMap::new()));
+}
+
+// Only the tracker is locked, not the other ones
+fn with_tracker<T>(
+    tracker: u64,
+    f: impl FnOnce(&mut ConfirmationTracker) -> T,
+) -> Result<T, ZcashError> {
+    let tracker = CONFIRMATION_TRACKERS
+        .lock()
+        .1
+        .get(&tracker)
+        .cloned()
+        .ok_or(ZcashError::AssertError(format!(
+            "Unknown confirmation tracker {tracker}"
+        )))?;
+    let mut tracker = tracker.lock();
+    Ok(f(&mut tracker))
+}
+
+/// Create a confirmation tracker. A tx needs the confirmations
+/// of the tier with the highest `min_amount` it reaches
+/// Returns the tracker handle
+pub fn confirmation_tracker_new(tiers: Vec<ConfirmationTier>) -> Result<u64, ZcashError> {
+    if tiers.iter().any(|t| t.confirmations == 0) {
+        return Err(ZcashError::AssertError(
+            "confirmations must be positive".into(),
+        ));
+    }
+    let tracker = ConfirmationTracker::new(tiers);
+    let mut trackers = CONFIRMATION_TRACKERS.lock();
+    trackers.0 += 1;
+    let handle = trackers.0;
+    trackers.1.insert(handle, Arc::new(Mutex::new(tracker)));
+    Ok(handle)
+}
+
+/// Watch a tx, its amount decides how many confirmations it needs
+pub fn confirmation_tracker_watch(
+    tracker: u64,
+    txid: String,
+    amount: u64,
+) -> Result<(), ZcashError> {
+    with_tracker(tracker, |t| t.watch(txid, amount))
+}
+
+/// Watch the txs returned by the scanners by the amount
+/// of their counterparty
+pub fn confirmation_tracker_watch_vault_txs(
+    tracker: u64,
+    txs: Vec<VaultTx>,
+) -> Result<(), ZcashError> {
+    with_tracker(tracker, |t| {
+        for tx in txs {
+            t.watch(tx.txid, tx.counterparty.amount);
+        }
+    })
+}
+
+pub fn confirmation_tracker_unwatch(tracker: u64, txid: String) -> Result<(), ZcashError> {
+    with_tracker(tracker, |t| t.txs.retain(|tx| tx.txid != txid))
+}
+
+/// Report every watched tx, in the order they were added
+pub fn confirmation_tracker_poll(tracker: u64) -> Result<Vec<TxConfirmation>, ZcashError> {
+    with_tracker(tracker, |t| {
+        uniffi_async_export!(context, { t.poll(&context.config).await })
+    })?
+}
+
+pub fn confirmation_tracker_close(tracker: u64) {
+    CONFIRMATION_TRACKERS.lock().1.remove(&tracker);
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn tiers() -> Vec<ConfirmationTier> {
+        vec![
+            ConfirmationTier {
+                min_amount: 0,
+                confirmations: 2,
+            },
+            ConfirmationTier {
+                min_amount: 1_000_000_000,
+                confirmations: 10,
+            },
+            ConfirmationTier {
+                min_amount: 100_000_000,
+                confirmations: 5,
+            },
+        ]
+    }
+
+    fn mined(block_hash: &str, height: i64, confirmations: u32) -> Option<TxChainInfo> {
+        Some(TxChainInfo {
+            block_hash: Some(block_hash.to_string()),
+            height: Some(height),
+            confirmations: Some(confirmations),
+        })
+    }
+
+    #[test]
+    fn test_tiers() {
+        let tracker = ConfirmationTracker::new(tiers());
+        assert_eq!(tracker.required_confirmations(1_000), 2);
+        assert_eq!(tracker.required_confirmations(100_000_000), 5);
+        assert_eq!(tracker.required_confirmations(999_999_999), 5);
+        assert_eq!(tracker.required_confirmations(5_000_000_000), 10);
+        assert_eq!(
+            ConfirmationTracker::new(vec![]).required_confirmations(1),
+            1
+        );
+    }
+
+    #[test]
+    fn test_confirmations() {
+        let mut tracker = ConfirmationTracker::new(tiers());
+        tracker.watch("a".into(), 1_000);
+        tracker.watch("a".into(), 1_000);
+        assert_eq!(tracker.txs.len(), 1);
+        let tx = &mut tracker.txs[0];
+
+        // in the mempool
+        let c = tx.update(Some(TxChainInfo {
+            block_hash: None,
+            height: None,
+            confirmations: None,
+        }));
+        assert!(!c.in_best_chain && !c.threshold_crossed && c.height == 0);
+
+        let c = tx.update(mined("h100", 100, 1));
+        assert!(c.in_best_chain && !c.threshold_crossed);
+        assert_eq!(c.height, 100);
+        let c = tx.update(mined("h100", 100, 2));
+        assert!(c.threshold_crossed);
+        let c = tx.update(mined("h100", 100, 3));
+        assert!(!c.threshold_crossed && c.confirmations == 3);
+
+        // its block is orphaned and it is mined again
+        let c = tx.update(mined("h100", -1, 0));
+        assert!(c.reorged && !c.in_best_chain && c.block_hash.is_empty());
+        let c = tx.update(mined("h103", 103, 1));
+        assert!(!c.reorged && c.height == 103);
+        let c = tx.update(mined("h103", 103, 2));
+        assert!(c.threshold_crossed);
+
+        // dropped by the node
+        let c = tx.update(None);
+        assert!(c.reorged && c.confirmations == 0);
+    }
+}
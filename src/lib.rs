@@ -1,15 +1,31 @@
 pub mod addr;
+pub mod analyze;
+pub mod backfill;
+pub mod cache;
 pub mod chain;
 pub mod config;
+pub mod confirm;
+pub mod memo;
 pub mod network;
+pub mod notify;
 pub mod pay;
 pub mod rpc;
 pub mod scan;
+pub mod script;
+pub mod shielded;
+pub mod store;
+pub mod tracker;
+pub mod viewing;
+pub mod walker;
 pub mod wallet;
 
+use std::collections::HashMap;
+
 use config::{build_provers, Context};
 use orchard::circuit::ProvingKey;
-use parking_lot::ReentrantMutex;
+use parking_lot::{Mutex, ReentrantMutex};
+use rand_chacha::ChaCha20Rng;
+use rand_core::SeedableRng as _;
 use thiserror::Error;
 use tokio::runtime::Runtime;
 use tracing_subscriber::layer::SubscriberExt as _;
@@ -53,11 +69,18 @@ fn init() -> Context {
     let config = config::read_config("config.yaml").expect("Missing config.yaml");
     let runtime = Runtime::new().unwrap();
     let prover = build_provers(&config);
+    cache::TX_CACHE
+        .lock()
+        .set_capacity(config.tx_cache_size.unwrap_or(cache::DEFAULT_TX_CACHE_SIZE));
+    let rng_seed = config.rng_seed;
     let context = Context {
         config,
         runtime,
         sapling_prover: prover,
         orchard_prover: ProvingKey::build(),
+        viewing_keys: Mutex::new(HashMap::new()),
+        store: Mutex::new(None),
+        rng: Mutex::new(rng_seed.map(ChaCha20Rng::seed_from_u64)),
     };
     context
 }
@@ -71,12 +94,44 @@ pub fn init_logger() {
 
 use crate::addr::{get_ovk, get_vault_address, match_with_blockchain_receiver, validate_address,
     best_recipient_of_ua, make_ua};
+use crate::analyze::analyze_vault_tx;
+use crate::backfill::backfill_vault;
+use crate::cache::{get_tx_cache_stats, set_tx_cache_size, TxCacheStats};
 use crate::chain::{broadcast_raw_tx, get_latest_height};
+use crate::confirm::{
+    confirmation_tracker_close, confirmation_tracker_new, confirmation_tracker_poll,
+    confirmation_tracker_unwatch, confirmation_tracker_watch,
+    confirmation_tracker_watch_vault_txs, ConfirmationTier, TxConfirmation,
+};
+use crate::memo::{Memo, MemoKind};
+use crate::network::Network;
+use crate::notify::{
+    subscription_close, subscription_new, subscription_next, VaultEvent, VaultEventKind,
+};
 use crate::pay::{
-    apply_signatures, combine_vault, combine_vault_utxos,
-    pay_from_vault, send_to_vault, sign_sighash, Output, PartialTx, Sighashes, TxBytes,
+    apply_deposit_signatures, apply_signatures, build_deposit_to_vault,
+    build_deposit_to_vault_from, combine_vault, combine_vault_utxos, pay_from_vault,
+    send_to_vault, send_to_vault_from, sign_sighash, Output, PartialTx, Sighashes, TxBytes,
+};
+use crate::script::{parse_script_pubkey, ScriptKind, ScriptPubKey};
+use crate::shielded::{send_shielded_to_vault, ShieldedNote};
+use crate::scan::{
+    get_vault_tx_detail, scan_block_range, scan_block_range_detail, scan_blocks,
+    scan_blocks_multi, scan_mempool, scan_mempool_multi, BlockTxDetails, BlockTxs, Direction, ShieldedPool, TxInput,
+    UndecryptedOutput, VaultTx, VaultTxDetail,
+};
+use crate::store::{
+    close_store, open_store, store_get_balance, store_get_cursor, store_list_txs,
+    store_list_utxos, store_rewind, store_scan, StoredUTXO,
+};
+use crate::tracker::{
+    chain_tracker_close, chain_tracker_new, chain_tracker_poll, ChainEvent, ChainEventKind,
+};
+use crate::viewing::{get_vault_deposit_address, set_vault_viewing_key};
+use crate::walker::{
+    block_walker_balance, block_walker_close, block_walker_new, block_walker_rewind,
+    block_walker_scan, block_walker_utxos,
 };
-use crate::scan::{scan_blocks, scan_mempool, BlockTxs, Direction, VaultTx};
 use crate::wallet::{get_balance, list_utxos, sk_to_pub, TransparentKey, UTXO};
 
 uniffi::include_scaffolding!("interface");
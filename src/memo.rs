@@ -0,0 +1,203 @@
+use std::str::FromStr as _;
+
+use anyhow::{anyhow, bail, Result};
+use zcash_protocol::memo::{Memo as ZcashMemo, MemoBytes};
+
+/// Size limit of the OP_RETURN memo of a transparent tx
+pub(crate) const MAX_OP_RETURN_LEN: usize = 80;
+
+#[derive(Clone, Copy, Debug, PartialEq)]
+pub enum MemoKind {
+    Text,
+    Arbitrary,
+    // ZIP-302 memos with a reserved first byte
+    Future,
+}
+
+/// The memo of an output, empty if `kind` is None
+/// `text` is only set for Text memos. `data` has the UTF-8 bytes
+/// of Text memos, the payload of Arbitrary memos (without the 0xFF
+/// tag) and the raw bytes of Future memos. The zero padding of
+/// shielded memos is removed
+#[derive(Clone, Default, Debug, PartialEq)]
+pub struct Memo {
+    pub kind: Option<MemoKind>,
+    pub text: String,
+    pub data: Vec<u8>,
+}
+
+impl Memo {
+    pub(crate) fn text(text: &str) -> Self {
+        Memo {
+            kind: Some(MemoKind::Text),
+            text: text.to_string(),
+            data: text.as_bytes().to_vec(),
+        }
+    }
+
+    pub(crate) fn arbitrary(data: Vec<u8>) -> Self {
+        Memo {
+            kind: Some(MemoKind::Arbitrary),
+            text: String::new(),
+            data,
+        }
+    }
+
+    pub(crate) fn is_empty(&self) -> bool {
+        self.kind.is_none()
+    }
+
+    /// Size of the memo in an OP_RETURN output
+    pub(crate) fn len(&self) -> usize {
+        match self.kind {
+            None => 0,
+            Some(MemoKind::Text) => self.text.len(),
+            Some(MemoKind::Arbitrary | MemoKind::Future) => self.data.len(),
+        }
+    }
+
+    /// The memo of a transparent tx is text if the OP_RETURN data
+    /// is valid UTF-8
+    pub(crate) fn from_op_return(data: Vec<u8>) -> Self {
+        if data.is_empty() {
+            return Memo::default();
+        }
+        match String::from_utf8(data) {
+            Ok(text) => Memo::text(&text),
+            Err(e) => Memo::arbitrary(e.into_bytes()),
+        }
+    }
+
+    /// The data of the OP_RETURN output, empty if there is no memo
+    pub(crate) fn to_op_return(&self) -> Result<Vec<u8>> {
+        let data = match self.kind {
+            None => return Ok(vec![]),
+            Some(MemoKind::Text) => self.text.as_bytes().to_vec(),
+            Some(MemoKind::Arbitrary) => self.data.clone(),
+            Some(MemoKind::Future) => bail!("Future memos can only be sent to shielded addresses"),
+        };
+        if data.len() > MAX_OP_RETURN_LEN {
+            bail!(
+                "Memo too long: {} bytes, transparent memos are limited to {MAX_OP_RETURN_LEN}",
+                data.len()
+            );
+        }
+        Ok(data)
+    }
+
+    /// Decode the memo of a Sapling or Orchard note
+    /// Text memos that are not valid UTF-8 are kept as Future memos
+    pub(crate) fn from_memo_bytes(memo: &[u8; 512]) -> Self {
+        let memo = MemoBytes::from_bytes(memo).unwrap();
+        match ZcashMemo::try_from(memo.clone()) {
+            Ok(ZcashMemo::Empty) => Memo::default(),
+            Ok(ZcashMemo::Text(text)) => Memo::text(&text),
+            Ok(ZcashMemo::Arbitrary(data)) => {
+                let len = data.iter().rposition(|&b| b != 0).map_or(0, |p| p + 1);
+                Memo::arbitrary(data[..len].to_vec())
+            }
+            Ok(ZcashMemo::Future(_)) | Err(_) => Memo {
+                kind: Some(MemoKind::Future),
+                text: String::new(),
+                data: memo.as_slice().to_vec(),
+            },
+        }
+    }
+
+    /// Encode the memo of a Sapling or Orchard output
+    /// None if there is no memo
+    pub(crate) fn to_memo_bytes(&self) -> Result<Option<[u8; 512]>> {
+        let memo = match self.kind {
+            None => return Ok(None),
+            Some(MemoKind::Text) => {
+                let memo = ZcashMemo::from_str(&self.text)
+                    .map_err(|_| anyhow!("Memo too long: {} bytes", self.text.len()))?;
+                MemoBytes::try_from(memo).unwrap()
+            }
+            Some(MemoKind::Arbitrary) => {
+                if self.data.len() > 511 {
+                    bail!("Memo too long: {} bytes", self.data.len());
+                }
+                let mut data = vec![0xFF];
+                data.extend_from_slice(&self.data);
+                MemoBytes::from_bytes(&data).unwrap()
+            }
+            Some(MemoKind::Future) => {
+                if !matches!(self.data.first(), Some(0xF5..=0xFE)) {
+                    bail!("Future memos must start with a byte between 0xF5 and 0xFE");
+                }
+                MemoBytes::from_bytes(&self.data)
+                    .map_err(|_| anyhow!("Memo too long: {} bytes", self.data.len()))?
+            }
+        };
+        Ok(Some(*memo.as_array()))
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn roundtrip(memo: &Memo) -> Memo {
+        Memo::from_memo_bytes(&memo.to_memo_bytes().unwrap().unwrap_or_else(|| {
+            let mut empty = [0u8; 512];
+            empty[0] = 0xF6;
+            empty
+        }))
+    }
+
+    #[test]
+    fn test_shielded_memos() {
+        let text = Memo::text("=:ETH.ETH:0x9d2a2ea4ff4d2d8d7fc7bd2b8f1dab8e8b4a3f4c");
+        assert_eq!(roundtrip(&text), text);
+        assert_eq!(roundtrip(&text).data, text.text.as_bytes());
+        assert_eq!(roundtrip(&Memo::default()), Memo::default());
+        let bin = Memo::arbitrary(vec![0xde, 0xad, 0x00, 0xbe, 0xef]);
+        assert_eq!(roundtrip(&bin), bin);
+        let future = Memo {
+            kind: Some(MemoKind::Future),
+            text: String::new(),
+            data: vec![0xF5, 1, 2, 3],
+        };
+        assert_eq!(roundtrip(&future), future);
+
+        // shielded memos go up to 512 bytes
+        assert!(Memo::text(&"a".repeat(512)).to_memo_bytes().is_ok());
+        assert!(Memo::text(&"a".repeat(513)).to_memo_bytes().is_err());
+        assert!(Memo::arbitrary(vec![1; 511]).to_memo_bytes().is_ok());
+        assert!(Memo::arbitrary(vec![1; 512]).to_memo_bytes().is_err());
+        let text_prefix = Memo {
+            kind: Some(MemoKind::Future),
+            text: String::new(),
+            data: b"abc".to_vec(),
+        };
+        assert!(text_prefix.to_memo_bytes().is_err());
+
+        // the text range with invalid UTF-8 is kept as is
+        let mut invalid = [0u8; 512];
+        invalid[..2].copy_from_slice(&[0x41, 0xC3]);
+        let memo = Memo::from_memo_bytes(&invalid);
+        assert_eq!(memo.kind, Some(MemoKind::Future));
+        assert_eq!(memo.data, vec![0x41, 0xC3]);
+    }
+
+    #[test]
+    fn test_transparent_memos() {
+        assert_eq!(Memo::from_op_return(b"MEMO".to_vec()), Memo::text("MEMO"));
+        assert_eq!(Memo::from_op_return(vec![]), Memo::default());
+        // text memos are also kept as bytes
+        assert_eq!(Memo::text("MEMO").data, b"MEMO");
+        let bin = Memo::from_op_return(vec![0xff, 0x00]);
+        assert_eq!(bin, Memo::arbitrary(vec![0xff, 0x00]));
+        assert_eq!(bin.to_op_return().unwrap(), vec![0xff, 0x00]);
+
+        assert!(Memo::text(&"a".repeat(80)).to_op_return().is_ok());
+        assert!(Memo::text(&"a".repeat(81)).to_op_return().is_err());
+        let future = Memo {
+            kind: Some(MemoKind::Future),
+            text: String::new(),
+            data: vec![0xF5],
+        };
+        assert!(future.to_op_return().is_err());
+    }
+}
@@ -0,0 +1,653 @@
+use std::{
+    collections::{HashMap, HashSet},
+    sync::{
+        atomic::{AtomicBool, Ordering},
+        mpsc::{self, Receiver, RecvTimeoutError, Sender},
+        Arc,
+    },
+    time::Duration,
+};
+
+use anyhow::Context as _;
+use parking_lot::Mutex;
+use serde::Deserialize;
+use serde_json::json;
+use uuid::Uuid;
+use zeromq::{Socket as _, SocketRecv as _, SubSocket};
+
+use crate::{
+    analyze::tx_inputs,
+    config::Config,
+    rpc::{is_node_error, json_request, map_rpc_error},
+    scan::{
+        fetch_raw_tx, get_mempool_txids, known_vault_addresses, process_mempool_tx, Direction,
+        VaultKeys, VaultTx,
+    },
+    tracker::{BlockSource as _, ChainEvent, ChainEventKind, ChainTracker, RpcBlockSource},
+    uniffi_async_export, ZcashError,
+};
+
+// Reorgs deeper than this end the subscription
+const SUBSCRIPTION_DEPTH: usize = 100;
+
+#[derive(Clone, Copy)]
+pub enum VaultEventKind {
+    Mempool,
+    Confirmed,
+    Removed,
+    // an inbound deposit was evicted by a double spend
+    Conflicted,
+}
+
+/// A change of status of a vault tx
+/// `height` and `hash` are the block of a confirmed tx, or the
+/// orphaned block of a removed tx. They are 0 and empty for
+/// txs that enter or leave the mempool.
+/// `conflicting_txid` is the tx that spent the inputs of a
+/// `Conflicted` deposit, empty for the other kinds
+pub struct VaultEvent {
+    pub kind: VaultEventKind,
+    pub height: u32,
+    pub hash: String,
+    pub tx: VaultTx,
+    pub conflicting_txid: String,
+}
+
+/// Vault txs of a mempool tx
+#[derive(Clone)]
+struct PendingTx {
+    vtxs: Vec<VaultTx>,
+    // outpoints spent by an inbound deposit
+    outpoints: Vec<(String, u32)>,
+}
+
+/// Follows the mempool and the blocks for a set of vaults
+/// ZMQ notifications from the node only tell the subscription
+/// when to look: the vault txs come from the address index
+/// like with `scan_mempool` and `scan_blocks`
+struct Subscription {
+    vaults: Vec<VaultKeys>,
+    known_vaults: HashSet<String>,
+    tracker: ChainTracker,
+    // vault txs in the mempool, by txid
+    pending: HashMap<String, PendingTx>,
+    // None when the node has no ZMQ endpoint: we poll
+    wakeup: Option<Receiver<()>>,
+    closed: Arc<AtomicBool>,
+}
+
+impl Subscription {
+    /// Block until the node has something new or the timeout expires
+    fn wait(&mut self, timeout: Duration) {
+        let Some(wakeup) = &self.wakeup else {
+            std::thread::sleep(timeout);
+            return;
+        };
+        match wakeup.recv_timeout(timeout) {
+            Ok(()) => {
+                // a burst of notifications only needs one update
+                while wakeup.try_recv().is_ok() {}
+            }
+            Err(RecvTimeoutError::Timeout) => {}
+            Err(RecvTimeoutError::Disconnected) => {
+                tracing::warn!("Lost the ZMQ notifications, falling back to polling");
+                self.wakeup = None;
+                std::thread::sleep(timeout);
+            }
+        }
+    }
+
+    async fn step(&mut self, config: &Config) -> Result<Vec<VaultEvent>, ZcashError> {
+        // take the mempool before polling the blocks so that a tx
+        // mined in between is seen as confirmed and not as removed
+        let txids = get_mempool_txids(config, &self.vaults).await?;
+        let mut mempool = HashMap::new();
+        for txid in txids {
+            let ptx = match self.pending.get(&txid) {
+                Some(ptx) => ptx.clone(),
+                None => {
+                    let vtxs =
+                        process_mempool_tx(config, &txid, &self.vaults, &self.known_vaults).await?;
+                    let outpoints = if vtxs
+                        .iter()
+                        .any(|vtx| matches!(vtx.direction, Direction::Incoming))
+                    {
+                        tx_inputs(&fetch_raw_tx(config, &txid).await?)
+                    } else {
+                        vec![]
+                    };
+                    PendingTx { vtxs, outpoints }
+                }
+            };
+            mempool.insert(txid, ptx);
+        }
+
+        // the deposits that left the mempool may have been
+        // double spent, by a mempool tx or a block tx
+        let mut conflicts = HashMap::new();
+        for (txid, ptx) in self.pending.iter() {
+            if mempool.contains_key(txid) {
+                continue;
+            }
+            for (ptxid, vout) in ptx.outpoints.iter() {
+                match get_spender(config, ptxid, *vout).await? {
+                    Some(spender) if &spender != txid => {
+                        conflicts.insert(txid.clone(), spender);
+                        break;
+                    }
+                    _ => {}
+                }
+            }
+        }
+
+        let chain_events = self.tracker.poll(&RpcBlockSource { config }).await?;
+        Ok(self.update(mempool, chain_events, conflicts))
+    }
+
+    fn update(
+        &mut self,
+        mut mempool: HashMap<String, PendingTx>,
+        chain_events: Vec<ChainEvent>,
+        conflicts: HashMap<String, String>,
+    ) -> Vec<VaultEvent> {
+        let mut events = vec![];
+        let mut confirmed = HashSet::new();
+        for e in chain_events {
+            let kind = match e.kind {
+                ChainEventKind::BlockConnected => VaultEventKind::Confirmed,
+                ChainEventKind::BlockDisconnected => VaultEventKind::Removed,
+            };
+            for tx in e.txs {
+                if matches!(kind, VaultEventKind::Confirmed) {
+                    self.pending.remove(&tx.txid);
+                    confirmed.insert(tx.txid.clone());
+                }
+                events.push(VaultEvent {
+                    kind,
+                    height: e.height,
+                    hash: e.hash.clone(),
+                    tx,
+                    conflicting_txid: String::new(),
+                });
+            }
+        }
+
+        // the mempool may still have the txs of the new blocks
+        mempool.retain(|txid, _| !confirmed.contains(txid));
+        let dropped = self
+            .pending
+            .keys()
+            .filter(|txid| !mempool.contains_key(*txid))
+            .cloned()
+            .collect::<Vec<_>>();
+        for txid in dropped {
+            let conflicting_txid = conflicts.get(&txid);
+            let kind = match conflicting_txid {
+                Some(_) => VaultEventKind::Conflicted,
+                None => VaultEventKind::Removed,
+            };
+            for tx in self.pending.remove(&txid).unwrap().vtxs {
+                events.push(VaultEvent {
+                    kind,
+                    height: 0,
+                    hash: String::new(),
+                    tx,
+                    conflicting_txid: conflicting_txid.cloned().unwrap_or_default(),
+                });
+            }
+        }
+        for (txid, ptx) in mempool {
+            if self.pending.contains_key(&txid) {
+                continue;
+            }
+            for tx in ptx.vtxs.iter() {
+                events.push(VaultEvent {
+                    kind: VaultEventKind::Mempool,
+                    height: 0,
+                    hash: String::new(),
+                    tx: tx.clone(),
+                    conflicting_txid: String::new(),
+                });
+            }
+            self.pending.insert(txid, ptx);
+        }
+        events
+    }
+}
+
+#[derive(Deserialize)]
+struct SpentInfo {
+    txid: String,
+}
+
+/// The tx that spends an outpoint, from the mempool or the blocks
+async fn get_spender(config: &Config, txid: &str, vout: u32) -> Result<Option<String>, ZcashError> {
+    let id = Uuid::new_v4().to_string();
+    let rep = match json_request(
+        config,
+        &id,
+        "getspentinfo",
+        vec![json!({
+            "txid": txid,
+            "index": vout
+        })],
+    )
+    .await
+    {
+        Ok(rep) => rep,
+        // the node returns this error if the outpoint is unspent
+        Err(e) if is_node_error(&e, "Unable to get spent info") => return Ok(None),
+        Err(e) => return Err(map_rpc_error(e)),
+    };
+    let info: SpentInfo = serde_json::from_value(rep)
+        .context("Cannot parse getspentinfo reply")
+        .map_err(map_rpc_error)?;
+    Ok(Some(info.txid))
+}
+
+/// Forward the ZMQ notifications of the node until
+/// the subscription closes. The receiver is disconnected
+/// if the node cannot be reached
+fn start_listener(endpoint: String, closed: Arc<AtomicBool>) -> Receiver<()> {
+    let (tx, rx) = mpsc::channel();
+    std::thread::spawn(move || {
+        let runtime = tokio::runtime::Builder::new_current_thread()
+            .enable_all()
+            .build()
+            .unwrap();
+        if let Err(e) = runtime.block_on(listen(&endpoint, &tx, &closed)) {
+            tracing::warn!("ZMQ notifications unavailable at {endpoint}: {e}");
+        }
+    });
+    rx
+}
+
+async fn listen(endpoint: &str, tx: &Sender<()>, closed: &AtomicBool) -> anyhow::Result<()> {
+    let mut socket = SubSocket::new();
+    socket.connect(endpoint).await?;
+    for topic in ["hashblock", "hashtx"] {
+        socket.subscribe(topic).await?;
+    }
+    while !closed.load(Ordering::Relaxed) {
+        // wake up now and then to check if we are closed
+        if let Ok(message) = tokio::time::timeout(Duration::from_secs(1), socket.recv()).await {
+            let message = message?;
+            if let Some(topic) = message.get(0) {
+                tracing::debug!("ZMQ {}", String::from_utf8_lossy(topic));
+            }
+            if tx.send(()).is_err() {
+                break;
+            }
+        }
+    }
+    Ok(())
+}
+
+// The closed flag is kept outside of the subscription lock,
+// that `subscription_next` holds while it waits
+type SubscriptionEntry = (Arc<AtomicBool>, Arc<Mutex<Subscription>>);
+
+lazy_static::lazy_static! {
+    static ref SUBSCRIPTIONS: Mutex<(u64, HashMap<u64, SubscriptionEntry>)> =
+        Mutex::new((0, HashMap::new()));
+}
+
+/// Subscribe to the vault txs from the tip
+/// The node notifications are used if `zmq` is in the config
+/// Returns the subscription handle
+pub fn subscription_new(
+    pubkeys: Vec<Vec<u8>>,
+    known_vaults: Vec<Vec<u8>>,
+) -> Result<u64, ZcashError> {
+    let vaults = VaultKeys::from_pubkeys(pubkeys)?;
+    let known_vaults = known_vault_addresses(known_vaults)?;
+    let closed = Arc::new(AtomicBool::new(false));
+    let (tracker, wakeup) = uniffi_async_export!(context, {
+        let source = RpcBlockSource {
+            config: &context.config,
+        };
+        let height = source.tip_height().await?;
+        let hash = source.block_hash(height).await?;
+        let tracker = ChainTracker::new(
+            vaults.clone(),
+            known_vaults.clone(),
+            height,
+            hash,
+            SUBSCRIPTION_DEPTH,
+        );
+        let wakeup = context
+            .config
+            .zmq
+            .clone()
+            .map(|endpoint| start_listener(endpoint, closed.clone()));
+        Ok::<_, ZcashError>((tracker, wakeup))
+    })?;
+
+    let subscription = Subscription {
+        vaults,
+        known_vaults,
+        tracker,
+        pending: HashMap::new(),
+        wakeup,
+        closed: closed.clone(),
+    };
+    let mut subscriptions = SUBSCRIPTIONS.lock();
+    subscriptions.0 += 1;
+    let handle = subscriptions.0;
+    subscriptions
+        .1
+        .insert(handle, (closed, Arc::new(Mutex::new(subscription))));
+    Ok(handle)
+}
+
+/// Wait for a notification, or at most `timeout_ms`, and
+/// return what changed since the previous call.
+/// The first call reports the vault txs already in the mempool
+pub fn subscription_next(
+    subscription: u64,
+    timeout_ms: u32,
+) -> Result<Vec<VaultEvent>, ZcashError> {
+    let subscription = SUBSCRIPTIONS
+        .lock()
+        .1
+        .get(&subscription)
+        .map(|(_, subscription)| subscription.clone())
+        .ok_or(ZcashError::AssertError(format!(
+            "Unknown subscription {subscription}"
+        )))?;
+    let mut subscription = subscription.lock();
+    // do not hold the context while we wait
+    subscription.wait(Duration::from_millis(timeout_ms as u64));
+    // closed while we waited
+    if subscription.closed.load(Ordering::Relaxed) {
+        return Ok(vec![]);
+    }
+    uniffi_async_export!(context, { subscription.step(&context.config).await })
+}
+
+/// Release the subscription, without waiting for a
+/// `subscription_next` in progress
+pub fn subscription_close(subscription: u64) {
+    if let Some((closed, _)) = SUBSCRIPTIONS.lock().1.remove(&subscription) {
+        closed.store(true, Ordering::Relaxed);
+    }
+}
+
+#[cfg(test)]
+pub(crate) mod tests {
+    use std::{
+        io::{BufRead as _, BufReader, Read as _, Write as _},
+        net::TcpListener,
+    };
+
+    use serde_json::Value;
+    use zeromq::{PubSocket, SocketSend as _, ZmqMessage};
+
+    use crate::{analyze::tests::DEPOSIT, config::Server, pay::Output};
+
+    use super::*;
+
+    fn vault_tx(txid: &str, height: u32) -> VaultTx {
+        VaultTx {
+            height,
+            txid: txid.to_string(),
+            vault: vec![],
+            counterparty: Output::default(),
+            direction: Direction::Incoming,
+            destinations: vec![],
+            undecrypted: vec![],
+            incomplete: false,
+            shielded_value_balance: 0,
+        }
+    }
+
+    fn mempool(txids: &[&str]) -> HashMap<String, PendingTx> {
+        txids
+            .iter()
+            .map(|txid| {
+                let ptx = PendingTx {
+                    vtxs: vec![vault_tx(txid, 0)],
+                    outpoints: vec![(format!("funding-{txid}"), 0)],
+                };
+                (txid.to_string(), ptx)
+            })
+            .collect()
+    }
+
+    fn block(kind: ChainEventKind, height: u32, txids: &[&str]) -> ChainEvent {
+        ChainEvent {
+            kind,
+            height,
+            hash: format!("h{height}"),
+            txs: txids.iter().map(|txid| vault_tx(txid, height)).collect(),
+        }
+    }
+
+    fn summary(events: Vec<VaultEvent>) -> Vec<(char, String, u32)> {
+        let mut summary = events
+            .into_iter()
+            .map(|e| {
+                let kind = match e.kind {
+                    VaultEventKind::Mempool => 'm',
+                    VaultEventKind::Confirmed => 'c',
+                    VaultEventKind::Removed => 'r',
+                    VaultEventKind::Conflicted => 'x',
+                };
+                (kind, e.tx.txid, e.height)
+            })
+            .collect::<Vec<_>>();
+        // the mempool events are in no particular order
+        summary.sort();
+        summary
+    }
+
+    #[test]
+    fn test_update() {
+        let mut subscription = Subscription {
+            vaults: vec![],
+            known_vaults: HashSet::new(),
+            tracker: ChainTracker::new(vec![], HashSet::new(), 0, String::new(), 1),
+            pending: HashMap::new(),
+            wakeup: None,
+            closed: Arc::new(AtomicBool::new(false)),
+        };
+
+        let events = subscription.update(mempool(&["a", "b"]), vec![], HashMap::new());
+        assert_eq!(
+            summary(events),
+            vec![('m', "a".into(), 0), ('m', "b".into(), 0)]
+        );
+        assert!(subscription
+            .update(mempool(&["a", "b"]), vec![], HashMap::new())
+            .is_empty());
+
+        // "a" is mined while we take the mempool: it must not
+        // come back as a mempool tx. "b" expires
+        let events = subscription.update(
+            mempool(&["a"]),
+            vec![block(ChainEventKind::BlockConnected, 5, &["a"])],
+            HashMap::new(),
+        );
+        assert_eq!(
+            summary(events),
+            vec![('c', "a".into(), 5), ('r', "b".into(), 0)]
+        );
+        assert!(subscription
+            .update(mempool(&[]), vec![], HashMap::new())
+            .is_empty());
+
+        // the block of "a" is orphaned and "a" goes back to the mempool
+        let events = subscription.update(
+            mempool(&["a"]),
+            vec![block(ChainEventKind::BlockDisconnected, 5, &["a"])],
+            HashMap::new(),
+        );
+        assert_eq!(
+            summary(events),
+            vec![('m', "a".into(), 0), ('r', "a".into(), 5)]
+        );
+
+        // "c" spends the funding of "a"
+        let conflicts = HashMap::from([("a".to_string(), "c".to_string())]);
+        let events = subscription.update(mempool(&[]), vec![], conflicts);
+        assert_eq!(events.len(), 1);
+        assert!(matches!(events[0].kind, VaultEventKind::Conflicted));
+        assert_eq!(events[0].tx.txid, "a");
+        assert_eq!(events[0].conflicting_txid, "c");
+    }
+
+    /// A JSON-RPC node that answers every request with `rpc`
+    /// Returns its URL
+    pub(crate) fn fake_node(
+        rpc: impl Fn(&str, &Value) -> Result<Value, String> + Send + 'static,
+    ) -> String {
+        let listener = TcpListener::bind("127.0.0.1:0").unwrap();
+        let address = listener.local_addr().unwrap();
+        std::thread::spawn(move || {
+            for stream in listener.incoming() {
+                let mut stream = stream.unwrap();
+                let mut reader = BufReader::new(stream.try_clone().unwrap());
+                let mut length = 0;
+                loop {
+                    let mut line = String::new();
+                    if reader.read_line(&mut line).unwrap() == 0 || line == "\r\n" {
+                        break;
+                    }
+                    if let Some(v) = line.to_ascii_lowercase().strip_prefix("content-length:") {
+                        length = v.trim().parse().unwrap();
+                    }
+                }
+                let mut body = vec![0u8; length];
+                reader.read_exact(&mut body).unwrap();
+                let req: Value = serde_json::from_slice(&body).unwrap();
+                let rep = match rpc(req["method"].as_str().unwrap(), &req["params"]) {
+                    Ok(result) => json!({"result": result, "error": null, "id": req["id"]}),
+                    Err(message) => json!({
+                        "result": null,
+                        "error": {"code": -5, "message": message},
+                        "id": req["id"]
+                    }),
+                };
+                let rep = rep.to_string();
+                write!(
+                    stream,
+                    "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\
+                    Content-Length: {}\r\nConnection: close\r\n\r\n{rep}",
+                    rep.len()
+                )
+                .unwrap();
+            }
+        });
+        format!("http://{address}")
+    }
+
+    #[test]
+    fn test_conflict() {
+        const VAULT_ADDR: &str = "tmWksakBYGg7Lqtm1EqSqvPkVYJHYxGq6Za";
+        const TXID: &str = "d120e67dac6ccdb49915542544ae2673fd4aef6adc1fa4eac9012134c9f3ddd0";
+        // (deposit in the mempool, getspentinfo reply of its input)
+        let state = Arc::new(Mutex::new((true, Err::<Value, _>(String::new()))));
+        let node = {
+            let state = state.clone();
+            fake_node(move |method, _params| {
+                let state = state.lock();
+                let (in_mempool, spent_info) = &*state;
+                match method {
+                    "getaddressmempool" if *in_mempool => Ok(json!([{
+                        "address": VAULT_ADDR,
+                        "txid": TXID,
+                        "index": 1,
+                        "satoshis": 10_000_000,
+                        "timestamp": 0
+                    }])),
+                    "getaddressmempool" => Ok(json!([])),
+                    // the deposit and its parent
+                    "getrawtransaction" => Ok(json!(DEPOSIT)),
+                    "getspentinfo" => spent_info.clone(),
+                    "getblockcount" => Ok(json!(10)),
+                    "getblockhash" => Ok(json!("h10")),
+                    _ => Err(format!("Unexpected {method}")),
+                }
+            })
+        };
+        let config = Config {
+            server: Server {
+                host: node,
+                user: String::new(),
+                password: String::new(),
+            },
+            mainnet: false,
+            sapling_params_dir: String::new(),
+            zmq: None,
+            tx_cache_size: None,
+            rng_seed: None,
+        };
+        let vault = VaultKeys {
+            pubkey: vec![],
+            address: VAULT_ADDR.to_string(),
+            ovk: vec![0u8; 32],
+            viewing_key: None,
+        };
+        let mut subscription = Subscription {
+            vaults: vec![vault.clone()],
+            known_vaults: HashSet::new(),
+            tracker: ChainTracker::new(vec![vault], HashSet::new(), 10, "h10".to_string(), 1),
+            pending: HashMap::new(),
+            wakeup: None,
+            closed: Arc::new(AtomicBool::new(false)),
+        };
+        let runtime = tokio::runtime::Runtime::new().unwrap();
+        let mut step = |in_mempool: bool, spent_info: Result<Value, String>| {
+            *state.lock() = (in_mempool, spent_info);
+            runtime.block_on(subscription.step(&config)).map(summary)
+        };
+
+        let unspent = || Err("Unable to get spent info".to_string());
+        assert_eq!(step(true, unspent()).unwrap(), vec![('m', TXID.into(), 0)]);
+        // the deposit leaves the mempool but the node fails:
+        // nothing is reported yet
+        assert!(matches!(
+            step(false, Err("Database error".to_string())),
+            Err(ZcashError::RPC(_))
+        ));
+        // its input is unspent: it expired
+        assert_eq!(step(false, unspent()).unwrap(), vec![('r', TXID.into(), 0)]);
+
+        // it comes back and its input is spent by another tx
+        assert_eq!(step(true, unspent()).unwrap(), vec![('m', TXID.into(), 0)]);
+        let spent = Ok(json!({"txid": "c0ffee", "index": 0, "height": 11}));
+        assert_eq!(step(false, spent).unwrap(), vec![('x', TXID.into(), 0)]);
+    }
+
+    #[test]
+    fn test_zmq_listener() {
+        let runtime = tokio::runtime::Runtime::new().unwrap();
+        let mut publisher = PubSocket::new();
+        let endpoint = runtime
+            .block_on(publisher.bind("tcp://127.0.0.1:0"))
+            .unwrap();
+        let closed = Arc::new(AtomicBool::new(false));
+        let wakeup = start_listener(endpoint.to_string(), closed.clone());
+
+        // the subscriber may join late, keep publishing
+        let mut received = false;
+        for _ in 0..50 {
+            runtime
+                .block_on(publisher.send(ZmqMessage::from("hashtx")))
+                .unwrap();
+            if wakeup.recv_timeout(Duration::from_millis(100)).is_ok() {
+                received = true;
+                break;
+            }
+        }
+        assert!(received);
+
+        // the listener stops once the subscription is closed
+        closed.store(true, Ordering::Relaxed);
+        let start = std::time::Instant::now();
+        while wakeup.recv_timeout(Duration::from_millis(100)) != Err(RecvTimeoutError::Disconnected)
+        {
+            assert!(start.elapsed() < Duration::from_secs(5));
+        }
+    }
+}
@@ -1,11 +1,10 @@
-use std::{
-    cmp::{max, min},
-    str::FromStr as _,
-};
+use std::cmp::{max, min};
+use std::collections::HashSet;
 
 use anyhow::anyhow;
 use orchard::{builder::BundleType, bundle::Flags, keys::OutgoingViewingKey, value::NoteValue};
-use rand_core::{OsRng, RngCore, SeedableRng};
+use rand_chacha::ChaCha20Rng;
+use rand_core::{RngCore, SeedableRng};
 use sapling_crypto::{note_encryption::Zip212Enforcement, Anchor};
 use secp256k1::{ecdsa::Signature, All, PublicKey, Secp256k1, SecretKey};
 use zcash_keys::{
@@ -26,15 +25,16 @@ use zcash_primitives::{
 use zcash_proofs::prover::LocalTxProver;
 use zcash_protocol::{
     consensus::{BlockHeight, BranchId},
-    memo::{Memo, MemoBytes},
     value::{ZatBalance as Amount, Zatoshis},
 };
 
 use crate::{
     addr::{get_ovk, get_vault_address, validate_address},
-    config::Context,
+    config::{tx_rng, Context},
+    memo::Memo,
+    network::Network,
     to_ba, to_hash, to_zcasherror, uniffi_async_export, uniffi_export,
-    wallet::UTXO,
+    wallet::{TransparentKey, UTXO},
     ZcashError,
 };
 
@@ -51,7 +51,7 @@ pub fn send_to_vault(
     from: String,
     vault: Vec<u8>,
     amount: u64,
-    memo: String,
+    memo: Memo,
 ) -> Result<TxBytes, ZcashError> {
     uniffi_async_export!(context, {
         // user inputs should be checked
@@ -64,18 +64,14 @@ pub fn send_to_vault(
         let to_addr = get_vault_address(vault)?;
         Zatoshis::from_u64(amount)
             .map_err(to_zcasherror(anyhow!("Invalid amount: {amount} zats")))?;
-        if memo.len() > 80 {
-            return Err(ZcashError::AssertError(
-                anyhow!("Memo too long: {memo}").to_string(),
-            ));
-        }
+        memo.to_op_return()?;
         let utxos = crate::wallet::list_utxos_async(&context, from.clone()).await?;
         let (inputs, change, _) = select_utxos(&utxos, amount, &memo)?;
 
+        let inputs = inputs.into_iter().map(|utxo| (sk, utxo)).collect();
         let txb = pay_with_utxos(
             &context,
             expiry_height,
-            sk,
             inputs,
             from,
             to_addr,
@@ -87,19 +83,277 @@ pub fn send_to_vault(
     })?
 }
 
+/// Same as `send_to_vault` but the funds may come from several
+/// transparent addresses of the user. Each source must have its
+/// secret key. The change goes to `change_address`
+pub fn send_to_vault_from(
+    expiry_height: u32,
+    sources: Vec<TransparentKey>,
+    change_address: String,
+    vault: Vec<u8>,
+    amount: u64,
+    memo: Memo,
+) -> Result<TxBytes, ZcashError> {
+    uniffi_async_export!(context, {
+        let network = context.config.network();
+        let mut sks = vec![];
+        for source in sources.iter() {
+            let sk = SecretKey::from_slice(&source.sk)
+                .map_err(to_zcasherror(anyhow!("Invalid Secret Key")))?;
+            let secp = Secp256k1::<All>::new();
+            let pk = PublicKey::from_secret_key(&secp, &sk);
+            check_source_address(&source.addr, &pk.serialize())?;
+            sks.push(sk);
+        }
+        let (utxos, owners) = list_sources_utxos(&context, &sources).await?;
+        let (inputs, change) =
+            prepare_deposit(&network, &change_address, vault.clone(), &utxos, amount, &memo)?;
+        let to_addr = get_vault_address(vault)?;
+        // select_utxos picks a prefix of the utxo list
+        let inputs = inputs
+            .into_iter()
+            .zip(owners.into_iter())
+            .map(|(utxo, owner)| (sks[owner], utxo))
+            .collect();
+
+        let txb = pay_with_utxos(
+            &context,
+            expiry_height,
+            inputs,
+            change_address,
+            to_addr,
+            amount,
+            change,
+            memo,
+        );
+        Ok::<_, ZcashError>(txb)
+    })?
+}
+
+/// External signer version of `send_to_vault_from`.
+/// The secret keys of the sources are not used and can be empty.
+/// The sighashes must be signed by the owner of the corresponding
+/// input and passed to `apply_deposit_signatures`
+pub fn build_deposit_to_vault_from(
+    height: u32,
+    sources: Vec<TransparentKey>,
+    change_address: String,
+    vault: Vec<u8>,
+    amount: u64,
+    memo: Memo,
+) -> Result<PartialTx, ZcashError> {
+    uniffi_async_export!(context, {
+        let network = context.config.network();
+        for source in sources.iter() {
+            PublicKey::from_slice(&source.pk)
+                .map_err(to_zcasherror(anyhow!("Invalid Public Key")))?;
+            check_source_address(&source.addr, &source.pk)?;
+        }
+        let (utxos, _) = list_sources_utxos(&context, &sources).await?;
+        let (inputs, change) =
+            prepare_deposit(&network, &change_address, vault.clone(), &utxos, amount, &memo)?;
+        let fee = inputs.iter().map(|i| i.value).sum::<u64>() - amount - change;
+        let to_addr = get_vault_address(vault)?;
+        let mut outputs = vec![];
+        outputs.push(Output {
+            address: to_addr,
+            amount,
+            memo,
+        });
+        outputs.push(Output {
+            address: change_address,
+            amount: change,
+            memo: Memo::default(),
+        });
+        let mut tx_seed = [0u8; 32];
+        tx_rng().fill_bytes(&mut tx_seed);
+        let mut partial_tx = PartialTx {
+            height,
+            inputs,
+            outputs,
+            fee,
+            tx_seed: tx_seed.to_vec(),
+            sighashes: Sighashes::default(),
+        };
+        let input_keys = resolve_input_keys(&network, &sources, &partial_tx.inputs)?;
+        let ovk = get_ovk(sources[0].pk.clone())?;
+        build_sighashes(&input_keys, ovk, &mut partial_tx)?;
+
+        Ok::<_, ZcashError>(partial_tx)
+    })
+}
+
+/// Finalize a partial tx built by `build_deposit_to_vault_from`
+pub fn apply_deposit_signatures(
+    sources: Vec<TransparentKey>,
+    ptx: PartialTx,
+    signatures: Vec<Vec<u8>>,
+) -> Result<Vec<u8>, ZcashError> {
+    uniffi_export!(context, {
+        let network = context.config.network();
+        let input_keys = resolve_input_keys(&network, &sources, &ptx.inputs)?;
+        let first_source = sources
+            .first()
+            .ok_or(ZcashError::AssertError("No source".into()))?;
+        let ovk = get_ovk(first_source.pk.clone())?;
+        finalize_tx(&context, &input_keys, ovk, ptx, signatures)
+    })
+}
+
+fn check_source_address(address: &str, pubkey: &[u8]) -> Result<(), ZcashError> {
+    // the address derivation is the same for vaults and users
+    let pubkey_address = get_vault_address(pubkey.to_vec())?;
+    if pubkey_address != address {
+        return Err(ZcashError::InvalidAddress(address.to_string()));
+    }
+    Ok(())
+}
+
+/// Concatenate the utxos of every source
+/// Returns the utxos and the index of the source of each of them
+async fn list_sources_utxos(
+    context: &Context,
+    sources: &[TransparentKey],
+) -> Result<(Vec<UTXO>, Vec<usize>), ZcashError> {
+    if sources.is_empty() {
+        return Err(ZcashError::AssertError("No source".into()));
+    }
+    let mut seen = HashSet::new();
+    if let Some(source) = sources.iter().find(|s| !seen.insert(&s.addr)) {
+        return Err(ZcashError::AssertError(format!(
+            "Duplicate source {}",
+            source.addr
+        )));
+    }
+    let mut utxos = vec![];
+    let mut owners = vec![];
+    for (i, source) in sources.iter().enumerate() {
+        let source_utxos = crate::wallet::list_utxos_async(context, source.addr.clone()).await?;
+        owners.extend(std::iter::repeat(i).take(source_utxos.len()));
+        utxos.extend(source_utxos);
+    }
+    Ok((utxos, owners))
+}
+
+/// Check the deposit parameters and select the utxos
+/// Returns the selected inputs and the change
+fn prepare_deposit(
+    network: &Network,
+    change_address: &str,
+    vault: Vec<u8>,
+    utxos: &[UTXO],
+    amount: u64,
+    memo: &Memo,
+) -> Result<(Vec<UTXO>, u64), ZcashError> {
+    TransparentAddress::decode(network, change_address)
+        .map_err(|_| ZcashError::InvalidAddress(change_address.to_string()))?;
+    get_vault_address(vault)?;
+    Zatoshis::from_u64(amount)
+        .map_err(to_zcasherror(anyhow!("Invalid amount: {amount} zats")))?;
+    memo.to_op_return()?;
+    let (inputs, change, _) = select_utxos(utxos, amount, memo)?;
+    Ok((inputs, change))
+}
+
+/// Find the public key that owns each input by matching
+/// the input script with the P2PKH script of the sources
+fn resolve_input_keys(
+    network: &Network,
+    sources: &[TransparentKey],
+    inputs: &[UTXO],
+) -> Result<Vec<Vec<u8>>, ZcashError> {
+    let mut scripts = vec![];
+    for source in sources.iter() {
+        let taddr = TransparentAddress::decode(network, &source.addr)
+            .map_err(|_| ZcashError::InvalidAddress(source.addr.clone()))?;
+        scripts.push(hex::encode(&taddr.script().0));
+    }
+    inputs
+        .iter()
+        .map(|i| {
+            scripts
+                .iter()
+                .position(|s| *s == i.script)
+                .map(|p| sources[p].pk.clone())
+                .ok_or(ZcashError::AssertError(format!(
+                    "No source for input {}:{}",
+                    i.txid, i.vout
+                )))
+        })
+        .collect()
+}
+
+/// Same as `send_to_vault` but for users who sign with an external
+/// signer. Returns the sighashes of the inputs, that must be signed
+/// and passed to `apply_signatures` with `from_pubkey`
+pub fn build_deposit_to_vault(
+    height: u32,
+    from_address: String,
+    from_pubkey: Vec<u8>,
+    vault: Vec<u8>,
+    amount: u64,
+    memo: Memo,
+) -> Result<PartialTx, ZcashError> {
+    uniffi_async_export!(context, {
+        // user inputs should be checked
+        PublicKey::from_slice(&from_pubkey)
+            .map_err(to_zcasherror(anyhow!("Invalid Public Key")))?;
+        // the address derivation is the same for vaults and users
+        let pubkey_address = get_vault_address(from_pubkey.clone())?;
+        if pubkey_address != from_address {
+            return Err(ZcashError::InvalidAddress(from_address.clone()));
+        }
+        let to_addr = get_vault_address(vault)?;
+        Zatoshis::from_u64(amount)
+            .map_err(to_zcasherror(anyhow!("Invalid amount: {amount} zats")))?;
+        memo.to_op_return()?;
+        let utxos = crate::wallet::list_utxos_async(&context, from_address.clone()).await?;
+        let (inputs, change, fee) = select_utxos(&utxos, amount, &memo)?;
+        let mut outputs = vec![];
+        outputs.push(Output {
+            address: to_addr,
+            amount,
+            memo,
+        });
+        outputs.push(Output {
+            address: from_address,
+            amount: change,
+            memo: Memo::default(),
+        });
+        let mut tx_seed = [0u8; 32];
+        tx_rng().fill_bytes(&mut tx_seed);
+        let mut partial_tx = PartialTx {
+            height,
+            inputs,
+            outputs,
+            fee,
+            tx_seed: tx_seed.to_vec(),
+            sighashes: Sighashes::default(),
+        };
+        let input_keys = vec![from_pubkey.clone(); partial_tx.inputs.len()];
+        build_sighashes(&input_keys, get_ovk(from_pubkey)?, &mut partial_tx)?;
+
+        Ok::<_, ZcashError>(partial_tx)
+    })
+}
+
+/// Number of PKH outputs equivalent to the OP_RETURN memo output
+/// for the ZIP-317 fee computation
+pub(crate) fn num_memo_outputs(memo: &Memo) -> u64 {
+    if memo.is_empty() {
+        0
+    } else {
+        let len = memo.len() + 2; // size in bytes of the OP_RETURN output
+        ((len + 33) / 34) as u64 // 34 is the size of a PKH output
+    }
+}
+
 fn select_utxos(
     utxos: &[UTXO],
     amount: u64,
-    memo: &str,
+    memo: &Memo,
 ) -> Result<(Vec<UTXO>, u64, u64), ZcashError> {
-    let num_touts: u64 = 2 + {
-        if memo.is_empty() {
-            0
-        } else {
-            let len = memo.len() + 2; // size in bytes of the OP_RETURN output
-            ((len + 33) / 34) as u64 // 34 is the size of a PKH output
-        }
-    }; // vault + change
+    let num_touts: u64 = 2 + num_memo_outputs(memo); // vault + change
     let mut num_tins: u64 = 0;
     let fee = |num_tins, num_touts| max(num_tins, num_touts) * BASE_FEE;
     let mut current_fee = 0;
@@ -136,9 +390,12 @@ fn select_utxos(
 pub struct Output {
     pub address: String,
     pub amount: u64,
-    pub memo: String,
+    pub memo: Memo,
 }
 
+/// An unsigned vault tx and the sighashes of its inputs
+/// This is the only format of unsigned txs: PCZT is not supported,
+/// the pinned librustzcash fork predates the pczt crate
 pub struct PartialTx {
     pub height: u32,
     pub inputs: Vec<UTXO>,
@@ -153,7 +410,7 @@ pub fn pay_from_vault(
     vault: Vec<u8>,
     to: String,
     amount: u64,
-    memo: String,
+    memo: Memo,
 ) -> Result<PartialTx, ZcashError> {
     uniffi_async_export!(context, {
         let from = get_vault_address(vault.clone())?;
@@ -168,10 +425,10 @@ pub fn pay_from_vault(
         outputs.push(Output {
             address: from,
             amount: change,
-            memo: String::new(),
+            memo: Memo::default(),
         });
         let mut tx_seed = [0u8; 32];
-        OsRng.fill_bytes(&mut tx_seed);
+        tx_rng().fill_bytes(&mut tx_seed);
         let mut partial_tx = PartialTx {
             height,
             inputs,
@@ -180,7 +437,8 @@ pub fn pay_from_vault(
             tx_seed: tx_seed.to_vec(),
             sighashes: Sighashes::default(),
         };
-        build_vault_unauthorized_tx(vault, &mut partial_tx)?;
+        let input_keys = vec![vault.clone(); partial_tx.inputs.len()];
+        build_sighashes(&input_keys, get_ovk(vault)?, &mut partial_tx)?;
 
         Ok::<_, ZcashError>(partial_tx)
     })
@@ -191,7 +449,7 @@ pub fn combine_vault(height: u32, vault: Vec<u8>) -> Result<PartialTx, ZcashErro
         let from = get_vault_address(vault.clone())?;
         let utxos = crate::wallet::list_utxos_async(&context, from.clone()).await?;
         let amount = utxos.iter().map(|u| u.value).sum::<u64>();
-        let output = Output { address: from, amount, memo: String::new() };
+        let output = Output { address: from, amount, memo: Memo::default() };
         combine_vault_utxos_async(height, vault, vec![output], utxos).await
     })
 }
@@ -236,11 +494,11 @@ async fn combine_vault_utxos_async(
     // Txs can only have one transparent memo
     let memos = destination_vaults.iter_mut().filter(|v| !v.memo.is_empty()).skip(1);
     for m in memos {
-        m.memo = String::new();
+        m.memo = Memo::default();
     }
 
     let mut tx_seed = [0u8; 32];
-    OsRng.fill_bytes(&mut tx_seed);
+    tx_rng().fill_bytes(&mut tx_seed);
     let mut partial_tx = PartialTx {
         height,
         inputs: utxos,
@@ -249,7 +507,8 @@ async fn combine_vault_utxos_async(
         tx_seed: tx_seed.to_vec(),
         sighashes: Sighashes::default(),
     };
-    build_vault_unauthorized_tx(vault, &mut partial_tx)?;
+    let input_keys = vec![vault.clone(); partial_tx.inputs.len()];
+    build_sighashes(&input_keys, get_ovk(vault)?, &mut partial_tx)?;
 
     Ok::<_, ZcashError>(partial_tx)
 }
@@ -257,13 +516,12 @@ async fn combine_vault_utxos_async(
 fn pay_with_utxos(
     context: &Context,
     expiry_height: u32,
-    sk: SecretKey,
-    utxos: Vec<UTXO>,
+    utxos: Vec<(SecretKey, UTXO)>,
     from_addr: String,
     to_addr: String,
     amount: u64,
     change: u64,
-    memo: String,
+    memo: Memo,
 ) -> Result<TxBytes, ZcashError> {
     let network = context.config.network();
     let mut txbuilder = TxBuilder::new(
@@ -274,7 +532,7 @@ fn pay_with_utxos(
             orchard_anchor: None,
         },
     );
-    for utxo in utxos {
+    for (sk, utxo) in utxos {
         let op = OutPoint::new(to_hash(&utxo.txid)?, utxo.vout);
         let coin = TxOut {
             value: Zatoshis::from_u64(utxo.value).unwrap(),
@@ -284,10 +542,11 @@ fn pay_with_utxos(
             .add_transparent_input(sk, op, coin)
             .map_err(to_zcasherror(anyhow!("Cannot add utxo {utxo:?}")))?;
     }
+    let memo = memo.to_op_return()?;
     if !memo.is_empty() {
         txbuilder
-            .add_transparent_output_memo(memo.as_bytes())
-            .map_err(to_zcasherror(anyhow!("Cannot add memo {memo}")))?;
+            .add_transparent_output_memo(&memo)
+            .map_err(to_zcasherror(anyhow!("Cannot add memo")))?;
     }
     let from_taddr = TransparentAddress::decode(&network, &from_addr)
         .map_err(to_zcasherror(anyhow!("Invalid source address {from_addr}")))?;
@@ -307,7 +566,7 @@ fn pay_with_utxos(
 
     let prover = &context.sapling_prover;
     let res = txbuilder
-        .build(OsRng, prover, prover, &zip317::FeeRule::standard())
+        .build(tx_rng(), prover, prover, &zip317::FeeRule::standard())
         .map_err(|e| ZcashError::AssertError(e.to_string()))?;
 
     let tx = res.transaction();
@@ -324,12 +583,16 @@ pub struct Sighashes {
     pub hashes: Vec<Vec<u8>>,
 }
 
-fn build_vault_unauthorized_tx(
-    vault: Vec<u8>,
+/// Fill the sighashes of a partial tx whose inputs are
+/// P2PKH utxos of `input_keys` (vaults or user addresses),
+/// one public key per input
+fn build_sighashes(
+    input_keys: &[Vec<u8>],
+    ovk: Vec<u8>,
     ptx: &mut PartialTx,
 ) -> Result<(), ZcashError> {
     uniffi_export!(context, {
-        let unauthed_tx = build_unauthorized_tx(&context, vault, &ptx)?;
+        let unauthed_tx = build_unauthorized_tx(&context, input_keys, &ovk, &ptx)?;
         let txid_parts = unauthed_tx.digest(TxIdDigester);
         let _txid = signature_hash(&unauthed_tx, &SignableInput::Shielded, &txid_parts)
             .as_ref()
@@ -363,16 +626,21 @@ fn build_vault_unauthorized_tx(
 
 fn build_unauthorized_tx(
     context: &Context,
-    vault: Vec<u8>,
+    input_keys: &[Vec<u8>],
+    ovk: &[u8],
     ptx: &PartialTx,
 ) -> Result<TransactionData<zcash_primitives::transaction::Unauthorized>, ZcashError> {
     let network = context.config.network();
-    let mut tx_rng = rand_chacha::ChaCha20Rng::from_seed(to_ba(&ptx.tx_seed)?);
-    let pk = PublicKey::from_slice(&vault).map_err(|_| ZcashError::InvalidVaultPubkey)?;
-    let ovk = get_ovk(vault)?;
+    let mut tx_rng = ChaCha20Rng::from_seed(to_ba(&ptx.tx_seed)?);
+    if input_keys.len() != ptx.inputs.len() {
+        return Err(ZcashError::AssertError(
+            "There must be one public key per input".into(),
+        ));
+    }
 
     let mut tbuilder = TransparentBuilder::empty();
-    for i in ptx.inputs.iter() {
+    for (i, pubkey) in ptx.inputs.iter().zip(input_keys.iter()) {
+        let pk = PublicKey::from_slice(pubkey).map_err(|_| ZcashError::InvalidVaultPubkey)?;
         let UTXO {
             txid,
             vout,
@@ -419,7 +687,7 @@ fn build_unauthorized_tx(
                 receiver,
                 *amount,
                 &memo,
-                &ovk,
+                ovk,
                 &mut tbuilder,
                 &mut sbuilder,
                 &mut obuilder,
@@ -477,32 +745,25 @@ fn build_unauthorized_tx(
     Ok(unauthed_tx)
 }
 
-fn handle_receiver(
+pub(crate) fn handle_receiver(
     receiver: Receiver,
     amount: u64,
-    memo: &str,
+    memo: &Memo,
     ovk: &[u8],
     tbuilder: &mut TransparentBuilder,
     sbuilder: &mut sapling_crypto::builder::Builder,
     obuilder: &mut orchard::builder::Builder,
 ) -> Result<(), ZcashError> {
-    let memo_bytes = if memo.is_empty() {
-        None
-    } else {
-        let memo =
-            Memo::from_str(&memo).map_err(|_| ZcashError::AssertError("Invalid memo".into()))?;
-        let memo = MemoBytes::try_from(memo).unwrap();
-        Some(memo.as_array().clone())
-    };
     match receiver {
         Receiver::Transparent(transparent_address) => {
             let amount = Zatoshis::from_u64(amount).unwrap();
             tbuilder
                 .add_output(&transparent_address, amount)
                 .map_err(to_zcasherror(anyhow!("Cannot add transparent output")))?;
+            let memo = memo.to_op_return()?;
             if !memo.is_empty() {
                 tbuilder
-                    .add_output_memo(memo.as_bytes())
+                    .add_output_memo(&memo)
                     .map_err(to_zcasherror(anyhow!("Cannot add transparent memo")))?;
             }
         }
@@ -512,7 +773,7 @@ fn handle_receiver(
                     Some(sapling_crypto::keys::OutgoingViewingKey(to_ba(ovk)?)),
                     payment_address,
                     sapling_crypto::value::NoteValue::from_raw(amount),
-                    memo_bytes,
+                    memo.to_memo_bytes()?,
                 )
                 .map_err(|e| ZcashError::AssertError(e.to_string()))?;
         }
@@ -522,7 +783,7 @@ fn handle_receiver(
                     Some(OutgoingViewingKey::from(to_ba(ovk)?)),
                     address,
                     NoteValue::from_raw(amount),
-                    memo_bytes,
+                    memo.to_memo_bytes()?,
                 )
                 .map_err(|e| ZcashError::AssertError(e.to_string()))?;
         }
@@ -543,41 +804,81 @@ pub fn sign_sighash(sk: Vec<u8>, sighash: Vec<u8>) -> Result<Vec<u8>, ZcashError
     Ok(sig)
 }
 
+/// Derive the RNG used for the authorization of the transaction.
+/// It is seeded from the tx_seed but does not reuse the stream
+/// consumed by the construction of the unauthorized transaction.
+fn auth_rng(tx_seed: &[u8]) -> Result<ChaCha20Rng, ZcashError> {
+    let hash = blake2b_simd::Params::new()
+        .hash_length(32)
+        .personal(b"Zcash_Maya_Auth_")
+        .hash(tx_seed);
+    let seed = to_ba(hash.as_bytes())?;
+    Ok(ChaCha20Rng::from_seed(seed))
+}
+
+/// Finalize a partial tx built by `pay_from_vault`, `combine_vault`,
+/// `combine_vault_utxos` or `build_deposit_to_vault`.
+/// `pubkey` is the public key that owns the inputs, i.e the vault
+/// or the user address for deposits
 pub fn apply_signatures(
-    vault: Vec<u8>,
+    pubkey: Vec<u8>,
     ptx: PartialTx,
     signatures: Vec<Vec<u8>>,
 ) -> Result<Vec<u8>, ZcashError> {
     uniffi_export!(context, {
-        let unauthed_tx = build_unauthorized_tx(&context, vault, &ptx)?;
-        let signatures = signatures
-            .iter()
-            .map(|s| Signature::from_compact(&s))
-            .collect::<Result<Vec<_>, _>>()
-            .map_err(to_zcasherror(anyhow!("Invalid signature(s)")))?;
-        let txid_parts = unauthed_tx.digest(TxIdDigester);
-        let txid = signature_hash(&unauthed_tx, &SignableInput::Shielded, &txid_parts)
-            .as_ref()
-            .clone();
-        tracing::info!("txid {}", hex::encode(txid));
-
-        let pk = &context.orchard_prover;
-        let tx_data: TransactionData<zcash_primitives::transaction::Authorized> = unauthed_tx
-            .map_bundles(
-                |tb| tb.map(|tb| tb.apply_external_signatures(signatures)),
-                |sb| sb.map(|sb| sb.apply_signatures(OsRng, txid.clone(), &[]).unwrap()),
-                |ob| {
-                    ob.map(|ob| {
-                        let ob = ob.create_proof(pk, OsRng).unwrap();
-                        ob.apply_signatures(OsRng, txid.clone(), &[]).unwrap()
-                    })
-                },
-            );
+        let input_keys = vec![pubkey.clone(); ptx.inputs.len()];
+        finalize_tx(&context, &input_keys, get_ovk(pubkey)?, ptx, signatures)
+    })
+}
+
+fn finalize_tx(
+    context: &Context,
+    input_keys: &[Vec<u8>],
+    ovk: Vec<u8>,
+    ptx: PartialTx,
+    signatures: Vec<Vec<u8>>,
+) -> Result<Vec<u8>, ZcashError> {
+    let unauthed_tx = build_unauthorized_tx(context, input_keys, &ovk, &ptx)?;
+    let signatures = signatures
+        .iter()
+        .map(|s| Signature::from_compact(&s))
+        .collect::<Result<Vec<_>, _>>()
+        .map_err(to_zcasherror(anyhow!("Invalid signature(s)")))?;
+    let txid_parts = unauthed_tx.digest(TxIdDigester);
+    let txid = signature_hash(&unauthed_tx, &SignableInput::Shielded, &txid_parts)
+        .as_ref()
+        .clone();
+    tracing::info!("txid {}", hex::encode(txid));
+
+    // Every node that finalizes the same PartialTx must produce
+    // the same bytes, therefore the binding signatures and the
+    // orchard proof cannot use OsRng
+    let mut auth_rng = auth_rng(&ptx.tx_seed)?;
+    let mut sapling_rng = ChaCha20Rng::from_rng(&mut auth_rng).unwrap();
+    let mut orchard_rng = ChaCha20Rng::from_rng(&mut auth_rng).unwrap();
+
+    let pk = &context.orchard_prover;
+    let tx_data: TransactionData<zcash_primitives::transaction::Authorized> = unauthed_tx
+        .map_bundles(
+            |tb| tb.map(|tb| tb.apply_external_signatures(signatures)),
+            |sb| {
+                sb.map(|sb| {
+                    sb.apply_signatures(&mut sapling_rng, txid.clone(), &[])
+                        .unwrap()
+                })
+            },
+            |ob| {
+                ob.map(|ob| {
+                    let ob = ob.create_proof(pk, &mut orchard_rng).unwrap();
+                    ob.apply_signatures(&mut orchard_rng, txid.clone(), &[])
+                        .unwrap()
+                })
+            },
+        );
 
-        let tx = tx_data.freeze().unwrap();
-        let mut buffer = vec![];
-        tx.write(&mut buffer).unwrap();
+    let tx = tx_data.freeze().unwrap();
+    let mut buffer = vec![];
+    tx.write(&mut buffer).unwrap();
 
-        Ok::<_, ZcashError>(buffer)
-    })
+    Ok(buffer)
 }
@@ -56,3 +56,9 @@ pub async fn json_request<'a>(
 pub fn map_rpc_error(e: anyhow::Error) -> ZcashError {
     ZcashError::RPC(e.to_string())
 }
+
+/// True if the node replied with an error that starts with `message`
+/// Connection failures and the other node errors are not
+pub(crate) fn is_node_error(e: &anyhow::Error, message: &str) -> bool {
+    e.to_string().starts_with(message)
+}
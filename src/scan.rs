@@ -1,47 +1,66 @@
-use std::collections::HashSet;
+use std::{
+    collections::{HashMap, HashSet},
+    sync::Arc,
+};
 
 use anyhow::{anyhow, Context as _};
-use orchard::{
-    note_encryption::OrchardDomain,
-    primitives::redpallas::{SpendAuth, VerificationKey},
-};
-use sapling_crypto::{
-    bundle::OutputDescription, note::ExtractedNoteCommitment, note_encryption::SaplingDomain,
-    value::ValueCommitment,
-};
+use futures::{stream, StreamExt as _, TryStreamExt as _};
+use parking_lot::Mutex;
 use serde::{Deserialize, Serialize};
 use serde_json::json;
+use tokio::sync::{OnceCell, Semaphore, SemaphorePermit};
 use uuid::Uuid;
-use zcash_keys::{address::UnifiedAddress, encoding::AddressCodec};
-use zcash_note_encryption::{EphemeralKeyBytes, ENC_CIPHERTEXT_SIZE, OUT_CIPHERTEXT_SIZE};
-use zcash_protocol::memo::{Memo, MemoBytes};
+use zcash_primitives::transaction::Transaction;
 
 use crate::{
-    addr::{get_ovk, get_vault_address},
+    addr::{get_ovk, get_vault_address, vault_address},
+    analyze::{analyze_tx, read_tx, tout_address, tx_inputs, tx_outputs, vault_outputs},
+    cache::TX_CACHE,
     config::{Config, Context},
+    memo::Memo,
     network::Network,
     pay::Output,
     rpc::{json_request, map_rpc_error},
-    to_ba, to_hash, to_uhash, to_zcasherror, uniffi_async_export, ZcashError,
+    to_zcasherror, uniffi_async_export,
+    viewing::{decode_viewing_key, get_vault_viewing_key, VaultViewingKey},
+    walker::{get_block, get_block_hash},
+    ZcashError,
 };
 
-pub struct Note {
-    pub address: String,
-    pub value: i64,
-    pub memo: Option<String>,
+// number of txs (and parent txs) fetched at the same time
+pub(crate) const MAX_CONCURRENT_TXS: usize = 8;
+
+type InFlightTx = Arc<OnceCell<Arc<Transaction>>>;
+
+lazy_static::lazy_static! {
+    // the tx stages are nested (the prevouts of each tx are fetched
+    // by its worker) so the streams cannot bound the requests alone
+    static ref TX_REQUESTS: Semaphore = Semaphore::new(MAX_CONCURRENT_TXS);
+    // txids being fetched, the other callers wait for the same reply
+    static ref IN_FLIGHT_TXS: Mutex<HashMap<String, InFlightTx>> = Mutex::new(HashMap::new());
 }
 
-pub struct TxData {
-    pub txid: String,
-    // Who paid us or who we are paying, and how much
-    // value > 0 -> vault received funds
-    pub counterparty: Note,
-    // All the transparent input/output and the shielded outputs
-    // we can decrypt
-    pub plain: Vec<Note>,
-    // amount encrypted
-    pub encrypted: i64,
-    pub fee: u64,
+/// Wait for a slot to request a tx from the node
+pub(crate) async fn tx_request_permit() -> SemaphorePermit<'static> {
+    // the semaphore is never closed
+    TX_REQUESTS.acquire().await.unwrap()
+}
+
+// Removes the in flight entry when its last caller is done,
+// even if the fetch was cancelled
+struct InFlightGuard<'a> {
+    txid: &'a str,
+    cell: InFlightTx,
+}
+
+impl Drop for InFlightGuard<'_> {
+    fn drop(&mut self) {
+        let mut in_flight = IN_FLIGHT_TXS.lock();
+        // the map and this guard
+        if Arc::strong_count(&self.cell) == 2 {
+            in_flight.remove(self.txid);
+        }
+    }
 }
 
 #[derive(Clone, Serialize, Deserialize, Debug)]
@@ -57,29 +76,6 @@ pub struct MempoolTxDelta {
     prevout: Option<u32>,
 }
 
-#[derive(Clone, Serialize, Deserialize, Debug)]
-pub struct TIn {
-    txid: String,
-    vout: u32,
-}
-
-#[derive(Clone, Serialize, Deserialize, Debug)]
-pub struct ScriptPubKey {
-    addresses: Option<Vec<String>>,
-    asm: String,
-    r#type: String,
-}
-
-#[derive(Clone, Serialize, Deserialize, Debug)]
-pub struct TRawOut {
-    #[serde(rename = "n")]
-    pub vout: u32,
-    #[serde(rename = "scriptPubKey")]
-    pub script: ScriptPubKey,
-    #[serde(rename = "valueSat")]
-    pub value: u64,
-}
-
 #[derive(Clone, Serialize, Deserialize, Debug)]
 pub struct TOut {
     pub address: String,
@@ -87,256 +83,109 @@ pub struct TOut {
     pub memo: Option<String>,
 }
 
-impl TryFrom<TRawOut> for TOut {
-    type Error = ();
-
-    fn try_from(out: TRawOut) -> Result<Self, Self::Error> {
-        match out.script.r#type.as_str() {
-            "pubkeyhash" => Ok(TOut {
-                address: out.script.addresses.unwrap()[0].clone(),
-                value: out.value,
-                memo: None,
-            }),
-            "nulldata" => {
-                let asm = &out.script.asm;
-                if asm.starts_with("OP_RETURN") {
-                    let memo = hex::decode(&asm[10..]).unwrap();
-                    let memo = String::from_utf8_lossy(&memo).to_string();
-                    Ok(TOut {
-                        address: String::new(),
-                        value: 0,
-                        memo: Some(memo),
-                    })
-                } else {
-                    Err(())
-                }
-            }
-            _ => Err(()),
-        }
-    }
-}
-
-#[derive(Clone, Serialize, Deserialize, Debug)]
-pub struct SOut {
-    pub cv: String,
-    pub cmu: String,
-    #[serde(rename = "ephemeralKey")]
-    pub epk: String,
-    #[serde(rename = "encCiphertext")]
-    pub enc: String,
-    #[serde(rename = "outCiphertext")]
-    pub out: String,
-}
-
-#[derive(Clone, Serialize, Deserialize, Debug)]
-pub struct Orchard {
-    pub actions: Vec<Action>,
-}
-
-#[derive(Clone, Serialize, Deserialize, Debug)]
-pub struct Action {
-    pub cv: String,
-    pub cmx: String,
-    #[serde(rename = "nullifier")]
-    pub rho: String,
-    pub rk: String,
-    #[serde(rename = "ephemeralKey")]
-    pub epk: String,
-    #[serde(rename = "encCiphertext")]
-    pub enc: String,
-    #[serde(rename = "outCiphertext")]
-    pub out: String,
-}
-
-#[derive(Clone, Serialize, Deserialize, Debug)]
-pub struct RawVaultTx {
-    txid: String,
-    #[serde(rename = "vin")]
-    tins: Vec<TIn>,
-    #[serde(rename = "vout")]
-    touts: Vec<TRawOut>,
-    #[serde(rename = "vShieldedOutput")]
-    souts: Vec<SOut>,
-    orchard: Orchard,
-}
-
 #[derive(Clone, Debug)]
-pub struct VaultTxDetails {
-    txid: String,
-    tins: Vec<TIn>,
-    ptouts: Vec<TOut>,
-    touts: Vec<TOut>,
-    souts: Vec<SOut>,
-    actions: Vec<Action>,
-}
-
-#[derive(Debug)]
 pub enum Direction {
     Incoming,
     Outgoing,
+    // the vault pays itself (consolidation)
+    Internal,
+    // transfer between the vault and another known vault
+    Migration,
 }
 
-#[derive(Debug)]
+#[derive(Clone, Debug)]
 pub struct VaultTx {
     pub height: u32,
     pub txid: String,
+    // pubkey of the vault this tx belongs to
+    pub vault: Vec<u8>,
     pub counterparty: Output,
     pub direction: Direction,
+    // every output that the vault funded or received
+    // depending on the direction
+    pub destinations: Vec<Output>,
+    // shielded outputs paid by the vault that its OVK cannot
+    // recover. Orchard bundles are padded with dummy outputs
+    // that nobody can decrypt, so `incomplete` is only set if
+    // the undecrypted outputs carry some value
+    pub undecrypted: Vec<UndecryptedOutput>,
+    pub incomplete: bool,
+    // net value going from the shielded pools to the
+    // transparent pool, negative when it goes the other way
+    pub shielded_value_balance: i64,
 }
 
-impl From<RawVaultTx> for VaultTxDetails {
-    fn from(tx: RawVaultTx) -> Self {
-        Self {
-            txid: tx.txid.clone(),
-            tins: tx.tins,
-            ptouts: vec![],
-            touts: tx
-                .touts
-                .into_iter()
-                .filter_map(|o| o.try_into().ok())
-                .collect(),
-            souts: tx.souts,
-            actions: tx.orchard.actions,
-        }
-    }
+#[derive(Clone, Copy, Debug, PartialEq)]
+pub enum ShieldedPool {
+    Sapling,
+    Orchard,
 }
 
-pub struct VaultTxDecrypted {
-    txid: String,
-    ptouts: Vec<TOut>,
-    outputs: Vec<Output>,
+/// A shielded output that could not be decrypted
+/// `index` is the position of the output (or action) in its
+/// bundle, `cmx` its note commitment and `cv` its value commitment
+#[derive(Clone, Debug)]
+pub struct UndecryptedOutput {
+    pub pool: ShieldedPool,
+    pub index: u32,
+    pub cmx: Vec<u8>,
+    pub cv: Vec<u8>,
 }
 
-impl VaultTxDetails {
-    pub async fn resolve_inputs(&mut self, config: &Config) -> Result<(), ZcashError> {
-        for tin in self.tins.iter() {
-            let id = Uuid::new_v4().to_string();
-            let rep = json_request(
-                config,
-                &id,
-                "getrawtransaction",
-                vec![tin.txid.clone().into(), 1.into()],
-            )
-            .await
-            .map_err(map_rpc_error)?;
-            let tx: RawVaultTx = serde_json::from_value(rep)
-                .context("Cannot parse getrawtransaction reply")
-                .map_err(map_rpc_error)?;
-            self.ptouts
-                .push(tx.touts[tin.vout as usize].clone().try_into().unwrap());
-        }
-        Ok(())
-    }
-
-    pub fn decrypt(
-        &self,
-        network: &Network,
-        ovk: [u8; 32],
-    ) -> Result<VaultTxDecrypted, ZcashError> {
-        let mut outputs = vec![];
-
-        let mut tmemo = None;
-        for tout in self.touts.iter() {
-            if tout.memo.is_some() {
-                tmemo = tout.memo.clone();
-            }
-            outputs.push(Output {
-                address: tout.address.clone(),
-                amount: tout.value,
-                memo: String::new(),
-            });
-        }
-        if let Some(tmemo) = tmemo {
-            for o in outputs.iter_mut() {
-                o.memo = tmemo.clone();
-            }
-        }
+/// A resolved transparent input
+pub struct TxInput {
+    pub txid: String,
+    pub vout: u32,
+    pub address: String,
+    pub value: u64,
+}
 
-        for sout in self.souts.iter() {
-            let cv = to_hash(&sout.cv)?;
-            let cv = ValueCommitment::from_bytes_not_small_order(&cv).unwrap();
-            let cmu = to_hash(&sout.cmu)?;
-            let cmu = ExtractedNoteCommitment::from_bytes(&cmu).unwrap();
-            let epk = to_hash(&sout.epk)?;
-            let epk = EphemeralKeyBytes::from(epk);
-            let mut enc = [0u8; ENC_CIPHERTEXT_SIZE];
-            enc.copy_from_slice(&hex::decode(&sout.enc).unwrap());
-            let mut out = [0u8; OUT_CIPHERTEXT_SIZE];
-            out.copy_from_slice(&hex::decode(&sout.out).unwrap());
-            let output = OutputDescription::<()>::from_parts(cv, cmu, epk, enc, out, ());
-            let d = SaplingDomain::new(sapling_crypto::note_encryption::Zip212Enforcement::On);
-            let ovk = sapling_crypto::keys::OutgoingViewingKey(ovk);
-            if let Some((note, address, memo)) = zcash_note_encryption::try_output_recovery_with_ovk(
-                &d,
-                &ovk,
-                &output,
-                &output.cv(),
-                &out,
-            ) {
-                let memo = memo_to_string(&memo);
-                outputs.push(Output {
-                    address: address.encode(network),
-                    amount: note.value().inner(),
-                    memo,
-                });
-            }
-        }
+/// Everything we know about a vault transaction
+/// `outputs` has the transparent outputs and the shielded outputs
+/// recovered with the vault OVK. `block_hash` is empty and
+/// `block_time`, `position` and `confirmations` are 0 for
+/// mempool transactions
+pub struct VaultTxDetail {
+    pub tx: VaultTx,
+    pub inputs: Vec<TxInput>,
+    pub outputs: Vec<Output>,
+    pub fee: u64,
+    pub block_hash: String,
+    pub block_time: u32,
+    pub position: u32,
+    pub confirmations: u32,
+}
 
-        for a in self.actions.iter() {
-            let rho = to_uhash(&a.rho)?;
-            let rho = orchard::note::Nullifier::from_bytes(&rho).unwrap();
-            let rk = to_uhash(&a.rk)?;
-            let rk = VerificationKey::<SpendAuth>::try_from(rk).unwrap();
-            let cv = to_uhash(&a.cv)?;
-            let cv = orchard::value::ValueCommitment::from_bytes(&cv).unwrap();
-            let cmx = to_uhash(&a.cmx)?;
-            let cmx = orchard::note::ExtractedNoteCommitment::from_bytes(&cmx).unwrap();
-            let epk = to_uhash(&a.epk)?;
-            let mut enc = [0u8; ENC_CIPHERTEXT_SIZE];
-            enc.copy_from_slice(&hex::decode(&a.enc).unwrap());
-            let mut out = [0u8; OUT_CIPHERTEXT_SIZE];
-            out.copy_from_slice(&hex::decode(&a.out).unwrap());
-            let encrypted_note = orchard::note::TransmittedNoteCiphertext {
-                epk_bytes: epk,
-                enc_ciphertext: enc,
-                out_ciphertext: out,
-            };
-            let action = orchard::Action::from_parts(rho, rk, cmx, encrypted_note, cv, ());
-            let d = OrchardDomain::for_action(&action);
-            let ovk = orchard::keys::OutgoingViewingKey::from(ovk);
-            if let Some((note, address, memo)) = zcash_note_encryption::try_output_recovery_with_ovk(
-                &d,
-                &ovk,
-                &action,
-                action.cv_net(),
-                &action.encrypted_note().out_ciphertext,
-            ) {
-                let address = UnifiedAddress::from_receivers(Some(address), None, None).unwrap();
-                let memo = memo_to_string(&memo);
-                outputs.push(Output {
-                    address: address.encode(network),
-                    amount: note.value().inner(),
-                    memo,
-                });
-            }
-        }
+pub struct VaultTxDecrypted {
+    pub(crate) txid: String,
+    pub(crate) ptouts: Vec<TOut>,
+    pub(crate) outputs: Vec<Output>,
+    pub(crate) undecrypted: Vec<UndecryptedOutput>,
+    // value of the shielded outputs we recovered
+    pub(crate) shielded_value: u64,
+    pub(crate) value_balance: i64,
+}
 
-        let txdec = VaultTxDecrypted {
-            txid: self.txid.clone(),
-            ptouts: self.ptouts.clone(),
-            outputs,
-        };
+impl VaultTxDecrypted {
+    // Without shielded spends, what goes into the shielded pools
+    // and was not recovered went to the undecrypted outputs
+    fn undecrypted_value(&self) -> u64 {
+        (-self.value_balance - self.shielded_value as i64).max(0) as u64
+    }
 
-        Ok(txdec)
+    fn incomplete(&self) -> bool {
+        self.undecrypted_value() > 0
     }
 }
 
 impl VaultTx {
-    fn from_decrypted(
+    pub(crate) fn from_decrypted(
         height: u32,
         txd: &VaultTxDecrypted,
-        vault_addr: &str,
+        vault: &VaultKeys,
+        known_vaults: &HashSet<String>,
     ) -> Result<Self, ZcashError> {
+        let vault_addr = vault.address.as_str();
         let spent = txd
             .ptouts
             .iter()
@@ -353,19 +202,85 @@ impl VaultTx {
             let non_vault_outputs = txd
                 .outputs
                 .iter()
-                .filter(|&o| o.address != vault_addr && o.amount > 0)
+                .filter(|&o| !vault.owns(&o.address) && o.amount > 0)
                 .cloned()
                 .collect::<Vec<_>>();
-            if non_vault_outputs.len() > 1 {
-                return Err(ZcashError::AssertError(
-                    "Payment from vault should have at most a single recipient".into(),
-                ));
-            }
-            VaultTx {
-                height,
-                txid: txd.txid.clone(),
-                counterparty: non_vault_outputs.first().cloned().unwrap_or_default(),
-                direction: Direction::Outgoing,
+            if non_vault_outputs.is_empty() && txd.incomplete() {
+                // the vault paid shielded outputs that we cannot
+                // recover: the recipient and the memo are unknown
+                VaultTx {
+                    height,
+                    txid: txd.txid.clone(),
+                    vault: vault.pubkey.clone(),
+                    counterparty: Output {
+                        address: String::new(),
+                        amount: txd.undecrypted_value(),
+                        memo: Memo::default(),
+                    },
+                    direction: Direction::Outgoing,
+                    destinations: vec![],
+                    undecrypted: txd.undecrypted.clone(),
+                    incomplete: true,
+                    shielded_value_balance: txd.value_balance,
+                }
+            } else if non_vault_outputs.is_empty() {
+                // consolidation, everything goes back to the vault
+                let vault_outputs = txd
+                    .outputs
+                    .iter()
+                    .filter(|&o| vault.owns(&o.address) && o.amount > 0)
+                    .cloned()
+                    .collect::<Vec<_>>();
+                let total_value = vault_outputs.iter().map(|o| o.amount).sum::<u64>();
+                let memo = vault_outputs
+                    .first()
+                    .map(|o| o.memo.clone())
+                    .unwrap_or_default();
+                VaultTx {
+                    height,
+                    txid: txd.txid.clone(),
+                    vault: vault.pubkey.clone(),
+                    counterparty: Output {
+                        address: vault_addr.to_string(),
+                        amount: total_value,
+                        memo,
+                    },
+                    direction: Direction::Internal,
+                    destinations: vault_outputs,
+                    undecrypted: txd.undecrypted.clone(),
+                    incomplete: txd.incomplete(),
+                    shielded_value_balance: txd.value_balance,
+                }
+            } else if non_vault_outputs
+                .iter()
+                .all(|o| known_vaults.contains(&o.address))
+            {
+                // churn to one or several other vaults
+                VaultTx {
+                    height,
+                    txid: txd.txid.clone(),
+                    vault: vault.pubkey.clone(),
+                    counterparty: non_vault_outputs.first().cloned().unwrap(),
+                    direction: Direction::Migration,
+                    destinations: non_vault_outputs,
+                    undecrypted: txd.undecrypted.clone(),
+                    incomplete: txd.incomplete(),
+                    shielded_value_balance: txd.value_balance,
+                }
+            } else {
+                // a batched payout has several recipients, the
+                // counterparty is the first one
+                VaultTx {
+                    height,
+                    txid: txd.txid.clone(),
+                    vault: vault.pubkey.clone(),
+                    counterparty: non_vault_outputs.first().cloned().unwrap(),
+                    direction: Direction::Outgoing,
+                    destinations: non_vault_outputs,
+                    undecrypted: txd.undecrypted.clone(),
+                    incomplete: txd.incomplete(),
+                    shielded_value_balance: txd.value_balance,
+                }
             }
         } else {
             // spent is 0, there are no vault inputs, which means
@@ -373,7 +288,7 @@ impl VaultTx {
             let vault_outputs = txd
                 .outputs
                 .iter()
-                .filter(|&o| o.address == vault_addr && o.amount > 0)
+                .filter(|&o| vault.owns(&o.address) && o.amount > 0)
                 .cloned()
                 .collect::<Vec<_>>();
             if vault_outputs.is_empty() {
@@ -392,15 +307,31 @@ impl VaultTx {
             if let Some(first_tin) = txd.ptouts.first() {
                 counterparty_addr = first_tin.address.clone();
             }
+            // funds coming from another vault are part of a migration
+            let direction = if !txd.ptouts.is_empty()
+                && txd
+                    .ptouts
+                    .iter()
+                    .all(|pout| known_vaults.contains(&pout.address))
+            {
+                Direction::Migration
+            } else {
+                Direction::Incoming
+            };
             VaultTx {
                 height,
                 txid: txd.txid.clone(),
+                vault: vault.pubkey.clone(),
                 counterparty: Output {
                     address: counterparty_addr,
                     amount: total_value,
                     memo,
                 },
-                direction: Direction::Incoming,
+                direction,
+                destinations: vault_outputs,
+                undecrypted: vec![],
+                incomplete: false,
+                shielded_value_balance: txd.value_balance,
             }
         };
 
@@ -408,80 +339,396 @@ impl VaultTx {
     }
 }
 
-pub fn scan_mempool(pubkey: Vec<u8>) -> Result<Vec<VaultTx>, ZcashError> {
+/// Addresses of the other Maya vaults given to the scans
+/// Transfers between a scanned vault and one of these
+/// are reported as `Migration`
+pub(crate) fn known_vault_addresses(pubkeys: Vec<Vec<u8>>) -> Result<HashSet<String>, ZcashError> {
+    pubkeys.into_iter().map(get_vault_address).collect()
+}
+
+pub fn scan_mempool(
+    pubkey: Vec<u8>,
+    known_vaults: Vec<Vec<u8>>,
+) -> Result<Vec<VaultTx>, ZcashError> {
+    scan_mempool_multi(vec![pubkey], known_vaults)
+}
+
+/// Scan the mempool for several vaults at once
+/// Each tx is fetched once and reported once per vault it touches
+pub fn scan_mempool_multi(
+    pubkeys: Vec<Vec<u8>>,
+    known_vaults: Vec<Vec<u8>>,
+) -> Result<Vec<VaultTx>, ZcashError> {
+    let vaults = VaultKeys::from_pubkeys(pubkeys)?;
+    let known_vaults = known_vault_addresses(known_vaults)?;
     uniffi_async_export!(context, {
         let config = &context.config;
-        let vault_addr = get_vault_address(pubkey.clone())?;
-        let ovk = get_ovk(pubkey)?;
+        let tx_ids = get_mempool_txids(config, &vaults).await?;
 
-        let id = Uuid::new_v4().to_string();
-        let rep = json_request(
-            config,
-            &id,
-            "getaddressmempool",
-            vec![json!({
-                "addresses": [vault_addr]
-            })],
-        )
+        let txs: Vec<Vec<VaultTx>> = stream::iter(tx_ids.iter())
+            .map(|txid| process_mempool_tx(config, txid, &vaults, &known_vaults))
+            .buffered(MAX_CONCURRENT_TXS)
+            .try_collect()
+            .await?;
+
+        Ok(txs.into_iter().flatten().collect())
+    })
+}
+
+/// Txids of the mempool txs that touch the vaults
+pub(crate) async fn get_mempool_txids(
+    config: &Config,
+    vaults: &[VaultKeys],
+) -> Result<HashSet<String>, ZcashError> {
+    let addresses = vaults.iter().map(|v| v.address.clone()).collect::<Vec<_>>();
+
+    let id = Uuid::new_v4().to_string();
+    let rep = json_request(
+        config,
+        &id,
+        "getaddressmempool",
+        vec![json!({
+            "addresses": addresses
+        })],
+    )
+    .await
+    .map_err(map_rpc_error)?;
+    let delta: Vec<MempoolTxDelta> = serde_json::from_value(rep)
+        .context("Cannot parse getaddressmempool reply")
+        .map_err(map_rpc_error)?;
+    let mut txids = delta.into_iter().map(|d| d.txid).collect::<HashSet<_>>();
+    txids.extend(get_shielded_mempool_txids(config, vaults).await?);
+    Ok(txids)
+}
+
+/// The address index does not see the shielded deposits:
+/// every mempool tx is trial decrypted with the viewing keys.
+/// The txs stay in the raw tx cache between two polls
+async fn get_shielded_mempool_txids(
+    config: &Config,
+    vaults: &[VaultKeys],
+) -> Result<Vec<String>, ZcashError> {
+    if vaults.iter().all(|v| v.viewing_key.is_none()) {
+        return Ok(vec![]);
+    }
+    let id = Uuid::new_v4().to_string();
+    let rep = json_request(config, &id, "getrawmempool", vec![])
         .await
         .map_err(map_rpc_error)?;
-        let delta: Vec<MempoolTxDelta> = serde_json::from_value(rep)
-            .context("Cannot parse getaddressmempool reply")
-            .map_err(map_rpc_error)?;
-        let tx_ids: HashSet<String> = delta.into_iter().map(|d| d.txid).collect();
+    let txids: Vec<String> = serde_json::from_value(rep)
+        .context("Cannot parse getrawmempool reply")
+        .map_err(map_rpc_error)?;
+    let txids: Vec<Option<String>> = stream::iter(txids)
+        .map(|txid| async move {
+            // the tx may have left the mempool in the meantime
+            let tx = fetch_raw_tx(config, &txid).await.ok()?;
+            vaults.iter().any(|v| v.receives_notes(&tx)).then_some(txid)
+        })
+        .buffer_unordered(MAX_CONCURRENT_TXS)
+        .collect()
+        .await;
+    Ok(txids.into_iter().flatten().collect())
+}
 
-        let mut txs = vec![];
-        for txid in tx_ids.iter() {
-            if let Some(tx) = process_tx(
-                config,
-                &TxId {
-                    txid: txid.clone(),
-                    height: 0,
-                },
-                &vault_addr,
-                &ovk,
-            )
-            .await?
-            {
-                txs.push(tx);
-            }
-        }
+pub(crate) async fn process_mempool_tx(
+    config: &Config,
+    txid: &str,
+    vaults: &[VaultKeys],
+    known_vaults: &HashSet<String>,
+) -> Result<Vec<VaultTx>, ZcashError> {
+    process_tx(
+        config,
+        &TxId {
+            txid: txid.to_string(),
+            height: 0,
+        },
+        vaults,
+        known_vaults,
+    )
+    .await
+}
 
-        Ok(txs)
-    })
+/// Pubkey, address and ovk of a vault we scan
+/// and its viewing key if it takes shielded deposits
+#[derive(Clone)]
+pub(crate) struct VaultKeys {
+    pub(crate) pubkey: Vec<u8>,
+    pub(crate) address: String,
+    pub(crate) ovk: Vec<u8>,
+    pub(crate) viewing_key: Option<VaultViewingKey>,
+}
+
+impl VaultKeys {
+    /// The keys of the vault on the given network, without
+    /// the context. `ufvk` is its viewing key, if not empty
+    pub(crate) fn with_network(
+        network: &Network,
+        pubkey: Vec<u8>,
+        ufvk: &str,
+    ) -> Result<Self, ZcashError> {
+        let address = vault_address(network, &pubkey)?;
+        let ovk = get_ovk(pubkey.clone())?;
+        let viewing_key = if ufvk.is_empty() {
+            None
+        } else {
+            Some(decode_viewing_key(network, &address, ufvk)?)
+        };
+        Ok(Self {
+            pubkey,
+            address,
+            ovk,
+            viewing_key,
+        })
+    }
+
+    pub(crate) fn new(pubkey: Vec<u8>) -> Result<Self, ZcashError> {
+        let address = get_vault_address(pubkey.clone())?;
+        let ovk = get_ovk(pubkey.clone())?;
+        let viewing_key = get_vault_viewing_key(&address);
+        Ok(Self {
+            pubkey,
+            address,
+            ovk,
+            viewing_key,
+        })
+    }
+
+    /// The transparent address or the shielded deposit address
+    pub(crate) fn owns(&self, address: &str) -> bool {
+        address == self.address
+            || self
+                .viewing_key
+                .as_ref()
+                .is_some_and(|vk| vk.deposit_address == address)
+    }
+
+    /// True if the tx has notes for the viewing key
+    pub(crate) fn receives_notes(&self, tx: &Transaction) -> bool {
+        self.viewing_key
+            .as_ref()
+            .is_some_and(|vk| !vk.decrypt_incoming(tx).is_empty())
+    }
+
+    pub(crate) fn from_pubkeys(pubkeys: Vec<Vec<u8>>) -> Result<Vec<Self>, ZcashError> {
+        if pubkeys.is_empty() {
+            return Err(ZcashError::AssertError("No vault to scan".into()));
+        }
+        pubkeys.into_iter().map(Self::new).collect()
+    }
 }
 
 async fn process_tx(
     config: &Config,
     txid: &TxId,
-    vault_addr: &str,
-    ovk: &[u8],
-) -> Result<Option<VaultTx>, ZcashError> {
+    vaults: &[VaultKeys],
+    known_vaults: &HashSet<String>,
+) -> Result<Vec<VaultTx>, ZcashError> {
     let network = config.network();
+    let tx = fetch_raw_tx(config, &txid.txid).await?;
+    // a spend from the vault may not have any vault output
+    // (no change), so we need the previous outputs too
+    let prevouts = fetch_prevouts(config, &tx).await?;
+    let mut vtxs = vec![];
+    for vault in vaults.iter() {
+        if let Some(vtx) = analyze_tx(&network, &tx, &prevouts, txid.height, vault, known_vaults)? {
+            tracing::info!("{:?}", vtx);
+            vtxs.push(vtx);
+        }
+    }
+    Ok(vtxs)
+}
+
+/// The parsed tx, from the cache if it was fetched before
+/// Concurrent calls for the same txid make a single request
+pub(crate) async fn fetch_raw_tx(
+    config: &Config,
+    txid: &str,
+) -> Result<Arc<Transaction>, ZcashError> {
+    if let Some(tx) = TX_CACHE.lock().get(txid) {
+        return Ok(tx);
+    }
+    let guard = InFlightGuard {
+        txid,
+        cell: IN_FLIGHT_TXS
+            .lock()
+            .entry(txid.to_string())
+            .or_default()
+            .clone(),
+    };
+    // if the request fails, the next waiter makes its own
+    let tx = guard
+        .cell
+        .get_or_try_init(|| request_raw_tx(config, txid))
+        .await?;
+    Ok(tx.clone())
+}
 
+async fn request_raw_tx(config: &Config, txid: &str) -> Result<Arc<Transaction>, ZcashError> {
+    let _permit = tx_request_permit().await;
     let id = Uuid::new_v4().to_string();
     let rep = json_request(
         config,
         &id,
         "getrawtransaction",
-        vec![txid.txid.clone().into(), 1.into()],
+        vec![txid.into(), 0.into()],
     )
     .await
     .map_err(map_rpc_error)?;
-    tracing::debug!("{:?}", rep);
+    let data = rep
+        .as_str()
+        .and_then(|h| hex::decode(h).ok())
+        .ok_or(ZcashError::AssertError(
+            "Cannot parse getrawtransaction reply".into(),
+        ))?;
+    let tx = Arc::new(read_tx(&data)?);
+    TX_CACHE.lock().insert(txid.to_string(), tx.clone());
+    Ok(tx)
+}
+
+/// The outputs spent by the transparent inputs
+pub(crate) async fn fetch_prevouts(
+    config: &Config,
+    tx: &Transaction,
+) -> Result<Vec<TxInput>, ZcashError> {
+    let network = &config.network();
+    stream::iter(tx_inputs(tx))
+        .map(|(txid, vout)| async move {
+            let ptx = fetch_raw_tx(config, &txid).await?;
+            let tout = tx_outputs(&ptx)
+                .get(vout as usize)
+                .ok_or(ZcashError::AssertError(format!("No output {txid}:{vout}")))?;
+            Ok::<_, ZcashError>(TxInput {
+                address: tout_address(network, tout),
+                value: tout.value.into_u64(),
+                txid,
+                vout,
+            })
+        })
+        .buffered(MAX_CONCURRENT_TXS)
+        .try_collect()
+        .await
+}
 
-    let tx: RawVaultTx = serde_json::from_value(rep)
-        .context("Cannot parse getrawtransaction reply")
+// Only the block hash of the verbose getrawtransaction reply
+#[derive(Serialize, Deserialize)]
+struct TxBlockInfo {
+    hex: String,
+    #[serde(rename = "blockhash")]
+    block_hash: Option<String>,
+}
+
+// Only the fields of the verbose getblock reply that go in the details
+#[derive(Serialize, Deserialize)]
+struct BlockInfo {
+    hash: String,
+    height: u32,
+    time: u32,
+    // -1 if the block is not on the best chain
+    confirmations: i64,
+    tx: Vec<String>,
+}
+
+async fn get_block_info(config: &Config, hash: &str) -> Result<BlockInfo, ZcashError> {
+    let id = Uuid::new_v4().to_string();
+    let rep = json_request(config, &id, "getblock", vec![hash.into(), 1.into()])
+        .await
         .map_err(map_rpc_error)?;
-    let mut tx: VaultTxDetails = tx.into();
-    if tx.touts.iter().any(|o| o.address == vault_addr) {
-        tx.resolve_inputs(&config).await?;
-        let txd = tx.decrypt(&network, to_ba(&ovk)?)?;
-        let tx = VaultTx::from_decrypted(txid.height, &txd, &vault_addr)?;
-        tracing::info!("{:?}", tx);
-        return Ok(Some(tx));
+    let block: BlockInfo = serde_json::from_value(rep)
+        .map_err(to_zcasherror(anyhow!("Failed to parse getblock reply")))?;
+    if block.confirmations < 0 {
+        return Err(ZcashError::Reorg);
     }
-    Ok(None)
+    Ok(block)
+}
+
+/// The detail of a vault tx mined in `block`, or in the mempool
+/// if `block` is None
+fn tx_detail(
+    network: &Network,
+    vtx: VaultTx,
+    tx: &Transaction,
+    inputs: Vec<TxInput>,
+    vault: &VaultKeys,
+    block: Option<&BlockInfo>,
+) -> Result<VaultTxDetail, ZcashError> {
+    let outputs = vault_outputs(network, tx, vault)?.outputs;
+
+    let tin_total = inputs.iter().map(|i| i.value).sum::<u64>() as i64;
+    let tout_total = tx_outputs(tx)
+        .iter()
+        .map(|o| o.value.into_u64())
+        .sum::<u64>() as i64;
+    // a negative fee means that some inputs are missing
+    let fee = u64::try_from(tin_total + vtx.shielded_value_balance - tout_total)
+        .map_err(|_| ZcashError::AssertError(format!("Missing inputs for {}", vtx.txid)))?;
+
+    let (block_hash, block_time, position, confirmations) = match block {
+        Some(block) => {
+            let position = block
+                .tx
+                .iter()
+                .position(|id| id == &vtx.txid)
+                .ok_or(ZcashError::Reorg)? as u32;
+            (
+                block.hash.clone(),
+                block.time,
+                position,
+                block.confirmations as u32,
+            )
+        }
+        None => (String::new(), 0, 0, 0),
+    };
+
+    Ok(VaultTxDetail {
+        tx: vtx,
+        inputs,
+        outputs,
+        fee,
+        block_hash,
+        block_time,
+        position,
+        confirmations,
+    })
+}
+
+pub fn get_vault_tx_detail(
+    pubkey: Vec<u8>,
+    txid: String,
+    known_vaults: Vec<Vec<u8>>,
+) -> Result<VaultTxDetail, ZcashError> {
+    let vault = VaultKeys::new(pubkey)?;
+    let known_vaults = known_vault_addresses(known_vaults)?;
+    uniffi_async_export!(context, {
+        let config = &context.config;
+        let network = config.network();
+        let id = Uuid::new_v4().to_string();
+        let rep = json_request(
+            config,
+            &id,
+            "getrawtransaction",
+            vec![txid.clone().into(), 1.into()],
+        )
+        .await
+        .map_err(map_rpc_error)?;
+        let info: TxBlockInfo = serde_json::from_value(rep)
+            .context("Cannot parse getrawtransaction reply")
+            .map_err(map_rpc_error)?;
+        let tx = read_tx(&hex::decode(&info.hex).map_err(to_zcasherror(anyhow!(
+            "Cannot parse getrawtransaction reply"
+        )))?)?;
+        let inputs = fetch_prevouts(config, &tx).await?;
+
+        let block = match info.block_hash.as_ref() {
+            Some(block_hash) => Some(get_block_info(config, block_hash).await?),
+            None => None,
+        };
+        let height = block.as_ref().map(|b| b.height).unwrap_or_default();
+
+        let vtx = analyze_tx(&network, &tx, &inputs, height, &vault, &known_vaults)?.ok_or(
+            ZcashError::AssertError(format!("{txid} does not belong to the vault")),
+        )?;
+        tx_detail(&network, vtx, &tx, inputs, &vault, block.as_ref())
+    })
 }
 
 pub struct BlockTxs {
@@ -492,10 +739,21 @@ pub struct BlockTxs {
     pub txs: Vec<VaultTx>,
 }
 
+/// `BlockTxs` with the detail of every tx
+pub struct BlockTxDetails {
+    pub start_hash: String,
+    pub end_hash: String,
+    pub start_height: u32,
+    pub end_height: u32,
+    pub txs: Vec<VaultTxDetail>,
+}
+
 #[derive(Serialize, Deserialize, Debug)]
 struct BlockHeader {
     hash: String,
     height: u32,
+    // -1 if the block is not on the best chain
+    confirmations: i64,
     #[serde(rename = "previousblockhash")]
     prev_hash: String,
     #[serde(rename = "nextblockhash")]
@@ -524,12 +782,24 @@ struct AddressDeltas {
 
 pub fn scan_blocks(
     pubkey: Vec<u8>,
+    prev_hashes: Vec<String>,
+    known_vaults: Vec<Vec<u8>>,
+) -> Result<Option<BlockTxs>, ZcashError> {
+    scan_blocks_multi(vec![pubkey], prev_hashes, known_vaults)
+}
+
+/// Scan the new blocks for several vaults at once
+/// The address deltas of all the vaults are queried together
+/// and each tx is fetched once
+pub fn scan_blocks_multi(
+    pubkeys: Vec<Vec<u8>>,
     mut prev_hashes: Vec<String>,
+    known_vaults: Vec<Vec<u8>>,
 ) -> Result<Option<BlockTxs>, ZcashError> {
+    let vaults = VaultKeys::from_pubkeys(pubkeys)?;
+    let known_vaults = known_vault_addresses(known_vaults)?;
     uniffi_async_export!(context, {
         let config = &context.config;
-        let vault_addr = get_vault_address(pubkey.clone())?;
-        let ovk = get_ovk(pubkey)?;
 
         if prev_hashes.is_empty() {
             let id = Uuid::new_v4().to_string();
@@ -545,21 +815,116 @@ pub fn scan_blocks(
             prev_hashes = vec![genesis_hash];
         }
 
+        // only an orphaned block moves on to the next hash
         for prev_hash in prev_hashes {
-            if let Ok(block_txs) = scan_blocks_async(&context, &vault_addr, &ovk, prev_hash).await {
-                return Ok(block_txs);
+            match scan_blocks_async(&context, &vaults, &known_vaults, prev_hash, None).await {
+                Err(ZcashError::Reorg) => continue,
+                res => return res,
             }
         }
 
-        Err(ZcashError::AssertError("Not reachable".into()))
+        // none of the hashes is in the best chain
+        Err(ZcashError::Reorg)
     })
 }
 
-async fn scan_blocks_async(
+/// Scan at most `max_blocks` blocks after `from_hash`
+/// Returns None if `from_hash` is the tip and a Reorg error
+/// if it is no longer on the best chain
+/// Use the `end_hash` of the result as the `from_hash` of
+/// the next call to page through the blockchain
+pub fn scan_block_range(
+    pubkey: Vec<u8>,
+    from_hash: String,
+    max_blocks: u32,
+    known_vaults: Vec<Vec<u8>>,
+) -> Result<Option<BlockTxs>, ZcashError> {
+    if max_blocks == 0 {
+        return Err(ZcashError::AssertError(
+            "max_blocks must be positive".into(),
+        ));
+    }
+    let vaults = vec![VaultKeys::new(pubkey)?];
+    let known_vaults = known_vault_addresses(known_vaults)?;
+    uniffi_async_export!(context, {
+        scan_blocks_async(
+            &context,
+            &vaults,
+            &known_vaults,
+            from_hash,
+            Some(max_blocks),
+        )
+        .await
+    })
+}
+
+/// Same as `scan_block_range` but with the detail of every tx
+/// The txs and their inputs come from the cache filled by the scan,
+/// only the blocks with vault txs are fetched again
+pub fn scan_block_range_detail(
+    pubkey: Vec<u8>,
+    from_hash: String,
+    max_blocks: u32,
+    known_vaults: Vec<Vec<u8>>,
+) -> Result<Option<BlockTxDetails>, ZcashError> {
+    if max_blocks == 0 {
+        return Err(ZcashError::AssertError(
+            "max_blocks must be positive".into(),
+        ));
+    }
+    let vault = VaultKeys::new(pubkey)?;
+    let known_vaults = known_vault_addresses(known_vaults)?;
+    uniffi_async_export!(context, {
+        let config = &context.config;
+        let network = config.network();
+        let Some(btxs) = scan_blocks_async(
+            &context,
+            std::slice::from_ref(&vault),
+            &known_vaults,
+            from_hash,
+            Some(max_blocks),
+        )
+        .await?
+        else {
+            return Ok(None);
+        };
+
+        let mut blocks: HashMap<u32, BlockInfo> = HashMap::new();
+        let mut txs = vec![];
+        for vtx in btxs.txs {
+            let height = vtx.height;
+            if !blocks.contains_key(&height) {
+                let hash = get_block_hash(config, height).await?;
+                blocks.insert(height, get_block_info(config, &hash).await?);
+            }
+            let tx = fetch_raw_tx(config, &vtx.txid).await?;
+            let inputs = fetch_prevouts(config, &tx).await?;
+            txs.push(tx_detail(
+                &network,
+                vtx,
+                &tx,
+                inputs,
+                &vault,
+                blocks.get(&height),
+            )?);
+        }
+
+        Ok(Some(BlockTxDetails {
+            start_hash: btxs.start_hash,
+            end_hash: btxs.end_hash,
+            start_height: btxs.start_height,
+            end_height: btxs.end_height,
+            txs,
+        }))
+    })
+}
+
+pub(crate) async fn scan_blocks_async(
     context: &Context,
-    vault_addr: &str,
-    ovk: &[u8],
+    vaults: &[VaultKeys],
+    known_vaults: &HashSet<String>,
     prev_hash: String,
+    max_blocks: Option<u32>,
 ) -> Result<Option<BlockTxs>, ZcashError> {
     let config = &context.config;
     let id = Uuid::new_v4().to_string();
@@ -569,26 +934,44 @@ async fn scan_blocks_async(
     let block_header: BlockHeader = serde_json::from_value(rep).map_err(to_zcasherror(anyhow!(
         "Failed to parse getblockheader reply"
     )))?;
+    // an orphaned block has no next block either
+    if block_header.confirmations < 0 {
+        return Err(ZcashError::Reorg);
+    }
     let Some(next_hash) = block_header.next_hash else {
         return Ok(None);
     };
     let start_height = block_header.height + 1;
 
+    // the node rejects a range that goes past the tip
+    let id = Uuid::new_v4().to_string();
+    let rep = json_request(config, &id, "getblockcount", vec![])
+        .await
+        .map_err(map_rpc_error)?;
+    let tip_height = rep.as_u64().ok_or(ZcashError::AssertError(
+        "Failed to retrieve block count".into(),
+    ))? as u32;
+    let end_height = match max_blocks {
+        Some(max_blocks) => tip_height.min(start_height + max_blocks - 1),
+        None => tip_height,
+    };
+
+    let addresses = vaults.iter().map(|v| v.address.clone()).collect::<Vec<_>>();
     let id = Uuid::new_v4().to_string();
     let rep = json_request(
         config,
         &id,
         "getaddressdeltas",
         vec![json!({
-            "addresses": [ vault_addr ],
+            "addresses": addresses,
             "start": start_height,
-            "end": 0,
+            "end": end_height,
             "chainInfo": true
         })],
     )
     .await
     .map_err(map_rpc_error)?;
-    let deltas: AddressDeltas = serde_json::from_value(rep).map_err(to_zcasherror(anyhow!(
+    let mut deltas: AddressDeltas = serde_json::from_value(rep).map_err(to_zcasherror(anyhow!(
         "Failed to parse getaddressdeltas reply"
     )))?;
 
@@ -596,26 +979,179 @@ async fn scan_blocks_async(
         return Err(ZcashError::Reorg);
     }
 
-    for txid in deltas.txids {
-        tracing::info!(">> {} {}", txid.height, txid.txid);
-        process_tx(config, &txid, &vault_addr, &ovk).await?;
-    }
+    let mut txids = std::mem::take(&mut deltas.txids);
+    txids.extend(get_shielded_txids(config, vaults, deltas.start.height, deltas.end.height).await?);
+    let txs = process_deltas(config, txids, vaults, known_vaults).await?;
     let btxs = BlockTxs {
         start_hash: deltas.start.hash,
         end_hash: deltas.end.hash,
         start_height: deltas.start.height,
         end_height: deltas.end.height,
-        txs: vec![],
+        txs,
     };
     Ok(Some(btxs))
 }
 
-fn memo_to_string(memo: &[u8]) -> String {
-    let memo = MemoBytes::from_bytes(memo).unwrap();
-    let memo: Memo = memo.try_into().unwrap();
-    let memo = match memo {
-        Memo::Text(memo) => memo.to_string(),
-        _ => String::new(),
-    };
-    memo
+/// Vault txs of the block `hash` and the hash of its parent
+/// Reorg if the block is not in the best chain while it is scanned
+pub(crate) async fn scan_block(
+    config: &Config,
+    vaults: &[VaultKeys],
+    known_vaults: &HashSet<String>,
+    hash: &str,
+) -> Result<(String, Vec<VaultTx>), ZcashError> {
+    let id = Uuid::new_v4().to_string();
+    let rep = json_request(config, &id, "getblockheader", vec![hash.into()])
+        .await
+        .map_err(map_rpc_error)?;
+    let header: BlockHeader = serde_json::from_value(rep).map_err(to_zcasherror(anyhow!(
+        "Failed to parse getblockheader reply"
+    )))?;
+    if header.confirmations < 0 {
+        return Err(ZcashError::Reorg);
+    }
+
+    // the address index is by height, check that it is still this block
+    let addresses = vaults.iter().map(|v| v.address.clone()).collect::<Vec<_>>();
+    let id = Uuid::new_v4().to_string();
+    let rep = json_request(
+        config,
+        &id,
+        "getaddressdeltas",
+        vec![json!({
+            "addresses": addresses,
+            "start": header.height,
+            "end": header.height,
+            "chainInfo": true
+        })],
+    )
+    .await
+    .map_err(map_rpc_error)?;
+    let mut deltas: AddressDeltas = serde_json::from_value(rep).map_err(to_zcasherror(anyhow!(
+        "Failed to parse getaddressdeltas reply"
+    )))?;
+    if deltas.start.hash != hash {
+        return Err(ZcashError::Reorg);
+    }
+    let mut txids = std::mem::take(&mut deltas.txids);
+    if vaults.iter().any(|v| v.viewing_key.is_some()) {
+        txids.extend(block_shielded_txids(config, vaults, hash, header.height).await?);
+    }
+    let txs = process_deltas(config, txids, vaults, known_vaults).await?;
+    Ok((header.prev_hash, txs))
+}
+
+/// Txs of the blocks between `start_height` and `end_height`
+/// with notes for the viewing keys of the vaults
+/// Unlike the transparent deposits, they are not in the address
+/// index and we have to fetch and trial decrypt every block
+async fn get_shielded_txids(
+    config: &Config,
+    vaults: &[VaultKeys],
+    start_height: u32,
+    end_height: u32,
+) -> Result<Vec<TxId>, ZcashError> {
+    if vaults.iter().all(|v| v.viewing_key.is_none()) {
+        return Ok(vec![]);
+    }
+    let txids: Vec<Vec<TxId>> = stream::iter(start_height..=end_height)
+        .map(|height| async move {
+            let hash = get_block_hash(config, height).await?;
+            block_shielded_txids(config, vaults, &hash, height).await
+        })
+        .buffered(MAX_CONCURRENT_TXS)
+        .try_collect()
+        .await?;
+    Ok(txids.into_iter().flatten().collect())
+}
+
+/// Txs of the block `hash` with notes for the viewing keys
+async fn block_shielded_txids(
+    config: &Config,
+    vaults: &[VaultKeys],
+    hash: &str,
+    height: u32,
+) -> Result<Vec<TxId>, ZcashError> {
+    let (_, txs) = get_block(config, hash).await?;
+    let mut txids = vec![];
+    for tx in txs {
+        if vaults.iter().any(|v| v.receives_notes(&tx)) {
+            let txid = tx.txid().to_string();
+            // process_tx will not have to fetch it again
+            TX_CACHE.lock().insert(txid.clone(), Arc::new(tx));
+            txids.push(TxId { txid, height });
+        }
+    }
+    Ok(txids)
+}
+
+async fn process_deltas(
+    config: &Config,
+    mut txids: Vec<TxId>,
+    vaults: &[VaultKeys],
+    known_vaults: &HashSet<String>,
+) -> Result<Vec<VaultTx>, ZcashError> {
+    // the deltas are grouped by address, put them back
+    // in chain order
+    txids.sort_by_key(|txid| txid.height);
+    // there is a delta for every input and output of the vaults
+    // but we want each tx only once
+    let mut seen = HashSet::new();
+    txids.retain(|txid| seen.insert(txid.txid.clone()));
+    // the txs are processed concurrently but their
+    // results are kept in chain order
+    let txs: Vec<Vec<VaultTx>> = stream::iter(txids.iter())
+        .map(|txid| {
+            tracing::info!(">> {} {}", txid.height, txid.txid);
+            process_tx(config, txid, vaults, known_vaults)
+        })
+        .buffered(MAX_CONCURRENT_TXS)
+        .try_collect()
+        .await?;
+    Ok(txs.into_iter().flatten().collect())
+}
+
+#[cfg(test)]
+mod tests {
+    use std::sync::atomic::{AtomicU32, Ordering};
+
+    use crate::{analyze::tests::DEPOSIT, config::Server, notify::tests::fake_node};
+
+    use super::*;
+
+    #[test]
+    fn test_fetch_raw_tx_once() {
+        let requests = Arc::new(AtomicU32::new(0));
+        let node = {
+            let requests = requests.clone();
+            fake_node(move |method, _params| {
+                assert_eq!(method, "getrawtransaction");
+                requests.fetch_add(1, Ordering::SeqCst);
+                Ok(json!(DEPOSIT))
+            })
+        };
+        let config = Config {
+            server: Server {
+                host: node,
+                user: String::new(),
+                password: String::new(),
+            },
+            mainnet: false,
+            sapling_params_dir: String::new(),
+            zmq: None,
+            tx_cache_size: None,
+            rng_seed: None,
+        };
+        // a key that no other test fetches
+        let txid = "fetch-once";
+        let runtime = tokio::runtime::Runtime::new().unwrap();
+        let txs = runtime
+            .block_on(futures::future::try_join_all(
+                (0..4).map(|_| fetch_raw_tx(&config, txid)),
+            ))
+            .unwrap();
+        assert_eq!(requests.load(Ordering::SeqCst), 1);
+        assert!(txs.iter().all(|tx| Arc::ptr_eq(tx, &txs[0])));
+        assert!(!IN_FLIGHT_TXS.lock().contains_key(txid));
+    }
 }
@@ -0,0 +1,240 @@
+use zcash_keys::encoding::AddressCodec as _;
+use zcash_primitives::legacy::TransparentAddress;
+
+use crate::{network::Network, uniffi_export};
+
+const OP_PUSHDATA1: u8 = 0x4c;
+const OP_PUSHDATA2: u8 = 0x4d;
+const OP_PUSHDATA4: u8 = 0x4e;
+const OP_RETURN: u8 = 0x6a;
+const OP_EQUAL: u8 = 0x87;
+const OP_EQUALVERIFY: u8 = 0x88;
+const OP_HASH160: u8 = 0xa9;
+const OP_DUP: u8 = 0x76;
+const OP_CHECKSIG: u8 = 0xac;
+
+pub enum ScriptKind {
+    P2PKH,
+    P2SH,
+    OpReturn,
+    Unknown,
+}
+
+/// A decoded scriptPubKey
+/// `address` is only set for P2PKH and P2SH scripts.
+/// `data` has the pushes of an OP_RETURN script put together and
+/// `text` the same data if it is valid UTF-8, empty otherwise
+pub struct ScriptPubKey {
+    pub kind: ScriptKind,
+    pub address: String,
+    pub data: Vec<u8>,
+    pub text: String,
+}
+
+pub(crate) enum ParsedScript {
+    P2PKH([u8; 20]),
+    P2SH([u8; 20]),
+    OpReturn(Vec<u8>),
+    Unknown,
+}
+
+pub fn parse_script_pubkey(script: Vec<u8>) -> ScriptPubKey {
+    uniffi_export!(context, {
+        let network = context.config.network();
+        let address = script_address(&network, &script);
+        match parse_script(&script) {
+            ParsedScript::P2PKH(_) => ScriptPubKey {
+                kind: ScriptKind::P2PKH,
+                address,
+                data: vec![],
+                text: String::new(),
+            },
+            ParsedScript::P2SH(_) => ScriptPubKey {
+                kind: ScriptKind::P2SH,
+                address,
+                data: vec![],
+                text: String::new(),
+            },
+            ParsedScript::OpReturn(data) => ScriptPubKey {
+                kind: ScriptKind::OpReturn,
+                address,
+                text: String::from_utf8(data.clone()).unwrap_or_default(),
+                data,
+            },
+            ParsedScript::Unknown => ScriptPubKey {
+                kind: ScriptKind::Unknown,
+                address,
+                data: vec![],
+                text: String::new(),
+            },
+        }
+    })
+}
+
+/// Only the standard templates are recognized: an OP_RETURN script
+/// must be followed by data pushes only
+pub(crate) fn parse_script(script: &[u8]) -> ParsedScript {
+    match script {
+        [OP_DUP, OP_HASH160, 20, hash @ .., OP_EQUALVERIFY, OP_CHECKSIG] if hash.len() == 20 => {
+            ParsedScript::P2PKH(hash.try_into().unwrap())
+        }
+        [OP_HASH160, 20, hash @ .., OP_EQUAL] if hash.len() == 20 => {
+            ParsedScript::P2SH(hash.try_into().unwrap())
+        }
+        [OP_RETURN, pushes @ ..] => match read_pushes(pushes) {
+            Some(pushes) => ParsedScript::OpReturn(pushes.concat()),
+            None => ParsedScript::Unknown,
+        },
+        _ => ParsedScript::Unknown,
+    }
+}
+
+/// Address of a P2PKH or P2SH script, empty for other scripts
+pub(crate) fn script_address(network: &Network, script: &[u8]) -> String {
+    let address = match parse_script(script) {
+        ParsedScript::P2PKH(hash) => TransparentAddress::PublicKeyHash(hash),
+        ParsedScript::P2SH(hash) => TransparentAddress::ScriptHash(hash),
+        _ => return String::new(),
+    };
+    address.encode(network)
+}
+
+/// Split a script made of data pushes
+/// None if it has another opcode or a truncated push
+fn read_pushes(mut script: &[u8]) -> Option<Vec<&[u8]>> {
+    let mut pushes = vec![];
+    while let Some((&op, rest)) = script.split_first() {
+        let (len, rest) = match op {
+            0..=0x4b => (op as usize, rest),
+            OP_PUSHDATA1 => (*rest.first()? as usize, &rest[1..]),
+            OP_PUSHDATA2 => (
+                u16::from_le_bytes(rest.get(..2)?.try_into().unwrap()) as usize,
+                &rest[2..],
+            ),
+            OP_PUSHDATA4 => (
+                u32::from_le_bytes(rest.get(..4)?.try_into().unwrap()) as usize,
+                &rest[4..],
+            ),
+            _ => return None,
+        };
+        pushes.push(rest.get(..len)?);
+        script = &rest[len..];
+    }
+    Some(pushes)
+}
+
+#[cfg(test)]
+mod tests {
+    use crate::memo::Memo;
+
+    use super::*;
+
+    fn op_return_data(script: &str) -> Option<Vec<u8>> {
+        match parse_script(&hex::decode(script).unwrap()) {
+            ParsedScript::OpReturn(data) => Some(data),
+            _ => None,
+        }
+    }
+
+    #[test]
+    fn test_addresses() {
+        // the vault of the tests, on mainnet and regtest
+        let script = hex::decode("76a914e6d4b9d2c408bf6bd44523b3b6607de4853b806088ac").unwrap();
+        assert_eq!(
+            script_address(&Network::Main, &script),
+            "t1ev8Fuh8t1bqheZZa7974j5jwKCjVcP7Pq"
+        );
+        assert_eq!(
+            script_address(&Network::Regtest, &script),
+            "tmWksakBYGg7Lqtm1EqSqvPkVYJHYxGq6Za"
+        );
+        let script = hex::decode("76a91458d03d802ea3fa31cdb55dffd717d82944ff9b7c88ac").unwrap();
+        assert_eq!(
+            script_address(&Network::Main, &script),
+            "t1RyCw14wRXrh3mp21uxgr9ynjem7cNUkMH"
+        );
+        let script = hex::decode("a914e6d4b9d2c408bf6bd44523b3b6607de4853b806087").unwrap();
+        assert!(matches!(parse_script(&script), ParsedScript::P2SH(_)));
+        assert_eq!(
+            script_address(&Network::Main, &script),
+            "t3fc9BTBaRuvDnpFzhCohVMSg5qVT4ATXdq"
+        );
+        // one byte short
+        let script = hex::decode("76a914e6d4b9d2c408bf6bd44523b3b6607de4853b8088ac").unwrap();
+        assert!(matches!(parse_script(&script), ParsedScript::Unknown));
+        assert_eq!(script_address(&Network::Main, &script), "");
+    }
+
+    #[test]
+    fn test_op_return() {
+        // the memo of the regtest deposit
+        assert_eq!(op_return_data("6a044d454d4f").unwrap(), b"MEMO");
+        // a swap memo of 76 bytes needs OP_PUSHDATA1
+        let memo = "=:ETH.ETH:0x9d2a2ea4ff4d2d8d7fc7bd2b8f1dab8e8b4a3f4c:1234567890/1/0:mayac:15";
+        let mut script = vec![OP_RETURN, OP_PUSHDATA1, memo.len() as u8];
+        script.extend_from_slice(memo.as_bytes());
+        assert_eq!(
+            op_return_data(&hex::encode(&script)).unwrap(),
+            memo.as_bytes()
+        );
+        // same with OP_PUSHDATA2 and split over 2 pushes
+        let mut script = vec![OP_RETURN, OP_PUSHDATA2, 2, 0];
+        script.extend_from_slice(b"=:");
+        script.push(8);
+        script.extend_from_slice(b"ZEC.ZEC:");
+        assert_eq!(
+            op_return_data(&hex::encode(&script)).unwrap(),
+            b"=:ZEC.ZEC:"
+        );
+        // OP_0 and a bare OP_RETURN carry no data
+        assert_eq!(op_return_data("6a00").unwrap(), b"");
+        assert_eq!(op_return_data("6a").unwrap(), b"");
+        // binary data is kept
+        assert_eq!(op_return_data("6a02ff00").unwrap(), vec![0xff, 0x00]);
+
+        // truncated pushes and other opcodes
+        assert!(op_return_data("6a05414243").is_none());
+        assert!(op_return_data("6a4c").is_none());
+        assert!(op_return_data("6a4d0100").is_none());
+        assert!(op_return_data("6a0141ac").is_none());
+    }
+
+    #[test]
+    fn test_push_encodings() {
+        // the same memo with every push encoding a wallet may use,
+        // minimal or not
+        let memos = [
+            // OP_PUSHBYTES_10
+            "6a0a3d3a5a45432e5a45433a",
+            // OP_PUSHDATA1
+            "6a4c0a3d3a5a45432e5a45433a",
+            // OP_PUSHDATA2
+            "6a4d0a003d3a5a45432e5a45433a",
+            // OP_PUSHDATA4
+            "6a4e0a0000003d3a5a45432e5a45433a",
+            // split after the action, with an empty push in between
+            "6a023d3a00085a45432e5a45433a",
+            // split over a direct push and an OP_PUSHDATA1
+            "6a053d3a5a45434c052e5a45433a",
+        ];
+        for script in memos {
+            let data = op_return_data(script).unwrap();
+            assert_eq!(
+                Memo::from_op_return(data),
+                Memo::text("=:ZEC.ZEC:"),
+                "{script}"
+            );
+        }
+
+        // an 80 byte memo, the most a standard OP_RETURN carries
+        let memo =
+            "=:ZEC.ZEC:t1ev8Fuh8t1bqheZZa7974j5jwKCjVcP7Pq:1000000000/3/0:maya:50:maya:50:abc";
+        assert_eq!(memo.len(), 80);
+        let script = format!("6a4c50{}", hex::encode(memo));
+        let data = op_return_data(&script).unwrap();
+        assert_eq!(Memo::from_op_return(data), Memo::text(memo));
+
+        // a length that runs past the end of the script
+        assert!(op_return_data("6a4e0b0000003d3a5a45432e5a45433a").is_none());
+    }
+}
@@ -0,0 +1,573 @@
+use anyhow::anyhow;
+use incrementalmerkletree::Position;
+use orchard::{
+    builder::BundleType,
+    bundle::Flags,
+    circuit::ProvingKey,
+    keys::{FullViewingKey, Scope, SpendAuthorizingKey, SpendingKey},
+    note::{ExtractedNoteCommitment, RandomSeed, Rho},
+    tree::MerkleHashOrchard,
+    value::NoteValue,
+};
+use rand_chacha::ChaCha20Rng;
+use rand_core::{CryptoRng, RngCore, SeedableRng};
+use sapling_crypto::{
+    note_encryption::Zip212Enforcement,
+    prover::{OutputProver, SpendProver},
+    zip32::ExtendedSpendingKey,
+    Node, PaymentAddress, Rseed,
+};
+use zcash_keys::{address::Receiver, encoding::AddressCodec};
+use zcash_primitives::{
+    legacy::TransparentAddress,
+    transaction::{
+        components::transparent::builder::TransparentBuilder,
+        fees::{
+            transparent::InputSize,
+            zip317::{self, P2PKH_STANDARD_OUTPUT_SIZE},
+            FeeRule as _,
+        },
+        sighash::{signature_hash, SignableInput},
+        txid::TxIdDigester,
+        TransactionData, TxVersion,
+    },
+};
+use zcash_protocol::{
+    consensus::{BlockHeight, BranchId},
+    value::{ZatBalance as Amount, Zatoshis},
+};
+
+use crate::{
+    addr::get_vault_address,
+    config::tx_rng,
+    memo::Memo,
+    network::Network,
+    pay::{handle_receiver, TxBytes},
+    to_ba, to_zcasherror, uniffi_export, ZcashError,
+};
+
+/// A note owned by the user and its merkle path in the
+/// commitment tree of its pool (Sapling or Orchard).
+/// `rho` is only used by Orchard notes.
+/// `auth_path` has the 32 sibling hashes from the leaf to the root
+pub struct ShieldedNote {
+    pub recipient: Vec<u8>,
+    pub value: u64,
+    pub rho: Vec<u8>,
+    pub rseed: Vec<u8>,
+    pub position: u32,
+    pub auth_path: Vec<Vec<u8>>,
+}
+
+const SAPLING_BUNDLE: sapling_crypto::builder::BundleType =
+    sapling_crypto::builder::BundleType::Transactional {
+        bundle_required: false,
+    };
+const ORCHARD_BUNDLE: BundleType = BundleType::Transactional {
+    flags: Flags::ENABLED,
+    bundle_required: false,
+};
+
+/// Deposit into the vault from the user shielded notes.
+/// The vault receives `amount` on its transparent address with
+/// the memo in an OP_RETURN output and the change goes back to
+/// the internal address of the user, in the Orchard pool if
+/// Orchard notes are spent, in the Sapling pool otherwise.
+///
+/// `sapling_sk` is the encoding of a Sapling extended spending key
+/// and `orchard_sk` the bytes of an Orchard spending key.
+/// All the notes of a pool must have the same anchor.
+pub fn send_shielded_to_vault(
+    expiry_height: u32,
+    sapling_sk: Option<Vec<u8>>,
+    sapling_notes: Vec<ShieldedNote>,
+    orchard_sk: Option<Vec<u8>>,
+    orchard_notes: Vec<ShieldedNote>,
+    vault: Vec<u8>,
+    amount: u64,
+    memo: Memo,
+) -> Result<TxBytes, ZcashError> {
+    let to_addr = get_vault_address(vault)?;
+    uniffi_export!(context, {
+        let network = context.config.network();
+        let sapling_sk = sapling_sk
+            .map(|sk| {
+                ExtendedSpendingKey::from_bytes(&sk)
+                    .map_err(to_zcasherror(anyhow!("Invalid Sapling Spending Key")))
+            })
+            .transpose()?;
+        let orchard_sk = orchard_sk
+            .map(|sk| {
+                let sk = to_ba::<32>(&sk)?;
+                Option::from(SpendingKey::from_bytes(sk)).ok_or(ZcashError::AssertError(
+                    "Invalid Orchard Spending Key".into(),
+                ))
+            })
+            .transpose()?;
+        let prover = &context.sapling_prover;
+        build_shielded_deposit(
+            &network,
+            prover,
+            prover,
+            &context.orchard_prover,
+            expiry_height,
+            sapling_sk.as_ref(),
+            &sapling_notes,
+            orchard_sk.as_ref(),
+            &orchard_notes,
+            &to_addr,
+            amount,
+            &memo,
+            tx_rng(),
+        )
+    })
+}
+
+fn build_shielded_deposit<SP: SpendProver, OP: OutputProver, R: RngCore + CryptoRng>(
+    network: &Network,
+    spend_prover: &SP,
+    output_prover: &OP,
+    orchard_pk: &ProvingKey,
+    expiry_height: u32,
+    sapling_sk: Option<&ExtendedSpendingKey>,
+    sapling_notes: &[ShieldedNote],
+    orchard_sk: Option<&SpendingKey>,
+    orchard_notes: &[ShieldedNote],
+    to_addr: &str,
+    amount: u64,
+    memo: &Memo,
+    mut rng: R,
+) -> Result<TxBytes, ZcashError> {
+    let to_taddr = TransparentAddress::decode(network, to_addr)
+        .map_err(|_| ZcashError::InvalidAddress(to_addr.to_string()))?;
+    Zatoshis::from_u64(amount).map_err(to_zcasherror(anyhow!("Invalid amount: {amount} zats")))?;
+    memo.to_op_return()?;
+
+    let sapling_notes = sapling_notes
+        .iter()
+        .map(parse_sapling_note)
+        .collect::<Result<Vec<_>, _>>()?;
+    let orchard_notes = orchard_notes
+        .iter()
+        .map(parse_orchard_note)
+        .collect::<Result<Vec<_>, _>>()?;
+    let height = BlockHeight::from_u32(expiry_height);
+    let (sapling_notes, orchard_notes, change) =
+        select_notes(network, height, sapling_notes, orchard_notes, amount, memo)?;
+
+    let sapling_anchor = match sapling_notes.first() {
+        Some((note, path)) => {
+            let anchor = path.root(Node::from_cmu(&note.cmu()));
+            if sapling_notes
+                .iter()
+                .any(|(n, p)| p.root(Node::from_cmu(&n.cmu())) != anchor)
+            {
+                return Err(ZcashError::AssertError(
+                    "Sapling notes must have the same anchor".into(),
+                ));
+            }
+            sapling_crypto::Anchor::from(anchor)
+        }
+        None => sapling_crypto::Anchor::empty_tree(),
+    };
+    let orchard_anchor = match orchard_notes.first() {
+        Some((note, path)) => {
+            let anchor = path.root(ExtractedNoteCommitment::from(note.commitment()));
+            if orchard_notes
+                .iter()
+                .any(|(n, p)| p.root(ExtractedNoteCommitment::from(n.commitment())) != anchor)
+            {
+                return Err(ZcashError::AssertError(
+                    "Orchard notes must have the same anchor".into(),
+                ));
+            }
+            anchor
+        }
+        None => orchard::Anchor::empty_tree(),
+    };
+
+    let mut tbuilder = TransparentBuilder::empty();
+    let mut sbuilder = sapling_crypto::builder::Builder::new(
+        Zip212Enforcement::On,
+        SAPLING_BUNDLE,
+        sapling_anchor,
+    );
+    let mut obuilder = orchard::builder::Builder::new(ORCHARD_BUNDLE, orchard_anchor);
+
+    let mut sapling_asks = vec![];
+    if !sapling_notes.is_empty() {
+        let sk = sapling_sk.ok_or(ZcashError::AssertError(
+            "Missing Sapling Spending Key".into(),
+        ))?;
+        let fvk = sk.to_diversifiable_full_viewing_key().fvk().clone();
+        for (note, merkle_path) in sapling_notes.iter() {
+            sbuilder
+                .add_spend(fvk.clone(), note.clone(), merkle_path.clone())
+                .map_err(|e| ZcashError::AssertError(e.to_string()))?;
+        }
+        sapling_asks.push(sk.expsk.ask.clone());
+    }
+    let mut orchard_asks = vec![];
+    if !orchard_notes.is_empty() {
+        let sk = orchard_sk.ok_or(ZcashError::AssertError(
+            "Missing Orchard Spending Key".into(),
+        ))?;
+        let fvk = FullViewingKey::from(sk);
+        for (note, merkle_path) in orchard_notes.iter() {
+            obuilder
+                .add_spend(fvk.clone(), *note, merkle_path.clone())
+                .map_err(|e| ZcashError::AssertError(e.to_string()))?;
+        }
+        orchard_asks.push(SpendAuthorizingKey::from(sk));
+    }
+
+    handle_receiver(
+        Receiver::Transparent(to_taddr),
+        amount,
+        memo,
+        &[0u8; 32], // not used by transparent receivers
+        &mut tbuilder,
+        &mut sbuilder,
+        &mut obuilder,
+    )?;
+
+    if change > 0 {
+        if !orchard_notes.is_empty() {
+            let fvk = FullViewingKey::from(orchard_sk.unwrap());
+            obuilder
+                .add_output(
+                    Some(fvk.to_ovk(Scope::Internal)),
+                    fvk.address_at(0u32, Scope::Internal),
+                    NoteValue::from_raw(change),
+                    None,
+                )
+                .map_err(|e| ZcashError::AssertError(e.to_string()))?;
+        } else {
+            let dfvk = sapling_sk.unwrap().to_diversifiable_full_viewing_key();
+            sbuilder
+                .add_output(
+                    Some(dfvk.to_ovk(Scope::Internal)),
+                    dfvk.change_address().1,
+                    sapling_crypto::value::NoteValue::from_raw(change),
+                    None,
+                )
+                .map_err(|e| ZcashError::AssertError(e.to_string()))?;
+        }
+    }
+
+    let tbundle = tbuilder.build();
+    let sbundle = sbuilder
+        .build::<SP, OP, _, Amount>(&mut rng)
+        .map_err(to_zcasherror(anyhow!("Cannot build sapling bundle")))?
+        .map(|(bundle, _)| bundle.create_proofs(spend_prover, output_prover, &mut rng, ()));
+    let obundle = obuilder
+        .build::<Amount>(&mut rng)
+        .map_err(to_zcasherror(anyhow!("Cannot build orchard bundle")))?
+        .map(|v| v.0);
+
+    let consensus_branch_id = BranchId::for_height(network, height);
+    let version = TxVersion::suggested_for_branch(consensus_branch_id);
+    let unauthed_tx: TransactionData<zcash_primitives::transaction::Unauthorized> =
+        TransactionData::from_parts(
+            version,
+            consensus_branch_id,
+            0,
+            height,
+            tbundle,
+            None,
+            sbundle,
+            obundle,
+        );
+
+    let txid_parts = unauthed_tx.digest(TxIdDigester);
+    let sighash = signature_hash(&unauthed_tx, &SignableInput::Shielded, &txid_parts)
+        .as_ref()
+        .clone();
+
+    let mut sapling_rng = ChaCha20Rng::from_rng(&mut rng).unwrap();
+    let mut orchard_rng = ChaCha20Rng::from_rng(&mut rng).unwrap();
+    let tx_data: TransactionData<zcash_primitives::transaction::Authorized> = unauthed_tx
+        .map_bundles(
+            |tb| tb.map(|tb| tb.apply_external_signatures(vec![])),
+            |sb| {
+                sb.map(|sb| {
+                    sb.apply_signatures(&mut sapling_rng, sighash, &sapling_asks)
+                        .unwrap()
+                })
+            },
+            |ob| {
+                ob.map(|ob| {
+                    let ob = ob.create_proof(orchard_pk, &mut orchard_rng).unwrap();
+                    ob.apply_signatures(&mut orchard_rng, sighash, &orchard_asks)
+                        .unwrap()
+                })
+            },
+        );
+
+    let tx = tx_data.freeze().unwrap();
+    let txid = tx.txid().to_string();
+    let mut data = vec![];
+    tx.write(&mut data).unwrap();
+
+    Ok(TxBytes { txid, data })
+}
+
+/// ZIP-317 conventional fee of a deposit spending `num_sapling`
+/// and `num_orchard` notes, with the padding of the bundles:
+/// Sapling outputs and Orchard actions come by two at least.
+/// The change goes to the Orchard pool if Orchard notes are spent
+fn deposit_fee(
+    network: &Network,
+    height: BlockHeight,
+    memo: &Memo,
+    num_sapling: usize,
+    num_orchard: usize,
+) -> Result<u64, ZcashError> {
+    let (sapling_change, orchard_change) = if num_orchard > 0 { (0, 1) } else { (1, 0) };
+    let sapling_outputs = SAPLING_BUNDLE
+        .num_outputs(num_sapling, sapling_change)
+        .map_err(|e| ZcashError::AssertError(e.to_string()))?;
+    let orchard_actions = ORCHARD_BUNDLE
+        .num_actions(num_orchard, orchard_change)
+        .map_err(|e| ZcashError::AssertError(e.to_string()))?;
+    let mut tout_sizes = vec![P2PKH_STANDARD_OUTPUT_SIZE];
+    if !memo.is_empty() {
+        tout_sizes.push(op_return_output_size(memo.len()));
+    }
+    let fee = zip317::FeeRule::standard()
+        .fee_required(
+            network,
+            height,
+            std::iter::empty::<InputSize>(),
+            tout_sizes,
+            num_sapling,
+            sapling_outputs,
+            orchard_actions,
+        )
+        .map_err(|e| ZcashError::AssertError(e.to_string()))?;
+    Ok(fee.into_u64())
+}
+
+/// Serialized size of an OP_RETURN output with `len` bytes of data
+/// (value, script length, OP_RETURN and the push of the data)
+fn op_return_output_size(len: usize) -> usize {
+    let push = if len < 0x4C { 1 } else { 2 }; // OP_PUSHDATA1 above 75 bytes
+    let script_len = 1 + push + len;
+    8 + 1 + script_len
+}
+
+/// Pick the notes that pay for the deposit, Orchard first.
+/// Returns the selected notes and the change
+fn select_notes(
+    network: &Network,
+    height: BlockHeight,
+    sapling_notes: Vec<(sapling_crypto::Note, sapling_crypto::MerklePath)>,
+    orchard_notes: Vec<(orchard::Note, orchard::tree::MerklePath)>,
+    amount: u64,
+    memo: &Memo,
+) -> Result<
+    (
+        Vec<(sapling_crypto::Note, sapling_crypto::MerklePath)>,
+        Vec<(orchard::Note, orchard::tree::MerklePath)>,
+        u64,
+    ),
+    ZcashError,
+> {
+    let fee = |num_sapling: usize, num_orchard: usize| {
+        deposit_fee(network, height, memo, num_sapling, num_orchard)
+    };
+
+    let mut selected_orchard = vec![];
+    let mut selected_sapling = vec![];
+    let mut total = 0u64;
+    for n in orchard_notes {
+        if total >= amount + fee(selected_sapling.len(), selected_orchard.len())? {
+            break;
+        }
+        total += n.0.value().inner();
+        selected_orchard.push(n);
+    }
+    for n in sapling_notes {
+        if total >= amount + fee(selected_sapling.len(), selected_orchard.len())? {
+            break;
+        }
+        total += n.0.value().inner();
+        selected_sapling.push(n);
+    }
+    let f = fee(selected_sapling.len(), selected_orchard.len())?;
+    if total < amount + f {
+        return Err(ZcashError::NotEnoughFunds);
+    }
+    let change = total - amount - f;
+
+    Ok((selected_sapling, selected_orchard, change))
+}
+
+fn parse_sapling_note(
+    n: &ShieldedNote,
+) -> Result<(sapling_crypto::Note, sapling_crypto::MerklePath), ZcashError> {
+    let recipient = PaymentAddress::from_bytes(&to_ba(&n.recipient)?)
+        .ok_or(ZcashError::AssertError("Invalid Sapling recipient".into()))?;
+    let note = sapling_crypto::Note::from_parts(
+        recipient,
+        sapling_crypto::value::NoteValue::from_raw(n.value),
+        Rseed::AfterZip212(to_ba(&n.rseed)?),
+    );
+    let auth_path = n
+        .auth_path
+        .iter()
+        .map(|h| {
+            Option::from(Node::from_bytes(to_ba(h)?)).ok_or(ZcashError::AssertError(
+                "Invalid Sapling merkle path".into(),
+            ))
+        })
+        .collect::<Result<Vec<_>, _>>()?;
+    let merkle_path =
+        sapling_crypto::MerklePath::from_parts(auth_path, Position::from(n.position as u64))
+            .map_err(|_| ZcashError::AssertError("Invalid Sapling merkle path".into()))?;
+    Ok((note, merkle_path))
+}
+
+fn parse_orchard_note(
+    n: &ShieldedNote,
+) -> Result<(orchard::Note, orchard::tree::MerklePath), ZcashError> {
+    let invalid = || ZcashError::AssertError("Invalid Orchard note".into());
+    let recipient = Option::from(orchard::Address::from_raw_address_bytes(&to_ba(
+        &n.recipient,
+    )?))
+    .ok_or_else(invalid)?;
+    let rho: Rho = Option::from(Rho::from_bytes(&to_ba(&n.rho)?)).ok_or_else(invalid)?;
+    let rseed = Option::from(RandomSeed::from_bytes(to_ba(&n.rseed)?, &rho)).ok_or_else(invalid)?;
+    let note = Option::from(orchard::Note::from_parts(
+        recipient,
+        NoteValue::from_raw(n.value),
+        rho,
+        rseed,
+    ))
+    .ok_or_else(invalid)?;
+    let auth_path = n
+        .auth_path
+        .iter()
+        .map(|h| Option::from(MerkleHashOrchard::from_bytes(&to_ba(h)?)).ok_or_else(invalid))
+        .collect::<Result<Vec<_>, _>>()?;
+    let auth_path: [MerkleHashOrchard; 32] = auth_path.try_into().map_err(|_| invalid())?;
+    let merkle_path = orchard::tree::MerklePath::from_parts(n.position, auth_path);
+    Ok((note, merkle_path))
+}
+
+#[cfg(test)]
+mod tests {
+    use incrementalmerkletree::{Hashable as _, Level};
+    use rand_core::OsRng;
+    use sapling_crypto::prover::mock::{MockOutputProver, MockSpendProver};
+    use zcash_primitives::transaction::Transaction;
+
+    use super::*;
+
+    const VAULT_ADDR: &str = "tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6";
+
+    fn orchard_note(sk: &SpendingKey, value: u64) -> ShieldedNote {
+        let fvk = FullViewingKey::from(sk);
+        let recipient = fvk.address_at(0u32, Scope::External);
+        let rho = Rho::from_bytes(&[0u8; 32]).unwrap();
+        let rseed = RandomSeed::from_bytes([1u8; 32], &rho).unwrap();
+        // single note at position 0 of an otherwise empty tree
+        let auth_path = (0..32u8)
+            .map(|i| {
+                MerkleHashOrchard::empty_root(Level::from(i))
+                    .to_bytes()
+                    .to_vec()
+            })
+            .collect();
+        ShieldedNote {
+            recipient: recipient.to_raw_address_bytes().to_vec(),
+            value,
+            rho: rho.to_bytes().to_vec(),
+            rseed: rseed.as_bytes().to_vec(),
+            position: 0,
+            auth_path,
+        }
+    }
+
+    #[test]
+    fn test_orchard_deposit() {
+        let network = Network::Regtest;
+        let sk = SpendingKey::from_bytes([7u8; 32]).unwrap();
+        let notes = vec![orchard_note(&sk, 100_000_000)];
+        let pk = ProvingKey::build();
+        let tx = build_shielded_deposit(
+            &network,
+            &MockSpendProver,
+            &MockOutputProver,
+            &pk,
+            200,
+            None,
+            &[],
+            Some(&sk),
+            &notes,
+            VAULT_ADDR,
+            10_000_000,
+            &Memo::text("MEMO"),
+            OsRng,
+        )
+        .unwrap();
+
+        let tx = Transaction::read(&*tx.data, BranchId::Nu6).unwrap();
+        let obundle = tx.orchard_bundle().unwrap();
+        // the spend and the change are padded to 2 actions
+        assert_eq!(obundle.actions().len(), 2);
+        let vault_script = TransparentAddress::decode(&network, VAULT_ADDR)
+            .unwrap()
+            .script();
+        let tbundle = tx.transparent_bundle().unwrap();
+        assert!(tbundle.vin.is_empty());
+        assert!(tbundle
+            .vout
+            .iter()
+            .any(|o| o.script_pubkey == vault_script && o.value.into_u64() == 10_000_000));
+        assert!(tx.sapling_bundle().is_none());
+
+        // the fee matches the ZIP-317 fee of the actual tx shape
+        let tout_sizes = tbundle.vout.iter().map(|o| {
+            let mut data = vec![];
+            o.write(&mut data).unwrap();
+            data.len()
+        });
+        let expected = zip317::FeeRule::standard()
+            .fee_required(
+                &network,
+                BlockHeight::from_u32(200),
+                std::iter::empty::<InputSize>(),
+                tout_sizes,
+                0,
+                0,
+                obundle.actions().len(),
+            )
+            .unwrap()
+            .into_u64();
+        let tout_value: u64 = tbundle.vout.iter().map(|o| o.value.into_u64()).sum();
+        let fee = i64::from(*obundle.value_balance()) as u64 - tout_value;
+        assert_eq!(fee, expected);
+        assert_eq!(fee, 20_000);
+    }
+
+    #[test]
+    fn test_not_enough_funds() {
+        let sk = SpendingKey::from_bytes([7u8; 32]).unwrap();
+        let notes = vec![orchard_note(&sk, 10_000)];
+        let notes = notes
+            .iter()
+            .map(parse_orchard_note)
+            .collect::<Result<Vec<_>, _>>()
+            .unwrap();
+        let r = select_notes(
+            &Network::Regtest,
+            BlockHeight::from_u32(200),
+            vec![],
+            notes,
+            10_000,
+            &Memo::default(),
+        );
+        assert!(matches!(r, Err(ZcashError::NotEnoughFunds)));
+    }
+}
@@ -0,0 +1,724 @@
+use std::sync::Arc;
+
+use anyhow::Result;
+use rusqlite::{params, Connection, OptionalExtension as _};
+use zcash_primitives::transaction::Transaction;
+
+use crate::{
+    analyze::{tout_address, tx_inputs, tx_outputs},
+    config::Context,
+    memo::{Memo, MemoKind},
+    network::Network,
+    pay::Output,
+    scan::{
+        fetch_raw_tx, known_vault_addresses, scan_blocks_async, BlockTxs, Direction, ShieldedPool,
+        UndecryptedOutput, VaultKeys, VaultTx,
+    },
+    uniffi_async_export, uniffi_export,
+    viewing::decode_viewing_key,
+    walker::get_block_hash,
+    wallet::UTXO,
+    Height, ZcashError,
+};
+
+/// A vault UTXO kept by the store
+/// `spent_txid` is empty and `spent_height` is 0 while it is unspent
+pub struct StoredUTXO {
+    pub utxo: UTXO,
+    pub spent_txid: String,
+    pub spent_height: u32,
+}
+
+// Append only: the version of a database is the number
+// of migrations applied (PRAGMA user_version)
+const MIGRATIONS: &[&str] = &[
+    // 1
+    "CREATE TABLE vaults(
+        id_vault INTEGER PRIMARY KEY,
+        pubkey BLOB NOT NULL UNIQUE,
+        address TEXT NOT NULL,
+        height INTEGER NOT NULL,
+        hash TEXT NOT NULL);
+    CREATE TABLE txs(
+        id_tx INTEGER PRIMARY KEY,
+        vault INTEGER NOT NULL REFERENCES vaults(id_vault),
+        txid TEXT NOT NULL,
+        height INTEGER NOT NULL,
+        direction INTEGER NOT NULL,
+        address TEXT NOT NULL,
+        amount INTEGER NOT NULL,
+        memo TEXT NOT NULL,
+        UNIQUE (vault, txid));
+    CREATE TABLE destinations(
+        tx INTEGER NOT NULL REFERENCES txs(id_tx) ON DELETE CASCADE,
+        address TEXT NOT NULL,
+        amount INTEGER NOT NULL,
+        memo TEXT NOT NULL);
+    CREATE TABLE utxos(
+        vault INTEGER NOT NULL REFERENCES vaults(id_vault),
+        txid TEXT NOT NULL,
+        vout INTEGER NOT NULL,
+        height INTEGER NOT NULL,
+        script TEXT NOT NULL,
+        value INTEGER NOT NULL,
+        spent_txid TEXT,
+        spent_height INTEGER,
+        PRIMARY KEY (vault, txid, vout));
+    CREATE INDEX i_txs_height ON txs(vault, height);",
+    // 2: binary and future memos, `memo` keeps the text
+    "ALTER TABLE txs ADD COLUMN memo_kind INTEGER NOT NULL DEFAULT 0;
+    ALTER TABLE txs ADD COLUMN memo_data BLOB NOT NULL DEFAULT x'';
+    ALTER TABLE destinations ADD COLUMN memo_kind INTEGER NOT NULL DEFAULT 0;
+    ALTER TABLE destinations ADD COLUMN memo_data BLOB NOT NULL DEFAULT x'';
+    UPDATE txs SET memo_kind = 1, memo_data = CAST(memo AS BLOB) WHERE memo <> '';
+    UPDATE destinations SET memo_kind = 1, memo_data = CAST(memo AS BLOB) WHERE memo <> '';",
+    // 3: shielded outputs that the vault cannot decrypt
+    "ALTER TABLE txs ADD COLUMN shielded_value_balance INTEGER NOT NULL DEFAULT 0;
+    ALTER TABLE txs ADD COLUMN incomplete BOOL NOT NULL DEFAULT FALSE;
+    CREATE TABLE undecrypted_outputs(
+        tx INTEGER NOT NULL REFERENCES txs(id_tx) ON DELETE CASCADE,
+        pool INTEGER NOT NULL,
+        idx INTEGER NOT NULL,
+        cmx BLOB NOT NULL,
+        cv BLOB NOT NULL);",
+    // 4: viewing keys of the vaults, by transparent address
+    "CREATE TABLE viewing_keys(
+        address TEXT PRIMARY KEY,
+        ufvk TEXT NOT NULL);",
+];
+
+/// Open (or create) the store at `path` and upgrade its schema
+/// The scans done with `store_scan` and the viewing keys of
+/// `set_vault_viewing_key` are recorded there and survive restarts.
+/// The keys set before opening the store replace the stored ones
+pub fn open_store(path: String) -> Result<(), ZcashError> {
+    let connection = open(&path)?;
+    uniffi_export!(context, {
+        let network = context.config.network();
+        let mut viewing_keys = context.viewing_keys.lock();
+        for (address, ufvk) in list_viewing_keys(&connection)? {
+            if !viewing_keys.contains_key(&address) {
+                let vk = decode_viewing_key(&network, &address, &ufvk)?;
+                viewing_keys.insert(address, vk);
+            }
+        }
+        for (address, vk) in viewing_keys.iter() {
+            save_viewing_key(&connection, address, &vk.ufvk)?;
+        }
+        *context.store.lock() = Some(connection);
+        Ok(())
+    })
+}
+
+pub fn close_store() {
+    uniffi_export!(context, {
+        context.store.lock().take();
+    })
+}
+
+pub(crate) fn open(path: &str) -> Result<Connection> {
+    let mut connection = Connection::open(path)?;
+    migrate(&mut connection)?;
+    Ok(connection)
+}
+
+pub(crate) fn migrate(connection: &mut Connection) -> Result<()> {
+    // every write is durable once its transaction commits
+    connection.pragma_update_and_check(None, "journal_mode", "WAL", |_| Ok(()))?;
+    connection.pragma_update(None, "synchronous", "FULL")?;
+    connection.pragma_update(None, "foreign_keys", "ON")?;
+    let version: usize = connection.pragma_query_value(None, "user_version", |r| r.get(0))?;
+    if version > MIGRATIONS.len() {
+        anyhow::bail!("The store was created by a newer version (schema {version})");
+    }
+    for (i, migration) in MIGRATIONS.iter().enumerate().skip(version) {
+        let db_tx = connection.transaction()?;
+        db_tx.execute_batch(migration)?;
+        db_tx.pragma_update(None, "user_version", i + 1)?;
+        db_tx.commit()?;
+    }
+    Ok(())
+}
+
+fn with_store<T>(
+    context: &Context,
+    f: impl FnOnce(&mut Connection) -> Result<T>,
+) -> Result<T, ZcashError> {
+    let mut store = context.store.lock();
+    let connection = store
+        .as_mut()
+        .ok_or(ZcashError::AssertError("No store is open".into()))?;
+    Ok(f(connection)?)
+}
+
+/// Scan at most `max_blocks` blocks after the cursor of the vault
+/// and record the txs and the UTXOs. The cursor starts at the
+/// genesis block the first time a vault is scanned.
+/// Returns None at the tip. On `Reorg`, call `store_rewind`
+pub fn store_scan(
+    pubkey: Vec<u8>,
+    max_blocks: u32,
+    known_vaults: Vec<Vec<u8>>,
+) -> Result<Option<BlockTxs>, ZcashError> {
+    if max_blocks == 0 {
+        return Err(ZcashError::AssertError(
+            "max_blocks must be positive".into(),
+        ));
+    }
+    let vault = VaultKeys::new(pubkey)?;
+    let known_vaults = known_vault_addresses(known_vaults)?;
+    uniffi_async_export!(context, {
+        let config = &context.config;
+        let cursor = with_store(&context, |c| get_cursor(c, &vault.pubkey))?;
+        let prev_hash = match cursor {
+            Some((height, hash)) => {
+                if get_block_hash(config, height).await? != hash {
+                    return Err(ZcashError::Reorg);
+                }
+                hash
+            }
+            None => get_block_hash(config, 1).await?,
+        };
+        let Some(btxs) = scan_blocks_async(
+            &context,
+            std::slice::from_ref(&vault),
+            &known_vaults,
+            prev_hash,
+            Some(max_blocks),
+        )
+        .await?
+        else {
+            return Ok(None);
+        };
+
+        // the UTXOs are updated from the raw txs
+        let mut raw_txs = vec![];
+        for vtx in btxs.txs.iter() {
+            raw_txs.push((vtx.height, fetch_raw_tx(config, &vtx.txid).await?));
+        }
+        let network = config.network();
+        with_store(&context, |c| {
+            store_block_txs(c, &network, &vault, &btxs, &raw_txs)
+        })?;
+        Ok(Some(btxs))
+    })
+}
+
+/// Last block scanned by `store_scan` for the vault
+pub fn store_get_cursor(pubkey: Vec<u8>) -> Result<Option<Height>, ZcashError> {
+    uniffi_export!(context, {
+        let cursor = with_store(&context, |c| get_cursor(c, &pubkey))?;
+        Ok(cursor.map(|(number, hash)| Height {
+            number,
+            hash: hex::decode(hash).unwrap(),
+        }))
+    })
+}
+
+/// Vault txs recorded at or after `from_height`, in chain order
+pub fn store_list_txs(pubkey: Vec<u8>, from_height: u32) -> Result<Vec<VaultTx>, ZcashError> {
+    uniffi_export!(context, {
+        with_store(&context, |c| list_txs(c, &pubkey, from_height))
+    })
+}
+
+pub fn store_list_utxos(
+    pubkey: Vec<u8>,
+    include_spent: bool,
+) -> Result<Vec<StoredUTXO>, ZcashError> {
+    uniffi_export!(context, {
+        with_store(&context, |c| list_utxos(c, &pubkey, include_spent))
+    })
+}
+
+pub fn store_get_balance(pubkey: Vec<u8>) -> Result<u64, ZcashError> {
+    let utxos = store_list_utxos(pubkey, false)?;
+    Ok(utxos.iter().map(|u| u.utxo.value).sum())
+}
+
+/// Forget everything the store has recorded for the vault
+/// after `height`, for example after a `Reorg` error,
+/// and move its cursor back to `height`
+pub fn store_rewind(pubkey: Vec<u8>, height: u32) -> Result<(), ZcashError> {
+    uniffi_async_export!(context, {
+        let hash = get_block_hash(&context.config, height).await?;
+        with_store(&context, |c| rewind(c, &pubkey, height, &hash))
+    })
+}
+
+/// Record the viewing key of the vault with this address if
+/// a store is open, an empty `ufvk` removes it
+pub(crate) fn persist_viewing_key(
+    context: &Context,
+    address: &str,
+    ufvk: &str,
+) -> Result<(), ZcashError> {
+    match context.store.lock().as_ref() {
+        Some(connection) => Ok(save_viewing_key(connection, address, ufvk)?),
+        None => Ok(()),
+    }
+}
+
+pub(crate) fn save_viewing_key(connection: &Connection, address: &str, ufvk: &str) -> Result<()> {
+    if ufvk.is_empty() {
+        connection.execute("DELETE FROM viewing_keys WHERE address = ?1", [address])?;
+    } else {
+        connection.execute(
+            "INSERT INTO viewing_keys(address, ufvk) VALUES (?1, ?2)
+            ON CONFLICT (address) DO UPDATE SET ufvk = excluded.ufvk",
+            [address, ufvk],
+        )?;
+    }
+    Ok(())
+}
+
+pub(crate) fn list_viewing_keys(connection: &Connection) -> Result<Vec<(String, String)>> {
+    let mut s = connection.prepare("SELECT address, ufvk FROM viewing_keys ORDER BY address")?;
+    let keys = s
+        .query_map([], |r| Ok((r.get(0)?, r.get(1)?)))?
+        .collect::<Result<_, _>>()?;
+    Ok(keys)
+}
+
+pub(crate) fn get_cursor(connection: &Connection, pubkey: &[u8]) -> Result<Option<(u32, String)>> {
+    let cursor = connection
+        .query_row(
+            "SELECT height, hash FROM vaults WHERE pubkey = ?1",
+            [pubkey],
+            |r| Ok((r.get(0)?, r.get(1)?)),
+        )
+        .optional()?;
+    Ok(cursor)
+}
+
+/// Record the txs of a scanned range and move the cursor
+/// to its end, all or nothing. Recording a range twice
+/// does not count its txs twice
+pub(crate) fn store_block_txs(
+    connection: &mut Connection,
+    network: &Network,
+    vault: &VaultKeys,
+    btxs: &BlockTxs,
+    raw_txs: &[(u32, Arc<Transaction>)],
+) -> Result<()> {
+    let db_tx = connection.transaction()?;
+    db_tx.execute(
+        "INSERT INTO vaults(pubkey, address, height, hash)
+        VALUES (?1, ?2, ?3, ?4)
+        ON CONFLICT (pubkey) DO UPDATE SET
+        height = excluded.height, hash = excluded.hash",
+        params![vault.pubkey, vault.address, btxs.end_height, btxs.end_hash],
+    )?;
+    let id_vault: u32 = db_tx.query_row(
+        "SELECT id_vault FROM vaults WHERE pubkey = ?1",
+        [&vault.pubkey],
+        |r| r.get(0),
+    )?;
+
+    for vtx in btxs.txs.iter() {
+        let id_tx: Option<u32> = db_tx
+            .query_row(
+                "INSERT INTO txs(vault, txid, height, direction, address, amount,
+                memo_kind, memo, memo_data, shielded_value_balance, incomplete)
+                VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11)
+                ON CONFLICT DO NOTHING RETURNING id_tx",
+                params![
+                    id_vault,
+                    vtx.txid,
+                    vtx.height,
+                    direction_to_int(&vtx.direction),
+                    vtx.counterparty.address,
+                    vtx.counterparty.amount,
+                    memo_kind_to_int(vtx.counterparty.memo.kind),
+                    vtx.counterparty.memo.text,
+                    vtx.counterparty.memo.data,
+                    vtx.shielded_value_balance,
+                    vtx.incomplete,
+                ],
+                |r| r.get(0),
+            )
+            .optional()?;
+        let Some(id_tx) = id_tx else {
+            continue;
+        };
+        for d in vtx.destinations.iter() {
+            db_tx.execute(
+                "INSERT INTO destinations(tx, address, amount, memo_kind, memo, memo_data)
+                VALUES (?1, ?2, ?3, ?4, ?5, ?6)",
+                params![
+                    id_tx,
+                    d.address,
+                    d.amount,
+                    memo_kind_to_int(d.memo.kind),
+                    d.memo.text,
+                    d.memo.data,
+                ],
+            )?;
+        }
+        for u in vtx.undecrypted.iter() {
+            db_tx.execute(
+                "INSERT INTO undecrypted_outputs(tx, pool, idx, cmx, cv)
+                VALUES (?1, ?2, ?3, ?4, ?5)",
+                params![id_tx, pool_to_int(u.pool), u.index, u.cmx, u.cv],
+            )?;
+        }
+    }
+
+    for (height, tx) in raw_txs.iter() {
+        let txid = tx.txid().to_string();
+        for (ptxid, pvout) in tx_inputs(tx) {
+            db_tx.execute(
+                "UPDATE utxos SET spent_txid = ?1, spent_height = ?2
+                WHERE vault = ?3 AND txid = ?4 AND vout = ?5",
+                params![txid, height, id_vault, ptxid, pvout],
+            )?;
+        }
+        for (vout, tout) in tx_outputs(tx).iter().enumerate() {
+            if tout_address(network, tout) != vault.address {
+                continue;
+            }
+            db_tx.execute(
+                "INSERT INTO utxos(vault, txid, vout, height, script, value)
+                VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+                ON CONFLICT DO NOTHING",
+                params![
+                    id_vault,
+                    txid,
+                    vout as u32,
+                    height,
+                    hex::encode(&tout.script_pubkey.0),
+                    tout.value.into_u64(),
+                ],
+            )?;
+        }
+    }
+    db_tx.commit()?;
+    Ok(())
+}
+
+pub(crate) fn list_txs(
+    connection: &Connection,
+    pubkey: &[u8],
+    from_height: u32,
+) -> Result<Vec<VaultTx>> {
+    let mut s = connection.prepare(
+        "SELECT t.id_tx, t.txid, t.height, t.direction, t.address, t.amount,
+        t.memo_kind, t.memo, t.memo_data, t.shielded_value_balance, t.incomplete
+        FROM txs t JOIN vaults v ON t.vault = v.id_vault
+        WHERE v.pubkey = ?1 AND t.height >= ?2
+        ORDER BY t.height, t.id_tx",
+    )?;
+    let rows = s.query_map(params![pubkey, from_height], |r| {
+        Ok((
+            r.get::<_, u32>(0)?,
+            VaultTx {
+                txid: r.get(1)?,
+                height: r.get(2)?,
+                vault: pubkey.to_vec(),
+                direction: int_to_direction(r.get(3)?),
+                counterparty: Output {
+                    address: r.get(4)?,
+                    amount: r.get(5)?,
+                    memo: Memo {
+                        kind: int_to_memo_kind(r.get(6)?),
+                        text: r.get(7)?,
+                        data: r.get(8)?,
+                    },
+                },
+                destinations: vec![],
+                undecrypted: vec![],
+                incomplete: r.get(10)?,
+                shielded_value_balance: r.get(9)?,
+            },
+        ))
+    })?;
+    let mut s_destinations = connection.prepare(
+        "SELECT address, amount, memo_kind, memo, memo_data FROM destinations WHERE tx = ?1",
+    )?;
+    let mut s_undecrypted = connection.prepare(
+        "SELECT pool, idx, cmx, cv FROM undecrypted_outputs WHERE tx = ?1 ORDER BY pool, idx",
+    )?;
+    let mut txs = vec![];
+    for row in rows {
+        let (id_tx, mut vtx) = row?;
+        let destinations = s_destinations.query_map([id_tx], |r| {
+            Ok(Output {
+                address: r.get(0)?,
+                amount: r.get(1)?,
+                memo: Memo {
+                    kind: int_to_memo_kind(r.get(2)?),
+                    text: r.get(3)?,
+                    data: r.get(4)?,
+                },
+            })
+        })?;
+        vtx.destinations = destinations.collect::<Result<_, _>>()?;
+        let undecrypted = s_undecrypted.query_map([id_tx], |r| {
+            Ok(UndecryptedOutput {
+                pool: int_to_pool(r.get(0)?),
+                index: r.get(1)?,
+                cmx: r.get(2)?,
+                cv: r.get(3)?,
+            })
+        })?;
+        vtx.undecrypted = undecrypted.collect::<Result<_, _>>()?;
+        txs.push(vtx);
+    }
+    Ok(txs)
+}
+
+pub(crate) fn list_utxos(
+    connection: &Connection,
+    pubkey: &[u8],
+    include_spent: bool,
+) -> Result<Vec<StoredUTXO>> {
+    let mut s = connection.prepare(
+        "SELECT u.txid, u.vout, u.height, u.script, u.value, u.spent_txid, u.spent_height
+        FROM utxos u JOIN vaults v ON u.vault = v.id_vault
+        WHERE v.pubkey = ?1 AND (?2 OR u.spent_txid IS NULL)
+        ORDER BY u.height, u.txid, u.vout",
+    )?;
+    let rows = s.query_map(params![pubkey, include_spent], |r| {
+        Ok(StoredUTXO {
+            utxo: UTXO {
+                txid: r.get(0)?,
+                vout: r.get(1)?,
+                height: r.get(2)?,
+                script: r.get(3)?,
+                value: r.get(4)?,
+            },
+            spent_txid: r.get::<_, Option<String>>(5)?.unwrap_or_default(),
+            spent_height: r.get::<_, Option<u32>>(6)?.unwrap_or_default(),
+        })
+    })?;
+    Ok(rows.collect::<Result<_, _>>()?)
+}
+
+pub(crate) fn rewind(
+    connection: &mut Connection,
+    pubkey: &[u8],
+    height: u32,
+    hash: &str,
+) -> Result<()> {
+    let db_tx = connection.transaction()?;
+    let id_vault: Option<u32> = db_tx
+        .query_row(
+            "SELECT id_vault FROM vaults WHERE pubkey = ?1",
+            [pubkey],
+            |r| r.get(0),
+        )
+        .optional()?;
+    let Some(id_vault) = id_vault else {
+        return Ok(());
+    };
+    db_tx.execute(
+        "DELETE FROM txs WHERE vault = ?1 AND height > ?2",
+        params![id_vault, height],
+    )?;
+    db_tx.execute(
+        "DELETE FROM utxos WHERE vault = ?1 AND height > ?2",
+        params![id_vault, height],
+    )?;
+    db_tx.execute(
+        "UPDATE utxos SET spent_txid = NULL, spent_height = NULL
+        WHERE vault = ?1 AND spent_height > ?2",
+        params![id_vault, height],
+    )?;
+    db_tx.execute(
+        "UPDATE vaults SET height = ?2, hash = ?3 WHERE id_vault = ?1",
+        params![id_vault, height, hash],
+    )?;
+    db_tx.commit()?;
+    Ok(())
+}
+
+fn direction_to_int(direction: &Direction) -> u8 {
+    match direction {
+        Direction::Incoming => 0,
+        Direction::Outgoing => 1,
+        Direction::Internal => 2,
+        Direction::Migration => 3,
+    }
+}
+
+fn int_to_direction(v: u8) -> Direction {
+    match v {
+        1 => Direction::Outgoing,
+        2 => Direction::Internal,
+        3 => Direction::Migration,
+        _ => Direction::Incoming,
+    }
+}
+
+fn memo_kind_to_int(kind: Option<MemoKind>) -> u8 {
+    match kind {
+        None => 0,
+        Some(MemoKind::Text) => 1,
+        Some(MemoKind::Arbitrary) => 2,
+        Some(MemoKind::Future) => 3,
+    }
+}
+
+fn int_to_memo_kind(v: u8) -> Option<MemoKind> {
+    match v {
+        1 => Some(MemoKind::Text),
+        2 => Some(MemoKind::Arbitrary),
+        3 => Some(MemoKind::Future),
+        _ => None,
+    }
+}
+
+fn pool_to_int(pool: ShieldedPool) -> u8 {
+    match pool {
+        ShieldedPool::Sapling => 0,
+        ShieldedPool::Orchard => 1,
+    }
+}
+
+fn int_to_pool(v: u8) -> ShieldedPool {
+    match v {
+        1 => ShieldedPool::Orchard,
+        _ => ShieldedPool::Sapling,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use crate::analyze::{read_tx, tests::DEPOSIT};
+
+    use super::*;
+
+    const VAULT_ADDR: &str = "tmWksakBYGg7Lqtm1EqSqvPkVYJHYxGq6Za";
+    const DEPOSIT_TXID: &str = "d120e67dac6ccdb49915542544ae2673fd4aef6adc1fa4eac9012134c9f3ddd0";
+
+    fn vault() -> VaultKeys {
+        VaultKeys {
+            pubkey: vec![1, 2, 3],
+            address: VAULT_ADDR.to_string(),
+            ovk: vec![0u8; 32],
+            viewing_key: None,
+        }
+    }
+
+    fn deposit_range() -> BlockTxs {
+        BlockTxs {
+            start_hash: "aa".into(),
+            end_hash: "bb".into(),
+            start_height: 10,
+            end_height: 12,
+            txs: vec![VaultTx {
+                height: 11,
+                txid: DEPOSIT_TXID.into(),
+                vault: vec![1, 2, 3],
+                counterparty: Output {
+                    address: "tmP9jLgTnhDdKdWJCm4BT2t6acGnxqP14yU".into(),
+                    amount: 10_000_000,
+                    memo: Memo::text("MEMO"),
+                },
+                direction: Direction::Incoming,
+                destinations: vec![Output {
+                    address: VAULT_ADDR.into(),
+                    amount: 10_000_000,
+                    memo: Memo::text("MEMO"),
+                }],
+                undecrypted: vec![],
+                incomplete: false,
+                shielded_value_balance: 0,
+            }],
+        }
+    }
+
+    #[test]
+    fn test_migrations() {
+        let mut connection = Connection::open_in_memory().unwrap();
+        migrate(&mut connection).unwrap();
+        // reopening an up to date store is a no-op
+        migrate(&mut connection).unwrap();
+        let version: usize = connection
+            .pragma_query_value(None, "user_version", |r| r.get(0))
+            .unwrap();
+        assert_eq!(version, MIGRATIONS.len());
+    }
+
+    #[test]
+    fn test_viewing_keys() {
+        let mut connection = Connection::open_in_memory().unwrap();
+        migrate(&mut connection).unwrap();
+        save_viewing_key(&connection, "t1", "uview1a").unwrap();
+        save_viewing_key(&connection, "t2", "uview1b").unwrap();
+        // a new key replaces the previous one
+        save_viewing_key(&connection, "t1", "uview1c").unwrap();
+        assert_eq!(
+            list_viewing_keys(&connection).unwrap(),
+            [
+                ("t1".into(), "uview1c".into()),
+                ("t2".into(), "uview1b".into())
+            ]
+        );
+        save_viewing_key(&connection, "t2", "").unwrap();
+        assert_eq!(
+            list_viewing_keys(&connection).unwrap(),
+            [("t1".to_string(), "uview1c".to_string())]
+        );
+    }
+
+    #[test]
+    fn test_text_memo_migration() {
+        let mut connection = Connection::open_in_memory().unwrap();
+        connection.execute_batch(MIGRATIONS[0]).unwrap();
+        connection.pragma_update(None, "user_version", 1).unwrap();
+        connection
+            .execute_batch(
+                "INSERT INTO vaults(id_vault, pubkey, address, height, hash)
+                VALUES (1, x'010203', 'tmWksakBYGg7Lqtm1EqSqvPkVYJHYxGq6Za', 12, 'bb');
+                INSERT INTO txs(vault, txid, height, direction, address, amount, memo)
+                VALUES (1, 'a', 11, 0, 'tmP9jLgTnhDdKdWJCm4BT2t6acGnxqP14yU', 1000, 'MEMO');
+                INSERT INTO txs(vault, txid, height, direction, address, amount, memo)
+                VALUES (1, 'b', 11, 0, 'tmP9jLgTnhDdKdWJCm4BT2t6acGnxqP14yU', 1000, '');",
+            )
+            .unwrap();
+        migrate(&mut connection).unwrap();
+        let txs = list_txs(&connection, &[1, 2, 3], 0).unwrap();
+        assert_eq!(txs[0].counterparty.memo, Memo::text("MEMO"));
+        assert_eq!(txs[1].counterparty.memo, Memo::default());
+    }
+
+    #[test]
+    fn test_store_and_rewind() {
+        let mut connection = Connection::open_in_memory().unwrap();
+        migrate(&mut connection).unwrap();
+        let vault = vault();
+        let tx = read_tx(&hex::decode(DEPOSIT).unwrap()).unwrap();
+        let btxs = deposit_range();
+        let raw_txs = vec![(11, Arc::new(tx))];
+
+        // a range recorded twice (crash after the commit)
+        // is only counted once
+        for _ in 0..2 {
+            store_block_txs(&mut connection, &Network::Regtest, &vault, &btxs, &raw_txs).unwrap();
+        }
+        assert_eq!(
+            get_cursor(&connection, &vault.pubkey).unwrap(),
+            Some((12, "bb".to_string()))
+        );
+        let txs = list_txs(&connection, &vault.pubkey, 0).unwrap();
+        assert_eq!(txs.len(), 1);
+        assert_eq!(txs[0].counterparty.memo, Memo::text("MEMO"));
+        assert_eq!(txs[0].destinations.len(), 1);
+        let utxos = list_utxos(&connection, &vault.pubkey, false).unwrap();
+        assert_eq!(utxos.len(), 1);
+        assert_eq!(utxos[0].utxo.txid, DEPOSIT_TXID);
+        assert_eq!(utxos[0].utxo.vout, 1);
+        assert_eq!(utxos[0].utxo.value, 10_000_000);
+
+        rewind(&mut connection, &vault.pubkey, 10, "aa").unwrap();
+        assert_eq!(
+            get_cursor(&connection, &vault.pubkey).unwrap(),
+            Some((10, "aa".to_string()))
+        );
+        assert!(list_txs(&connection, &vault.pubkey, 0).unwrap().is_empty());
+        assert!(list_utxos(&connection, &vault.pubkey, true)
+            .unwrap()
+            .is_empty());
+    }
+}
@@ -0,0 +1,498 @@
+use std::{
+    collections::{HashMap, HashSet, VecDeque},
+    sync::Arc,
+};
+
+use parking_lot::Mutex;
+use uuid::Uuid;
+
+use crate::{
+    config::Config,
+    rpc::{json_request, map_rpc_error},
+    scan::{known_vault_addresses, scan_block, VaultKeys, VaultTx},
+    uniffi_async_export, ZcashError,
+};
+
+// polls that may start over because the chain changed
+// in the middle of them
+const MAX_POLL_ATTEMPTS: u32 = 3;
+
+pub enum ChainEventKind {
+    BlockConnected,
+    BlockDisconnected,
+}
+
+/// A block that was added to or removed from the best chain
+/// with the vault txs it has. Disconnected blocks come
+/// from the tip down, connected blocks in chain order
+pub struct ChainEvent {
+    pub kind: ChainEventKind,
+    pub height: u32,
+    pub hash: String,
+    pub txs: Vec<VaultTx>,
+}
+
+struct TrackedBlock {
+    height: u32,
+    hash: String,
+    txs: Vec<VaultTx>,
+}
+
+/// Follows the tip of the chain and remembers the last `depth`
+/// blocks so that it can tell which ones were orphaned
+pub struct ChainTracker {
+    vaults: Vec<VaultKeys>,
+    known_vaults: HashSet<String>,
+    depth: usize,
+    window: VecDeque<TrackedBlock>,
+}
+
+/// What the tracker needs from the node
+pub(crate) trait BlockSource {
+    async fn tip_height(&self) -> Result<u32, ZcashError>;
+    async fn block_hash(&self, height: u32) -> Result<String, ZcashError>;
+    /// The parent hash and the vault txs of the block `hash`
+    /// Reorg if the block is not in the best chain anymore
+    async fn block(
+        &self,
+        vaults: &[VaultKeys],
+        known_vaults: &HashSet<String>,
+        hash: &str,
+    ) -> Result<(String, Vec<VaultTx>), ZcashError>;
+}
+
+pub(crate) struct RpcBlockSource<'a> {
+    pub(crate) config: &'a Config,
+}
+
+impl<'a> BlockSource for RpcBlockSource<'a> {
+    async fn tip_height(&self) -> Result<u32, ZcashError> {
+        let id = Uuid::new_v4().to_string();
+        let rep = json_request(self.config, &id, "getblockcount", vec![])
+            .await
+            .map_err(map_rpc_error)?;
+        let height = rep.as_u64().ok_or(ZcashError::AssertError(
+            "Failed to retrieve block count".into(),
+        ))?;
+        Ok(height as u32)
+    }
+
+    async fn block_hash(&self, height: u32) -> Result<String, ZcashError> {
+        let id = Uuid::new_v4().to_string();
+        let rep = json_request(self.config, &id, "getblockhash", vec![height.into()])
+            .await
+            .map_err(map_rpc_error)?;
+        let hash = rep
+            .as_str()
+            .ok_or(ZcashError::AssertError(format!(
+                "Failed to retrieve block hash at {height}"
+            )))?
+            .to_string();
+        Ok(hash)
+    }
+
+    async fn block(
+        &self,
+        vaults: &[VaultKeys],
+        known_vaults: &HashSet<String>,
+        hash: &str,
+    ) -> Result<(String, Vec<VaultTx>), ZcashError> {
+        scan_block(self.config, vaults, known_vaults, hash).await
+    }
+}
+
+impl ChainTracker {
+    pub(crate) fn new(
+        vaults: Vec<VaultKeys>,
+        known_vaults: HashSet<String>,
+        height: u32,
+        hash: String,
+        depth: usize,
+    ) -> Self {
+        let mut window = VecDeque::new();
+        window.push_back(TrackedBlock {
+            height,
+            hash,
+            txs: vec![],
+        });
+        ChainTracker {
+            vaults,
+            known_vaults,
+            depth,
+            window,
+        }
+    }
+
+    pub(crate) async fn poll<S: BlockSource>(
+        &mut self,
+        source: &S,
+    ) -> Result<Vec<ChainEvent>, ZcashError> {
+        // start over if the chain changes during the poll
+        let mut attempts = 0;
+        let (keep, connected) = loop {
+            if let Some(update) = self.fetch_update(source).await? {
+                break update;
+            }
+            attempts += 1;
+            if attempts == MAX_POLL_ATTEMPTS {
+                return Err(ZcashError::AssertError(
+                    "The chain changed during every poll".into(),
+                ));
+            }
+        };
+
+        let mut events = vec![];
+
+        // unwind the others, from the tip down
+        for block in self.window.drain(keep..).rev() {
+            events.push(ChainEvent {
+                kind: ChainEventKind::BlockDisconnected,
+                height: block.height,
+                hash: block.hash,
+                txs: block.txs,
+            });
+        }
+        for block in connected {
+            events.push(ChainEvent {
+                kind: ChainEventKind::BlockConnected,
+                height: block.height,
+                hash: block.hash.clone(),
+                txs: block.txs.clone(),
+            });
+            self.window.push_back(block);
+            while self.window.len() > self.depth {
+                self.window.pop_front();
+            }
+        }
+
+        Ok(events)
+    }
+
+    /// The number of blocks of the window that are still in the
+    /// best chain and the blocks that follow them, fetched before
+    /// touching the window so that an RPC error leaves it as it
+    /// was and the next poll reports the same events.
+    /// None if a new block does not link to the previous one
+    async fn fetch_update<S: BlockSource>(
+        &self,
+        source: &S,
+    ) -> Result<Option<(usize, Vec<TrackedBlock>)>, ZcashError> {
+        let tip_height = source.tip_height().await?;
+
+        // find the last block that is still in the best chain
+        let mut keep = self.window.len();
+        while keep > 0 {
+            let block = &self.window[keep - 1];
+            if block.height <= tip_height && source.block_hash(block.height).await? == block.hash {
+                break;
+            }
+            keep -= 1;
+        }
+        if keep == 0 {
+            // the reorg goes deeper than what we remember
+            return Err(ZcashError::Reorg);
+        }
+
+        let mut prev_hash = self.window[keep - 1].hash.clone();
+        let start_height = self.window[keep - 1].height + 1;
+        let mut connected = vec![];
+        for height in start_height..=tip_height {
+            let hash = source.block_hash(height).await?;
+            let (parent, txs) = match source.block(&self.vaults, &self.known_vaults, &hash).await {
+                Ok(block) => block,
+                Err(ZcashError::Reorg) => return Ok(None),
+                Err(e) => return Err(e),
+            };
+            if parent != prev_hash {
+                return Ok(None);
+            }
+            prev_hash = hash.clone();
+            connected.push(TrackedBlock { height, hash, txs });
+        }
+        Ok(Some((keep, connected)))
+    }
+}
+
+lazy_static::lazy_static! {
+    static ref TRACKERS: Mutex<(u64, HashMap<u64, Arc<Mutex<ChainTracker>>>)> =
+        Mutex::new((0, HashMap::new()));
+}
+
+/// Create a tracker for the given vaults that starts after
+/// the block `from_hash`, or at the tip if `from_hash` is empty.
+/// It can undo up to `depth` blocks.
+/// Returns the tracker handle
+pub fn chain_tracker_new(
+    pubkeys: Vec<Vec<u8>>,
+    from_hash: String,
+    depth: u32,
+    known_vaults: Vec<Vec<u8>>,
+) -> Result<u64, ZcashError> {
+    if depth == 0 {
+        return Err(ZcashError::AssertError("depth must be positive".into()));
+    }
+    let vaults = VaultKeys::from_pubkeys(pubkeys)?;
+    let known_vaults = known_vault_addresses(known_vaults)?;
+    let (height, hash) = uniffi_async_export!(context, {
+        let source = RpcBlockSource {
+            config: &context.config,
+        };
+        let from_hash = if from_hash.is_empty() {
+            let height = source.tip_height().await?;
+            source.block_hash(height).await?
+        } else {
+            from_hash
+        };
+        let id = Uuid::new_v4().to_string();
+        let rep = json_request(
+            &context.config,
+            &id,
+            "getblockheader",
+            vec![from_hash.clone().into()],
+        )
+        .await
+        .map_err(map_rpc_error)?;
+        let height = rep["height"].as_u64().ok_or(ZcashError::AssertError(
+            "Failed to parse getblockheader reply".into(),
+        ))?;
+        Ok::<_, ZcashError>((height as u32, from_hash))
+    })?;
+
+    let tracker = ChainTracker::new(vaults, known_vaults, height, hash, depth as usize);
+    let mut trackers = TRACKERS.lock();
+    trackers.0 += 1;
+    let handle = trackers.0;
+    trackers.1.insert(handle, Arc::new(Mutex::new(tracker)));
+    Ok(handle)
+}
+
+/// Catch up with the tip and report the blocks that were
+/// disconnected and connected since the last poll
+pub fn chain_tracker_poll(tracker: u64) -> Result<Vec<ChainEvent>, ZcashError> {
+    // do not hold the other trackers during the RPCs
+    let tracker = TRACKERS
+        .lock()
+        .1
+        .get(&tracker)
+        .cloned()
+        .ok_or(ZcashError::AssertError(format!(
+            "Unknown tracker {tracker}"
+        )))?;
+    let mut tracker = tracker.lock();
+    uniffi_async_export!(context, {
+        let source = RpcBlockSource {
+            config: &context.config,
+        };
+        tracker.poll(&source).await
+    })
+}
+
+pub fn chain_tracker_close(tracker: u64) {
+    TRACKERS.lock().1.remove(&tracker);
+}
+
+#[cfg(test)]
+mod tests {
+    use std::cell::RefCell;
+
+    use crate::{pay::Output, scan::Direction};
+
+    use super::*;
+
+    /// A node whose chain is scripted by the test
+    struct FakeNode {
+        // (hash, txs) by height, starting at 0
+        chain: RefCell<Vec<(String, Vec<String>)>>,
+        // height of a block whose txs cannot be fetched
+        failing: Option<u32>,
+        // (height, depth, extra, fork) of a reorg that happens
+        // when the hash at this height is requested
+        reorg_at: RefCell<Option<(u32, u32, u32, &'static str)>>,
+    }
+
+    impl FakeNode {
+        fn new(length: u32) -> Self {
+            let chain = (0..length)
+                .map(|h| (format!("a{h}"), vec![format!("tx-a{h}")]))
+                .collect();
+            FakeNode {
+                chain: RefCell::new(chain),
+                failing: None,
+                reorg_at: RefCell::new(None),
+            }
+        }
+
+        /// Replace the last `depth` blocks and add `extra` blocks
+        fn reorg(&self, depth: u32, extra: u32, fork: &str) {
+            let mut chain = self.chain.borrow_mut();
+            let fork_height = chain.len() - depth as usize;
+            chain.truncate(fork_height);
+            for h in fork_height..fork_height + (depth + extra) as usize {
+                chain.push((format!("{fork}{h}"), vec![format!("tx-{fork}{h}")]));
+            }
+        }
+    }
+
+    impl BlockSource for FakeNode {
+        async fn tip_height(&self) -> Result<u32, ZcashError> {
+            Ok(self.chain.borrow().len() as u32 - 1)
+        }
+
+        async fn block_hash(&self, height: u32) -> Result<String, ZcashError> {
+            let reorg = self.reorg_at.borrow_mut().take_if(|r| r.0 == height);
+            if let Some((_, depth, extra, fork)) = reorg {
+                self.reorg(depth, extra, fork);
+            }
+            Ok(self.chain.borrow()[height as usize].0.clone())
+        }
+
+        async fn block(
+            &self,
+            _vaults: &[VaultKeys],
+            _known_vaults: &HashSet<String>,
+            hash: &str,
+        ) -> Result<(String, Vec<VaultTx>), ZcashError> {
+            let chain = self.chain.borrow();
+            let height = chain
+                .iter()
+                .position(|(h, _)| h == hash)
+                .ok_or(ZcashError::Reorg)? as u32;
+            if self.failing == Some(height) {
+                return Err(ZcashError::RPC("connection refused".into()));
+            }
+            let parent = chain[height as usize - 1].0.clone();
+            let txs = chain[height as usize]
+                .1
+                .iter()
+                .map(|txid| VaultTx {
+                    height,
+                    txid: txid.clone(),
+                    vault: vec![],
+                    counterparty: Output::default(),
+                    direction: Direction::Incoming,
+                    destinations: vec![],
+                    undecrypted: vec![],
+                    incomplete: false,
+                    shielded_value_balance: 0,
+                })
+                .collect();
+            Ok((parent, txs))
+        }
+    }
+
+    fn summary(events: &[ChainEvent]) -> Vec<(char, u32, String, String)> {
+        events
+            .iter()
+            .map(|e| {
+                let kind = match e.kind {
+                    ChainEventKind::BlockConnected => '+',
+                    ChainEventKind::BlockDisconnected => '-',
+                };
+                (kind, e.height, e.hash.clone(), e.txs[0].txid.clone())
+            })
+            .collect()
+    }
+
+    fn poll(tracker: &mut ChainTracker, node: &FakeNode) -> Result<Vec<ChainEvent>, ZcashError> {
+        tokio::runtime::Builder::new_current_thread()
+            .build()
+            .unwrap()
+            .block_on(tracker.poll(node))
+    }
+
+    #[test]
+    fn test_reorgs() {
+        let node = FakeNode::new(6);
+        let mut tracker = ChainTracker::new(vec![], HashSet::new(), 3, "a3".to_string(), 4);
+
+        let events = poll(&mut tracker, &node).unwrap();
+        assert_eq!(
+            summary(&events),
+            vec![
+                ('+', 4, "a4".into(), "tx-a4".into()),
+                ('+', 5, "a5".into(), "tx-a5".into()),
+            ]
+        );
+        assert!(poll(&mut tracker, &node).unwrap().is_empty());
+
+        // the last 2 blocks are replaced and the new chain is longer
+        node.reorg(2, 1, "b");
+        let events = poll(&mut tracker, &node).unwrap();
+        assert_eq!(
+            summary(&events),
+            vec![
+                ('-', 5, "a5".into(), "tx-a5".into()),
+                ('-', 4, "a4".into(), "tx-a4".into()),
+                ('+', 4, "b4".into(), "tx-b4".into()),
+                ('+', 5, "b5".into(), "tx-b5".into()),
+                ('+', 6, "b6".into(), "tx-b6".into()),
+            ]
+        );
+
+        // a shorter chain wins
+        node.chain.borrow_mut().truncate(5);
+        node.chain
+            .borrow_mut()
+            .push(("c5".into(), vec!["tx-c5".into()]));
+        let events = poll(&mut tracker, &node).unwrap();
+        assert_eq!(
+            summary(&events),
+            vec![
+                ('-', 6, "b6".into(), "tx-b6".into()),
+                ('-', 5, "b5".into(), "tx-b5".into()),
+                ('+', 5, "c5".into(), "tx-c5".into()),
+            ]
+        );
+
+        // deeper than the window of 4 blocks (a3, b4, c5)
+        node.reorg(5, 0, "d");
+        assert!(matches!(poll(&mut tracker, &node), Err(ZcashError::Reorg)));
+    }
+
+    #[test]
+    fn test_failed_poll() {
+        let mut node = FakeNode::new(6);
+        let mut tracker = ChainTracker::new(vec![], HashSet::new(), 4, "a4".to_string(), 4);
+        assert_eq!(poll(&mut tracker, &node).unwrap().len(), 1);
+
+        // the disconnected blocks are not lost if a new block
+        // cannot be fetched
+        node.reorg(1, 1, "b");
+        node.failing = Some(6);
+        assert!(poll(&mut tracker, &node).is_err());
+        node.failing = None;
+        let events = poll(&mut tracker, &node).unwrap();
+        assert_eq!(
+            summary(&events),
+            vec![
+                ('-', 5, "a5".into(), "tx-a5".into()),
+                ('+', 5, "b5".into(), "tx-b5".into()),
+                ('+', 6, "b6".into(), "tx-b6".into()),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_reorg_during_poll() {
+        let node = FakeNode::new(6);
+        let mut tracker = ChainTracker::new(vec![], HashSet::new(), 3, "a3".to_string(), 4);
+        assert_eq!(poll(&mut tracker, &node).unwrap().len(), 2);
+
+        // a6 is mined, then a5 and a6 are replaced after the
+        // tracker checked a5 and before it asks for the hash at 6
+        node.reorg(0, 1, "a");
+        *node.reorg_at.borrow_mut() = Some((6, 2, 1, "b"));
+        let events = poll(&mut tracker, &node).unwrap();
+        // b6 does not link to a5, the poll starts over
+        assert_eq!(
+            summary(&events),
+            vec![
+                ('-', 5, "a5".into(), "tx-a5".into()),
+                ('+', 5, "b5".into(), "tx-b5".into()),
+                ('+', 6, "b6".into(), "tx-b6".into()),
+                ('+', 7, "b7".into(), "tx-b7".into()),
+            ]
+        );
+        assert!(poll(&mut tracker, &node).unwrap().is_empty());
+    }
+}
@@ -0,0 +1,229 @@
+use orchard::{
+    bundle::Authorization,
+    keys::{FullViewingKey, IncomingViewingKey, Scope},
+    note_encryption::OrchardDomain,
+};
+use sapling_crypto::{
+    note_encryption::{SaplingDomain, Zip212Enforcement},
+    zip32::DiversifiableFullViewingKey,
+    SaplingIvk,
+};
+use zcash_keys::{address::UnifiedAddress, encoding::AddressCodec, keys::UnifiedFullViewingKey};
+use zcash_note_encryption::try_note_decryption;
+use zcash_primitives::{legacy::TransparentAddress, transaction::Transaction};
+
+use crate::{
+    addr::get_vault_address, memo::Memo, network::Network, pay::Output, store::persist_viewing_key,
+    uniffi_export, ZcashError,
+};
+
+/// Incoming viewing keys of a vault and the unified address
+/// that receives its shielded deposits
+/// `ufvk` is the encoding it was decoded from, if any
+#[derive(Clone)]
+pub(crate) struct VaultViewingKey {
+    pub(crate) deposit_address: String,
+    pub(crate) ufvk: String,
+    sapling: Option<SaplingIvk>,
+    orchard: Option<IncomingViewingKey>,
+}
+
+impl VaultViewingKey {
+    /// The deposit address has the default Orchard and Sapling
+    /// receivers of the keys and the transparent vault address
+    pub(crate) fn new(
+        network: &Network,
+        vault_address: &str,
+        sapling: Option<&DiversifiableFullViewingKey>,
+        orchard: Option<&FullViewingKey>,
+    ) -> Result<Self, ZcashError> {
+        if sapling.is_none() && orchard.is_none() {
+            return Err(ZcashError::AssertError(
+                "The viewing key has no Sapling or Orchard component".into(),
+            ));
+        }
+        let taddr = TransparentAddress::decode(network, vault_address)
+            .map_err(|_| ZcashError::InvalidAddress(vault_address.to_string()))?;
+        // cannot fail, there is at least one shielded receiver
+        let ua = UnifiedAddress::from_receivers(
+            orchard.map(|fvk| fvk.address_at(0u32, Scope::External)),
+            sapling.map(|dfvk| dfvk.default_address().1),
+            Some(taddr),
+        )
+        .unwrap();
+        Ok(VaultViewingKey {
+            deposit_address: ua.encode(network),
+            ufvk: String::new(),
+            sapling: sapling.map(|dfvk| dfvk.to_ivk(Scope::External)),
+            orchard: orchard.map(|fvk| fvk.to_ivk(Scope::External)),
+        })
+    }
+
+    /// The notes of the tx received on any address of the keys
+    /// They are reported as outputs to the deposit address
+    pub(crate) fn decrypt_incoming(&self, tx: &Transaction) -> Vec<Output> {
+        let mut outputs = vec![];
+        if let (Some(ivk), Some(bundle)) = (&self.sapling, tx.sapling_bundle()) {
+            let d = SaplingDomain::new(Zip212Enforcement::On);
+            let pivk = sapling_crypto::keys::PreparedIncomingViewingKey::new(ivk);
+            for output in bundle.shielded_outputs() {
+                if let Some((note, _, memo)) = try_note_decryption(&d, &pivk, output) {
+                    outputs.push(self.deposit(note.value().inner(), &memo));
+                }
+            }
+        }
+        if let Some(bundle) = tx.orchard_bundle() {
+            outputs.extend(self.decrypt_orchard(bundle));
+        }
+        outputs
+    }
+
+    fn decrypt_orchard<A: Authorization, V>(&self, bundle: &orchard::Bundle<A, V>) -> Vec<Output> {
+        let Some(ivk) = &self.orchard else {
+            return vec![];
+        };
+        let pivk = orchard::keys::PreparedIncomingViewingKey::new(ivk);
+        bundle
+            .actions()
+            .iter()
+            .filter_map(|action| {
+                let d = OrchardDomain::for_action(action);
+                try_note_decryption(&d, &pivk, action)
+                    .map(|(note, _, memo)| self.deposit(note.value().inner(), &memo))
+            })
+            .collect()
+    }
+
+    fn deposit(&self, amount: u64, memo: &[u8; 512]) -> Output {
+        Output {
+            address: self.deposit_address.clone(),
+            amount,
+            memo: Memo::from_memo_bytes(memo),
+        }
+    }
+}
+
+/// Provision the viewing key of the shielded deposits of a vault
+/// `ufvk` is the encoding of a unified full viewing key with a
+/// Sapling and/or an Orchard component, an empty string removes it.
+/// The TSS cannot sign shielded spends: the notes stay under the
+/// control of the holder of the matching spending key.
+/// The scans, walkers and subscriptions pick up the key when
+/// they are created. The key is kept in the store, if one is open
+pub fn set_vault_viewing_key(pubkey: Vec<u8>, ufvk: String) -> Result<(), ZcashError> {
+    let address = get_vault_address(pubkey)?;
+    uniffi_export!(context, {
+        let network = context.config.network();
+        let mut viewing_keys = context.viewing_keys.lock();
+        if ufvk.is_empty() {
+            viewing_keys.remove(&address);
+        } else {
+            let vk = decode_viewing_key(&network, &address, &ufvk)?;
+            viewing_keys.insert(address.clone(), vk);
+        }
+        persist_viewing_key(&context, &address, &ufvk)
+    })
+}
+
+/// The viewing key of the vault with this transparent address
+/// from the encoding of a unified full viewing key
+pub(crate) fn decode_viewing_key(
+    network: &Network,
+    address: &str,
+    ufvk: &str,
+) -> Result<VaultViewingKey, ZcashError> {
+    let key = UnifiedFullViewingKey::decode(network, ufvk)
+        .map_err(|e| ZcashError::AssertError(format!("Invalid viewing key: {e}")))?;
+    let vk = VaultViewingKey::new(network, address, key.sapling(), key.orchard())?;
+    Ok(VaultViewingKey {
+        ufvk: ufvk.to_string(),
+        ..vk
+    })
+}
+
+/// Unified address for deposits from the shielded pools
+/// The vault must have a viewing key, see `set_vault_viewing_key`
+pub fn get_vault_deposit_address(pubkey: Vec<u8>) -> Result<String, ZcashError> {
+    let address = get_vault_address(pubkey)?;
+    uniffi_export!(context, {
+        context
+            .viewing_keys
+            .lock()
+            .get(&address)
+            .map(|vk| vk.deposit_address.clone())
+            .ok_or(ZcashError::AssertError(format!(
+                "No viewing key for the vault {address}"
+            )))
+    })
+}
+
+// Viewing key of the vault with this transparent address
+pub(crate) fn get_vault_viewing_key(address: &str) -> Option<VaultViewingKey> {
+    uniffi_export!(context, {
+        context.viewing_keys.lock().get(address).cloned()
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use orchard::{
+        builder::{Builder, BundleType},
+        keys::SpendingKey,
+        value::NoteValue,
+        Anchor,
+    };
+    use rand_core::OsRng;
+    use zcash_keys::address::Address;
+
+    use super::*;
+
+    const VAULT_ADDR: &str = "tmGys6dBuEGjch5LFnhdo5gpSa7jiNRWse6";
+
+    #[test]
+    fn test_orchard_deposit() {
+        let network = Network::Regtest;
+        let fvk = FullViewingKey::from(&SpendingKey::from_bytes([7u8; 32]).unwrap());
+        let other = FullViewingKey::from(&SpendingKey::from_bytes([8u8; 32]).unwrap());
+        let vk = VaultViewingKey::new(&network, VAULT_ADDR, None, Some(&fvk)).unwrap();
+
+        let Some(Address::Unified(ua)) = Address::decode(&network, &vk.deposit_address) else {
+            panic!("Not a UA");
+        };
+        assert_eq!(ua.orchard(), Some(&fvk.address_at(0u32, Scope::External)));
+        assert!(ua.sapling().is_none());
+        assert_eq!(ua.transparent().unwrap().encode(&network), VAULT_ADDR);
+
+        let mut builder = Builder::new(BundleType::DEFAULT, Anchor::empty_tree());
+        let memo = Memo::text("MEMO");
+        builder
+            .add_output(
+                None,
+                fvk.address_at(0u32, Scope::External),
+                NoteValue::from_raw(10_000_000),
+                memo.to_memo_bytes().unwrap(),
+            )
+            .unwrap();
+        builder
+            .add_output(
+                None,
+                other.address_at(0u32, Scope::External),
+                NoteValue::from_raw(20_000_000),
+                None,
+            )
+            .unwrap();
+        let (bundle, _) = builder.build::<i64>(&mut OsRng).unwrap().unwrap();
+
+        // only the note to the vault
+        let outputs = vk.decrypt_orchard(&bundle);
+        assert_eq!(outputs.len(), 1);
+        assert_eq!(outputs[0].address, vk.deposit_address);
+        assert_eq!(outputs[0].amount, 10_000_000);
+        assert_eq!(outputs[0].memo, memo);
+    }
+
+    #[test]
+    fn test_no_shielded_key() {
+        let r = VaultViewingKey::new(&Network::Regtest, VAULT_ADDR, None, None);
+        assert!(matches!(r, Err(ZcashError::AssertError(_))));
+    }
+}
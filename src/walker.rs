@@ -0,0 +1,605 @@
+use std::{
+    collections::{BTreeMap, HashMap, HashSet, VecDeque},
+    sync::Arc,
+};
+
+use anyhow::anyhow;
+use parking_lot::Mutex;
+use uuid::Uuid;
+use zcash_encoding::CompactSize;
+use zcash_primitives::{block::BlockHeader, transaction::Transaction};
+use zcash_protocol::consensus::BranchId;
+
+use crate::{
+    analyze::{analyze_tx, tout_address, tx_inputs, tx_outputs},
+    config::{Config, Context},
+    network::Network,
+    rpc::{json_request, map_rpc_error},
+    scan::{fetch_raw_tx, known_vault_addresses, BlockTxs, TxInput, VaultKeys, VaultTx},
+    to_zcasherror, uniffi_async_export,
+    wallet::UTXO,
+    ZcashError,
+};
+
+// number of blocks that a walker can rewind
+const UNDO_DEPTH: usize = 100;
+
+/// A change to the UTXO sets, with what it takes to revert it
+enum UtxoChange {
+    // (vault address, utxo)
+    Spent(String, UTXO),
+    // (vault address, outpoint)
+    Created(String, (String, u32)),
+}
+
+/// The changes of a walked block, in the order they were made
+struct BlockUndo {
+    height: u32,
+    prev_hash: String,
+    changes: Vec<UtxoChange>,
+}
+
+/// Scans the vaults by walking the blocks with `getblock <hash> 0`
+/// and keeps the UTXO set of every vault.
+/// Unlike `scan_blocks`, it does not need the address index
+/// of the node (`-addressindex`, `-spentindex`, `-insightexplorer`)
+pub struct BlockWalker {
+    vaults: Vec<VaultKeys>,
+    known_vaults: HashSet<String>,
+    // vault address -> outpoint -> utxo
+    utxos: HashMap<String, BTreeMap<(String, u32), UTXO>>,
+    height: u32,
+    hash: String,
+    // the last blocks walked, oldest first
+    undo: VecDeque<BlockUndo>,
+}
+
+impl BlockWalker {
+    fn new(
+        vaults: Vec<VaultKeys>,
+        known_vaults: HashSet<String>,
+        height: u32,
+        hash: String,
+    ) -> Self {
+        let utxos = vaults
+            .iter()
+            .map(|v| (v.address.clone(), BTreeMap::new()))
+            .collect();
+        BlockWalker {
+            vaults,
+            known_vaults,
+            utxos,
+            height,
+            hash,
+            undo: VecDeque::new(),
+        }
+    }
+
+    /// Update the UTXO sets with the tx and record the changes
+    /// Returns the indices of the vaults it touches and
+    /// the previous outputs of the vault inputs
+    fn apply_tx(
+        &mut self,
+        network: &Network,
+        height: u32,
+        tx: &Transaction,
+        changes: &mut Vec<UtxoChange>,
+    ) -> (Vec<usize>, Vec<Option<TxInput>>) {
+        let mut touched = HashSet::new();
+        let mut ptouts = vec![];
+        for (txid, vout) in tx_inputs(tx) {
+            let outpoint = (txid, vout);
+            let mut ptout = None;
+            for (i, vault) in self.vaults.iter().enumerate() {
+                let utxos = self.utxos.get_mut(&vault.address).unwrap();
+                if let Some(utxo) = utxos.remove(&outpoint) {
+                    touched.insert(i);
+                    ptout = Some(TxInput {
+                        txid: utxo.txid.clone(),
+                        vout: utxo.vout,
+                        address: vault.address.clone(),
+                        value: utxo.value,
+                    });
+                    changes.push(UtxoChange::Spent(vault.address.clone(), utxo));
+                    break;
+                }
+            }
+            ptouts.push(ptout);
+        }
+        let txid = tx.txid().to_string();
+        // shielded deposits are vault txs too,
+        // even if they do not change the UTXO sets
+        for (i, vault) in self.vaults.iter().enumerate() {
+            if vault.receives_notes(tx) {
+                touched.insert(i);
+            }
+        }
+        for (vout, tout) in tx_outputs(tx).iter().enumerate() {
+            let address = tout_address(network, tout);
+            for (i, vault) in self.vaults.iter().enumerate() {
+                if address == vault.address {
+                    touched.insert(i);
+                    let utxos = self.utxos.get_mut(&vault.address).unwrap();
+                    utxos.insert(
+                        (txid.clone(), vout as u32),
+                        UTXO {
+                            txid: txid.clone(),
+                            height,
+                            vout: vout as u32,
+                            script: hex::encode(&tout.script_pubkey.0),
+                            value: tout.value.into_u64(),
+                        },
+                    );
+                    changes.push(UtxoChange::Created(
+                        vault.address.clone(),
+                        (txid.clone(), vout as u32),
+                    ));
+                }
+            }
+        }
+        let mut touched = touched.into_iter().collect::<Vec<_>>();
+        touched.sort();
+        (touched, ptouts)
+    }
+
+    /// Walk the block after the current one and return its vault
+    /// txs. If it fails, the UTXO sets are left as they were
+    async fn walk_block(
+        &mut self,
+        config: &Config,
+        network: &Network,
+        height: u32,
+    ) -> Result<Vec<VaultTx>, ZcashError> {
+        let hash = get_block_hash(config, height).await?;
+        let (header, block_txs) = get_block(config, &hash).await?;
+        if header.prev_block.to_string() != self.hash {
+            return Err(ZcashError::Reorg);
+        }
+
+        let mut changes = vec![];
+        let txs = self
+            .walk_txs(config, network, height, &block_txs, &mut changes)
+            .await;
+        let txs = match txs {
+            Ok(txs) => txs,
+            Err(e) => {
+                self.revert(changes);
+                return Err(e);
+            }
+        };
+
+        self.undo.push_back(BlockUndo {
+            height,
+            prev_hash: std::mem::replace(&mut self.hash, hash),
+            changes,
+        });
+        while self.undo.len() > UNDO_DEPTH {
+            self.undo.pop_front();
+        }
+        self.height = height;
+        Ok(txs)
+    }
+
+    async fn walk_txs(
+        &mut self,
+        config: &Config,
+        network: &Network,
+        height: u32,
+        block_txs: &[Transaction],
+        changes: &mut Vec<UtxoChange>,
+    ) -> Result<Vec<VaultTx>, ZcashError> {
+        let mut txs = vec![];
+        for tx in block_txs {
+            // coinbase txs cannot spend from the vault
+            // and we do not expect the vault to be mined into
+            if tx.transparent_bundle().map(|b| b.is_coinbase()) == Some(true) {
+                continue;
+            }
+            let (touched, ptouts) = self.apply_tx(network, height, tx, changes);
+            if touched.is_empty() {
+                continue;
+            }
+            let mut prevouts = vec![];
+            for ((txid, vout), ptout) in tx_inputs(tx).into_iter().zip(ptouts) {
+                let ptout = match ptout {
+                    Some(ptout) => ptout,
+                    None => resolve_prevout(config, txid, vout).await,
+                };
+                prevouts.push(ptout);
+            }
+            for i in touched {
+                if let Some(vtx) = analyze_tx(
+                    network,
+                    tx,
+                    &prevouts,
+                    height,
+                    &self.vaults[i],
+                    &self.known_vaults,
+                )? {
+                    tracing::info!("{:?}", vtx);
+                    txs.push(vtx);
+                }
+            }
+        }
+        Ok(txs)
+    }
+
+    /// Undo the changes, the last one first
+    fn revert(&mut self, changes: Vec<UtxoChange>) {
+        for change in changes.into_iter().rev() {
+            match change {
+                UtxoChange::Spent(address, utxo) => {
+                    let utxos = self.utxos.get_mut(&address).unwrap();
+                    utxos.insert((utxo.txid.clone(), utxo.vout), utxo);
+                }
+                UtxoChange::Created(address, outpoint) => {
+                    let utxos = self.utxos.get_mut(&address).unwrap();
+                    utxos.remove(&outpoint);
+                }
+            }
+        }
+    }
+
+    /// Undo the blocks walked after `height`
+    fn rewind(&mut self, height: u32) -> Result<(), ZcashError> {
+        if height > self.height {
+            return Err(ZcashError::AssertError(format!(
+                "The walker is at {}, below {height}",
+                self.height
+            )));
+        }
+        let oldest = self
+            .undo
+            .front()
+            .map(|u| u.height)
+            .unwrap_or(self.height + 1);
+        if height + 1 < oldest {
+            return Err(ZcashError::AssertError(format!(
+                "The walker cannot rewind below {}",
+                oldest - 1
+            )));
+        }
+        while self.height > height {
+            let undo = self.undo.pop_back().unwrap();
+            self.revert(undo.changes);
+            self.height = undo.height - 1;
+            self.hash = undo.prev_hash;
+        }
+        Ok(())
+    }
+
+    async fn scan(
+        &mut self,
+        context: &Context,
+        max_blocks: u32,
+    ) -> Result<Option<BlockTxs>, ZcashError> {
+        let config = &context.config;
+        let network = config.network();
+        let tip_height = get_block_count(config).await?;
+        if self.height > tip_height || get_block_hash(config, self.height).await? != self.hash {
+            return Err(ZcashError::Reorg);
+        }
+        if self.height >= tip_height {
+            return Ok(None);
+        }
+        let start_height = self.height + 1;
+        let end_height = tip_height.min(self.height + max_blocks);
+
+        let mut start_hash = String::new();
+        let mut txs = vec![];
+        for height in start_height..=end_height {
+            match self.walk_block(config, &network, height).await {
+                Ok(block_txs) => {
+                    if height == start_height {
+                        start_hash = self.hash.clone();
+                    }
+                    txs.extend(block_txs);
+                }
+                // return the blocks walked so far, the next
+                // scan starts again at the failed block
+                Err(e) if height > start_height => {
+                    tracing::warn!("Walk stopped at {height}: {e}");
+                    break;
+                }
+                Err(e) => return Err(e),
+            }
+        }
+
+        Ok(Some(BlockTxs {
+            start_hash,
+            end_hash: self.hash.clone(),
+            start_height,
+            end_height: self.height,
+            txs,
+        }))
+    }
+}
+
+pub(crate) async fn get_block(
+    config: &Config,
+    hash: &str,
+) -> Result<(BlockHeader, Vec<Transaction>), ZcashError> {
+    let id = Uuid::new_v4().to_string();
+    let rep = json_request(config, &id, "getblock", vec![hash.into(), 0.into()])
+        .await
+        .map_err(map_rpc_error)?;
+    let data = rep
+        .as_str()
+        .and_then(|h| hex::decode(h).ok())
+        .ok_or(ZcashError::AssertError(
+            "Failed to parse getblock reply".into(),
+        ))?;
+    read_block(&data)
+}
+
+fn read_block(data: &[u8]) -> Result<(BlockHeader, Vec<Transaction>), ZcashError> {
+    let mut reader = data;
+    let header = BlockHeader::read(&mut reader)
+        .map_err(to_zcasherror(anyhow!("Cannot parse block header")))?;
+    let count =
+        CompactSize::read(&mut reader).map_err(to_zcasherror(anyhow!("Cannot parse block")))?;
+    let mut txs = vec![];
+    for _ in 0..count {
+        let tx = Transaction::read(&mut reader, BranchId::Nu6)
+            .map_err(to_zcasherror(anyhow!("Cannot parse block transaction")))?;
+        txs.push(tx);
+    }
+    Ok((header, txs))
+}
+
+// Without -txindex, the node cannot return the txs that do
+// not belong to its wallet. The counterparty stays unknown then
+async fn resolve_prevout(config: &Config, txid: String, vout: u32) -> TxInput {
+    let network = config.network();
+    let tout = fetch_raw_tx(config, &txid)
+        .await
+        .ok()
+        .and_then(|tx| tx_outputs(&tx).get(vout as usize).cloned());
+    TxInput {
+        address: tout
+            .as_ref()
+            .map(|o| tout_address(&network, o))
+            .unwrap_or_default(),
+        value: tout.map(|o| o.value.into_u64()).unwrap_or_default(),
+        txid,
+        vout,
+    }
+}
+
+pub(crate) async fn get_block_count(config: &Config) -> Result<u32, ZcashError> {
+    let id = Uuid::new_v4().to_string();
+    let rep = json_request(config, &id, "getblockcount", vec![])
+        .await
+        .map_err(map_rpc_error)?;
+    let height = rep.as_u64().ok_or(ZcashError::AssertError(
+        "Failed to retrieve block count".into(),
+    ))?;
+    Ok(height as u32)
+}
+
+pub(crate) async fn get_block_hash(config: &Config, height: u32) -> Result<String, ZcashError> {
+    let id = Uuid::new_v4().to_string();
+    let rep = json_request(config, &id, "getblockhash", vec![height.into()])
+        .await
+        .map_err(map_rpc_error)?;
+    let hash = rep
+        .as_str()
+        .ok_or(ZcashError::AssertError(format!(
+            "Failed to retrieve block hash at {height}"
+        )))?
+        .to_string();
+    Ok(hash)
+}
+
+type SharedWalker = Arc<Mutex<BlockWalker>>;
+
+lazy_static::lazy_static! {
+    static ref WALKERS: Mutex<(u64, HashMap<u64, SharedWalker>)> =
+        Mutex::new((0, HashMap::new()));
+}
+
+// The walker alone, so that a scan does not hold the other ones
+fn get_walker(walker: u64) -> Result<SharedWalker, ZcashError> {
+    WALKERS
+        .lock()
+        .1
+        .get(&walker)
+        .cloned()
+        .ok_or(ZcashError::AssertError(format!("Unknown walker {walker}")))
+}
+
+/// Create a walker for the given vaults that starts after the
+/// block `from_hash`, or after the genesis block if `from_hash`
+/// is empty. The vaults must not have any UTXO before `from_hash`.
+/// Returns the walker handle
+pub fn block_walker_new(
+    pubkeys: Vec<Vec<u8>>,
+    from_hash: String,
+    known_vaults: Vec<Vec<u8>>,
+) -> Result<u64, ZcashError> {
+    let vaults = VaultKeys::from_pubkeys(pubkeys)?;
+    let known_vaults = known_vault_addresses(known_vaults)?;
+    let (height, hash) = uniffi_async_export!(context, {
+        let config = &context.config;
+        let from_hash = if from_hash.is_empty() {
+            get_block_hash(config, 0).await?
+        } else {
+            from_hash
+        };
+        let id = Uuid::new_v4().to_string();
+        let rep = json_request(
+            config,
+            &id,
+            "getblockheader",
+            vec![from_hash.clone().into()],
+        )
+        .await
+        .map_err(map_rpc_error)?;
+        let height = rep["height"].as_u64().ok_or(ZcashError::AssertError(
+            "Failed to parse getblockheader reply".into(),
+        ))?;
+        Ok::<_, ZcashError>((height as u32, from_hash))
+    })?;
+
+    let walker = BlockWalker::new(vaults, known_vaults, height, hash);
+    let mut walkers = WALKERS.lock();
+    walkers.0 += 1;
+    let handle = walkers.0;
+    walkers.1.insert(handle, Arc::new(Mutex::new(walker)));
+    Ok(handle)
+}
+
+/// Walk at most `max_blocks` blocks and return the vault txs
+/// Returns None when the walker is at the tip. If a block
+/// fails, the blocks walked before it are returned.
+/// On `Reorg`, call `block_walker_rewind`
+pub fn block_walker_scan(walker: u64, max_blocks: u32) -> Result<Option<BlockTxs>, ZcashError> {
+    if max_blocks == 0 {
+        return Err(ZcashError::AssertError(
+            "max_blocks must be positive".into(),
+        ));
+    }
+    let walker = get_walker(walker)?;
+    let mut walker = walker.lock();
+    uniffi_async_export!(context, { walker.scan(&context, max_blocks).await })
+}
+
+/// Undo the blocks walked after `height`, for example after
+/// a `Reorg` error. Only the last 100 blocks can be undone
+pub fn block_walker_rewind(walker: u64, height: u32) -> Result<(), ZcashError> {
+    let walker = get_walker(walker)?;
+    let mut walker = walker.lock();
+    walker.rewind(height)
+}
+
+/// UTXOs of the vault as of the last block walked
+pub fn block_walker_utxos(walker: u64, pubkey: Vec<u8>) -> Result<Vec<UTXO>, ZcashError> {
+    let vault = VaultKeys::new(pubkey)?;
+    let walker = get_walker(walker)?;
+    let walker = walker.lock();
+    let utxos = walker
+        .utxos
+        .get(&vault.address)
+        .ok_or(ZcashError::AssertError(format!(
+            "{} is not scanned by this walker",
+            vault.address
+        )))?;
+    Ok(utxos.values().cloned().collect())
+}
+
+pub fn block_walker_balance(walker: u64, pubkey: Vec<u8>) -> Result<u64, ZcashError> {
+    let utxos = block_walker_utxos(walker, pubkey)?;
+    Ok(utxos.iter().map(|u| u.value).sum())
+}
+
+pub fn block_walker_close(walker: u64) {
+    WALKERS.lock().1.remove(&walker);
+}
+
+#[cfg(test)]
+mod tests {
+    use crate::analyze::{read_tx, tests::DEPOSIT};
+
+    use super::*;
+
+    const VAULT_ADDR: &str = "tmWksakBYGg7Lqtm1EqSqvPkVYJHYxGq6Za";
+    const OTHER_VAULT_ADDR: &str = "tmP9jLgTnhDdKdWJCm4BT2t6acGnxqP14yU";
+
+    fn vault(address: &str) -> VaultKeys {
+        VaultKeys {
+            pubkey: vec![],
+            address: address.to_string(),
+            ovk: vec![0u8; 32],
+            viewing_key: None,
+        }
+    }
+
+    #[test]
+    fn test_utxo_set() {
+        let network = Network::Regtest;
+        let mut walker = BlockWalker::new(
+            vec![vault(VAULT_ADDR), vault(OTHER_VAULT_ADDR)],
+            HashSet::new(),
+            0,
+            String::new(),
+        );
+        let prev_txid = "82643f223ae8c3283af56e50481bd81e89ef951478a08fe450756cf4c7a9b186";
+        walker.utxos.get_mut(OTHER_VAULT_ADDR).unwrap().insert(
+            (prev_txid.to_string(), 0),
+            UTXO {
+                txid: prev_txid.to_string(),
+                height: 1,
+                vout: 0,
+                script: String::new(),
+                value: 120_000_000,
+            },
+        );
+
+        // the other vault pays the vault and gets its change back
+        let tx = read_tx(&hex::decode(DEPOSIT).unwrap()).unwrap();
+        let mut changes = vec![];
+        let (touched, ptouts) = walker.apply_tx(&network, 10, &tx, &mut changes);
+        assert_eq!(touched, vec![0, 1]);
+        let ptout = ptouts[0].as_ref().unwrap();
+        assert_eq!(ptout.address, OTHER_VAULT_ADDR);
+        assert_eq!(ptout.value, 120_000_000);
+
+        let utxos = &walker.utxos[VAULT_ADDR];
+        assert_eq!(utxos.len(), 1);
+        let utxo = utxos.values().next().unwrap();
+        assert_eq!(utxo.vout, 1);
+        assert_eq!(utxo.value, 10_000_000);
+        assert_eq!(
+            utxo.script,
+            "76a914e6d4b9d2c408bf6bd44523b3b6607de4853b806088ac"
+        );
+        let utxos = &walker.utxos[OTHER_VAULT_ADDR];
+        assert_eq!(utxos.len(), 1);
+        assert_eq!(utxos.values().next().unwrap().value, 109_985_000);
+    }
+
+    #[test]
+    fn test_rewind() {
+        let network = Network::Regtest;
+        let mut walker = BlockWalker::new(
+            vec![vault(VAULT_ADDR), vault(OTHER_VAULT_ADDR)],
+            HashSet::new(),
+            9,
+            "h9".to_string(),
+        );
+        let prev_txid = "82643f223ae8c3283af56e50481bd81e89ef951478a08fe450756cf4c7a9b186";
+        walker.utxos.get_mut(OTHER_VAULT_ADDR).unwrap().insert(
+            (prev_txid.to_string(), 0),
+            UTXO {
+                txid: prev_txid.to_string(),
+                height: 1,
+                vout: 0,
+                script: String::new(),
+                value: 120_000_000,
+            },
+        );
+
+        // block 10 has the deposit
+        let tx = read_tx(&hex::decode(DEPOSIT).unwrap()).unwrap();
+        let mut changes = vec![];
+        walker.apply_tx(&network, 10, &tx, &mut changes);
+        assert_eq!(changes.len(), 3);
+        walker.undo.push_back(BlockUndo {
+            height: 10,
+            prev_hash: walker.hash.clone(),
+            changes,
+        });
+        walker.height = 10;
+        walker.hash = "h10".to_string();
+
+        assert!(walker.rewind(8).is_err());
+        walker.rewind(9).unwrap();
+        assert_eq!(walker.height, 9);
+        assert_eq!(walker.hash, "h9");
+        assert!(walker.undo.is_empty());
+        assert!(walker.utxos[VAULT_ADDR].is_empty());
+        let utxos = &walker.utxos[OTHER_VAULT_ADDR];
+        assert_eq!(utxos.len(), 1);
+        assert_eq!(utxos.values().next().unwrap().value, 120_000_000);
+    }
+}